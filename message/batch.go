@@ -0,0 +1,271 @@
+package message
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// A message batches its Sets. Instead of rewriting the whole payload, a
+// Set rewrites only the top-level field it falls in, kept aside in an
+// overlay; the overlay is spliced into the payload, with one copy, when the
+// message is next sent or read as a whole. Reads of a path go to the
+// changed field or to the payload, through a small cache of resolved paths,
+// so reading a field again does not parse the payload or resolve its LMO
+// blob again. Both are invisible to callers: a Set fails or succeeds as it
+// always did, and a read sees every Set before it.
+
+// maxCachedPaths bounds the path cache; it is emptied when full.
+const maxCachedPaths = 64
+
+// overlay holds the top-level fields changed since the last flush.
+type overlay struct {
+	// spans locates each top-level field of the payload, by unescaped key.
+	spans  map[string]span
+	fields map[string]*field
+	order  []string // of the fields, first changed first
+	empty  bool     // the payload has no fields
+}
+
+// span is where a top-level field's value sits in the payload.
+type span struct {
+	keyRaw     string // quoted, as in the payload
+	index, len int
+}
+
+// field is a top-level field being changed.
+type field struct {
+	at  span // len is 0 when the field is new
+	doc []byte
+}
+
+// set applies a Set of value at path, msg.mu held.
+func (msg *message) set(path string, value interface{}) (err error) {
+	key, nested, ok := topKey(path)
+	if ok && msg.over == nil {
+		msg.over, ok = indexFields(msg.data)
+	}
+	var f *field
+	if ok {
+		f = msg.over.field(key, msg.data)
+		// A write into a field holding BlobRefs may fall below one, which
+		// must be inlined first.
+		ok = !nested || Resolve == nil || !bytes.Contains(f.doc, []byte("__ref"))
+	}
+	if !ok {
+		msg.flush()
+		if err = msg.inline(path, false); err != nil {
+			return err
+		}
+		msg.data, err = sjson.SetBytes(msg.data, path, value)
+		msg.changed()
+		return err
+	}
+
+	// Applied now to the field alone: same result, same errors, and later
+	// changes to value by the caller do not show.
+	doc, err := sjson.SetBytes(f.doc, path, value)
+	if err != nil {
+		return err
+	}
+	f.doc = doc
+	if msg.over.fields[key] == nil {
+		msg.over.fields[key] = f
+		msg.over.order = append(msg.over.order, key)
+	}
+	msg.cache = nil
+	return nil
+}
+
+// field returns the changed field key, or a new one holding its value in
+// data, as a {key: value} document.
+func (over *overlay) field(key string, data []byte) *field {
+	if f := over.fields[key]; f != nil {
+		return f
+	}
+	f := &field{doc: []byte("{}")}
+	if at, ok := over.spans[key]; ok {
+		f.at = at
+		value := data[at.index : at.index+at.len]
+		f.doc = make([]byte, 0, len(at.keyRaw)+len(value)+3)
+		f.doc = append(append(append(append(append(f.doc, '{'), at.keyRaw...), ':'), value...), '}')
+	}
+	return f
+}
+
+// indexFields locates the top-level fields of data, without copying it.
+// ok is false when data is not an object; an empty payload is not one, as
+// sjson makes it an array or an object depending on the path.
+func indexFields(data []byte) (*overlay, bool) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	// gjson's indexes are relative to the first non-space byte.
+	lead := len(data) - len(trimmed)
+	doc := gjson.Parse(unsafe.String(unsafe.SliceData(trimmed), len(trimmed)))
+	if !doc.IsObject() {
+		return nil, false
+	}
+	over := &overlay{spans: map[string]span{}, fields: map[string]*field{}, empty: true}
+	doc.ForEach(func(key, value gjson.Result) bool {
+		over.empty = false
+		name := key.String()
+		if _, dup := over.spans[name]; !dup { // sjson changes the first
+			over.spans[name] = span{
+				keyRaw: strings.Clone(key.Raw),
+				index:  lead + value.Index,
+				len:    len(value.Raw),
+			}
+		}
+		return true
+	})
+	return over, true
+}
+
+// topKey returns the first key of path, unescaped, and whether there is
+// more to the path. ok is false for paths sjson rejects or that need the
+// whole payload.
+func topKey(path string) (key string, nested, ok bool) {
+	if path == "" || path[0] == ':' || strings.ContainsAny(path, "|#@*?") {
+		return "", false, false
+	}
+	end := len(path)
+	escaped := false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' {
+			escaped = true
+			i++
+			continue
+		}
+		if path[i] == '.' {
+			end, nested = i, true
+			break
+		}
+	}
+	key = path[:end]
+	if escaped {
+		key = unescapePath(key)
+	}
+	return key, nested, true
+}
+
+func unescapePath(part string) string {
+	var b strings.Builder
+	for i := 0; i < len(part); i++ {
+		if part[i] == '\\' && i+1 < len(part) {
+			i++
+		}
+		b.WriteByte(part[i])
+	}
+	return b.String()
+}
+
+// flush splices the overlay into the payload, msg.mu held. Changed fields
+// keep their place; new ones are added at the end, where sjson adds them.
+func (msg *message) flush() {
+	over := msg.over
+	if over == nil {
+		return
+	}
+	msg.over = nil
+	if len(over.fields) == 0 {
+		return
+	}
+
+	grow := 0
+	var changed []*field
+	for _, f := range over.fields {
+		grow += len(f.doc) - f.at.len
+		if f.at.len > 0 {
+			changed = append(changed, f)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].at.index < changed[j].at.index })
+
+	data := msg.data
+	end := bytes.LastIndexByte(data, '}')
+	out := make([]byte, 0, len(data)+grow)
+	last := 0
+	if len(changed) < len(over.fields) {
+		// Adding a field, sjson drops the space around the object.
+		last = len(data) - len(bytes.TrimLeft(data, " \t\r\n"))
+	}
+	for _, f := range changed {
+		out = append(out, data[last:f.at.index]...)
+		out = append(out, fieldValue(f.doc)...)
+		last = f.at.index + f.at.len
+	}
+	out = append(out, data[last:end]...)
+	empty, tail := over.empty, data[end:]
+	for _, key := range over.order {
+		f := over.fields[key]
+		if f.at.len > 0 {
+			continue
+		}
+		if !empty {
+			out = append(out, ',')
+		}
+		empty = false
+		out = append(out, f.doc[1:len(f.doc)-1]...) // "key":value
+		tail = data[end : end+1]                    // sjson drops what follows
+	}
+	msg.data = append(out, tail...)
+	msg.changed()
+}
+
+// fieldValue returns the value of the single field in doc, in place.
+func fieldValue(doc []byte) []byte {
+	var value []byte
+	gjson.Parse(unsafe.String(unsafe.SliceData(doc), len(doc))).ForEach(func(_, v gjson.Result) bool {
+		value = doc[v.Index : v.Index+len(v.Raw)]
+		return false
+	})
+	return value
+}
+
+// get returns the value at path through the path cache, BlobRefs
+// resolved. msg.mu must be held.
+func (msg *message) get(path string) gjson.Result {
+	path, err := msg.gjsonPath(path)
+	if err != nil {
+		return gjson.Result{}
+	}
+	if result, ok := msg.cache[path]; ok {
+		return result
+	}
+
+	// A path into a changed field is read from the field, any other from
+	// the payload, where only changed fields are out of date.
+	data := msg.data
+	if msg.over != nil {
+		if key, _, ok := topKey(path); !ok {
+			msg.flush()
+			data = msg.data
+		} else if f := msg.over.fields[key]; f != nil {
+			data = f.doc
+		}
+	}
+
+	var result gjson.Result
+	if Resolve != nil {
+		if resolved, err := Resolve(data, path); err == nil && resolved.Exists() {
+			result = resolved
+		}
+	}
+	if !result.Exists() {
+		result = gjson.GetBytes(data, path)
+	}
+	if msg.cache == nil || len(msg.cache) >= maxCachedPaths {
+		msg.cache = make(map[string]gjson.Result)
+	}
+	msg.cache[path] = result
+	return result
+}
+
+// changed drops what was derived from the old payload, msg.mu held.
+func (msg *message) changed() {
+	msg.over = nil
+	msg.cache = nil
+}
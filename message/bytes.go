@@ -0,0 +1,96 @@
+package message
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/tidwall/gjson"
+)
+
+// Hooks the runtime sets so binary data set with SetBytes lives in LMO
+// blobs instead of the message.
+var (
+	// PutBytes stores data as a blob and returns its BlobRef marker; ok is
+	// false when the robot cannot resolve blobs.
+	PutBytes func(data []byte, mime string) (ref json.RawMessage, ok bool, err error)
+	// OpenBlob streams the data of a "bytes" BlobRef marker.
+	OpenBlob func(ref json.RawMessage) (io.ReadCloser, error)
+)
+
+// ErrNoBlobStore is returned by OpenReader for a blob when the package has
+// no blob store to read it from.
+var ErrNoBlobStore = errors.New("no blob store")
+
+func (msg *message) SetBytes(path string, data []byte, mime string) error {
+	value, err := BytesValue(data, mime)
+	if err != nil {
+		return &PathError{Path: path, Err: err, Want: "bytes"}
+	}
+	return msg.Set(path, value)
+}
+
+func (msg *message) OpenReader(path string) (io.ReadCloser, error) {
+	msg.mu.Lock()
+	msg.flush()
+	// A blob at path itself is streamed; anything else, including a blob
+	// inside a resolved parent, comes as base64.
+	converted, err := msg.gjsonPath(path)
+	if err != nil {
+		msg.mu.Unlock()
+		return nil, err
+	}
+	value := gjson.GetBytes(msg.data, converted)
+	if !isBytesRef(value) {
+		value = msg.get(path)
+	}
+	msg.mu.Unlock()
+	return OpenBytes(path, value)
+}
+
+// BytesValue returns what SetBytes stores for data: a BlobRef marker when
+// the runtime keeps blobs, the base64 string of data otherwise.
+func BytesValue(data []byte, mime string) (interface{}, error) {
+	if PutBytes != nil {
+		ref, ok, err := PutBytes(data, mime)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return ref, nil
+		}
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// OpenBytes reads value, found at path, as SetBytes stored it: a "bytes"
+// BlobRef marker or a base64 string. Anything else is a *PathError.
+func OpenBytes(path string, value gjson.Result) (io.ReadCloser, error) {
+	switch {
+	case !value.Exists():
+		return nil, &PathError{Path: path, Err: ErrMissing, Want: "bytes"}
+	case isBytesRef(value):
+		if OpenBlob == nil {
+			return nil, &PathError{Path: path, Err: ErrNoBlobStore, Want: "bytes"}
+		}
+		r, err := OpenBlob(json.RawMessage(value.Raw))
+		if err != nil {
+			return nil, &PathError{Path: path, Err: err, Want: "bytes"}
+		}
+		return r, nil
+	case value.Type == gjson.String:
+		data, err := base64.StdEncoding.DecodeString(value.Str)
+		if err != nil {
+			return nil, &PathError{Path: path, Err: ErrWrongType, Want: "bytes", Got: "string", Cause: err}
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil, &PathError{Path: path, Err: ErrWrongType, Want: "bytes", Got: jsonType(json.RawMessage(value.Raw))}
+}
+
+// isBytesRef reports whether v is a BlobRef marker to binary data.
+func isBytesRef(v gjson.Result) bool {
+	return isBlobRef(v) && v.Get("__type").String() == "bytes"
+}
@@ -0,0 +1,115 @@
+package message
+
+import (
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// ChangeKind is what happened to a path of a message.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Changed ChangeKind = "changed"
+	Removed ChangeKind = "removed"
+)
+
+// Change is a path of the payload that differs from the message as the node
+// received it. Delta is how many bytes of JSON the value grew by, negative
+// when it shrank; a value in an LMO blob counts as its reference.
+type Change struct {
+	Path  string     `json:"path"`
+	Kind  ChangeKind `json:"kind"`
+	Delta int        `json:"delta"`
+}
+
+func (msg *message) Changes() []Change {
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	msg.flush()
+	return DiffJSON(msg.orig, msg.data)
+}
+
+// DiffJSON lists the paths where after differs from before, as
+// Context.Changes does: objects and arrays are compared key by key and index
+// by index, and only the outermost path that was added or removed is
+// listed. Paths are in the grammar of path.go, so each can be passed to Get,
+// and come in message order. An empty payload counts as an empty object.
+func DiffJSON(before, after []byte) []Change {
+	var changes []Change
+	diffValues(&changes, "", parsePayload(before), parsePayload(after))
+	return changes
+}
+
+func parsePayload(data []byte) gjson.Result {
+	if v := gjson.ParseBytes(data); v.Exists() {
+		return v
+	}
+	return gjson.Parse("{}")
+}
+
+func diffValues(changes *[]Change, path string, before, after gjson.Result) {
+	switch {
+	case !before.Exists() && !after.Exists():
+	case !before.Exists():
+		*changes = append(*changes, Change{Path: path, Kind: Added, Delta: len(after.Raw)})
+	case !after.Exists():
+		*changes = append(*changes, Change{Path: path, Kind: Removed, Delta: -len(before.Raw)})
+	case before.Raw == after.Raw:
+	case before.IsObject() && after.IsObject() && !isBlobRef(before) && !isBlobRef(after):
+		diffObjects(changes, path, before, after)
+	case before.IsArray() && after.IsArray():
+		diffArrays(changes, path, before.Array(), after.Array())
+	default:
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Delta: len(after.Raw) - len(before.Raw)})
+	}
+}
+
+func diffObjects(changes *[]Change, path string, before, after gjson.Result) {
+	// The first of duplicate keys counts, as for Get.
+	values := map[string]gjson.Result{}
+	after.ForEach(func(key, value gjson.Result) bool {
+		if _, dup := values[key.String()]; !dup {
+			values[key.String()] = value
+		}
+		return true
+	})
+	seen := map[string]bool{}
+	before.ForEach(func(key, value gjson.Result) bool {
+		name := key.String()
+		if !seen[name] {
+			seen[name] = true
+			diffValues(changes, joinPath(path, escapeKey(name)), value, values[name])
+		}
+		return true
+	})
+	after.ForEach(func(key, value gjson.Result) bool {
+		name := key.String()
+		if !seen[name] {
+			seen[name] = true
+			diffValues(changes, joinPath(path, escapeKey(name)), gjson.Result{}, value)
+		}
+		return true
+	})
+}
+
+func diffArrays(changes *[]Change, path string, before, after []gjson.Result) {
+	for i := 0; i < len(before) || i < len(after); i++ {
+		var b, a gjson.Result
+		if i < len(before) {
+			b = before[i]
+		}
+		if i < len(after) {
+			a = after[i]
+		}
+		diffValues(changes, joinPath(path, strconv.Itoa(i)), b, a)
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -1,11 +1,12 @@
 package message
 
 import (
+	"context"
 	"encoding/json"
-	"strings"
+	"io"
+	"sync"
 
 	"github.com/tidwall/gjson"
-	"github.com/tidwall/sjson"
 )
 
 type GetOption func(json.RawMessage) (json.RawMessage, error)
@@ -17,6 +18,8 @@ var (
 	Resolve func(data []byte, key string) (gjson.Result, error)
 )
 
+// Context is a message as a node sees it. Paths follow the grammar in
+// path.go: order.items[0], items[-1] for the last item, ["key.with.dots"].
 type Context interface {
 	GetID() string
 	Set(path string, value interface{}) error
@@ -28,84 +31,184 @@ type Context interface {
 	GetRaw(options ...GetOption) (json.RawMessage, error)
 	SetRaw(data json.RawMessage, options ...SetOption) error
 	IsEmpty() bool
+	// Query runs a gjson query over the message, LMO blobs resolved: the
+	// path grammar plus wildcards (items.*), the fields of every element
+	// (items.#.sku), filters (items.#(qty>1)#) and modifiers (@reverse).
+	// The error is for an expression the grammar rejects.
+	Query(expr string) (Result, error)
+	// Has reports whether path exists, even if it holds null.
+	Has(path string) bool
+	// Delete removes path. Deleting a path that does not exist is not an
+	// error.
+	Delete(path string) error
+	// Keys lists the keys of the object at path ("" for the message
+	// itself) in message order, or nil when there is no object there.
+	Keys(path string) []string
+	// Clone returns an independent copy of the message with the same ID and
+	// Context, and no routing. LMO blobs stay shared by reference, and its
+	// Changes are still against the message as received.
+	Clone() Context
+	// Merge sets each top-level key of object, a map or struct, under the
+	// object at path, creating it if missing. Other keys are kept.
+	Merge(path string, object interface{}) error
+	// Append adds value to the end of the array at path, creating it if
+	// missing.
+	Append(path string, value interface{}) error
+	// SetBytes stores binary data at path, as an LMO blob when the robot
+	// supports them and as a base64 string otherwise. mime is kept with
+	// the blob for the nodes downstream.
+	SetBytes(path string, data []byte, mime string) error
+	// OpenReader streams the binary data at path: a blob set by SetBytes,
+	// here or in an upstream node of any package, or a base64 string. The
+	// caller closes the reader.
+	OpenReader(path string) (io.ReadCloser, error)
+	// Context returns the cancellation context of the call that delivered
+	// this message. It is cancelled when the flow stops, the robot drops the
+	// connection or the call's deadline passes; pass it to HTTP requests and
+	// other blocking calls so they return early. Never nil.
+	Context() context.Context
+	// RouteTo sends this message out of the given output ports instead of
+	// port 0, e.g. ctx.RouteTo(1) for the "failure" port of a two-output
+	// node. Several ports send a copy to each; no ports sends this message
+	// nowhere, leaving only what SendTo added.
+	RouteTo(ports ...int)
+	// SendTo sends msg out of port in addition to this message, for nodes
+	// that split their input into different messages per port.
+	SendTo(port int, msg Context)
+	// Outputs lists every message the node sends, in order: this message on
+	// each routed port (port 0 without RouteTo), then the SendTo messages.
+	Outputs() []Output
+	// Meta returns the envelope of the message: its ID, parent, origin,
+	// timestamps, trace and tool-call routing. The envelope travels with the
+	// message but is not part of the payload that Get and GetRaw see. Headers
+	// missing from it are read from the legacy payload fields (id,
+	// __trace__, __message_type__, ...) of older senders.
+	Meta() Meta
+	// Changes lists the paths of the payload this node added, changed or
+	// removed since it received the message, with how many bytes each grew
+	// or shrank by. The envelope is not part of it.
+	Changes() []Change
 }
 
 type message struct {
-	ID   string
-	data []byte
+	ID      string
+	data    []byte
+	ctx     context.Context
+	routing routing
+	meta    Meta   // as received or set by the runtime; see meta.go
+	orig    []byte // the payload as received, for Changes
+
+	mu    sync.Mutex
+	over  *overlay // Sets not yet in data; see batch.go
+	cache map[string]gjson.Result
 }
 
 func NewContext(data []byte) Context {
+	return NewContextWith(context.Background(), data)
+}
+
+// NewContextWith creates a message context whose Context() is ctx.
+func NewContextWith(ctx context.Context, data []byte) Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data, meta := SplitMeta(data)
+	id := gjson.GetBytes(data, "id").String()
+	if id == "" {
+		id = meta.ID
+	}
 	return &message{
-		ID:   gjson.GetBytes(data, "id").String(),
+		ID:   id,
 		data: data,
+		ctx:  ctx,
+		meta: meta,
+		orig: data,
 	}
 }
 
-func convertPath(path string) string {
-	path = strings.ReplaceAll(path, "[", ".")
-	path = strings.ReplaceAll(path, "]", "")
-	return path
-}
-
-func (msg *message) Set(path string, value interface{}) (err error) {
-	path = convertPath(path)
-	msg.data, err = sjson.SetBytes(msg.data, path, value)
-	return
+func (msg *message) Set(path string, value interface{}) error {
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	path, err := msg.gjsonPath(path)
+	if err != nil {
+		return err
+	}
+	return msg.set(path, value)
 }
 
 func (msg *message) GetID() string {
 	return msg.ID
 }
 
-func (msg *message) get(path string) gjson.Result {
-	path = convertPath(path)
-	if Resolve != nil {
-		if result, err := Resolve(msg.data, path); err == nil && result.Exists() {
-			return result
-		}
-	}
-	return gjson.GetBytes(msg.data, path)
+// lookup returns the value at path, msg.mu taken.
+func (msg *message) lookup(path string) gjson.Result {
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	return msg.get(path)
 }
 
 func (msg *message) Get(path string) interface{} {
-	return msg.get(path).Value()
+	return msg.lookup(path).Value()
 }
 
 func (msg *message) GetString(path string) string {
-	return msg.get(path).String()
+	return msg.lookup(path).String()
 }
 
 func (msg *message) GetBool(path string) bool {
-	return msg.get(path).Bool()
+	return msg.lookup(path).Bool()
 }
 
 func (msg *message) GetInt(path string) int64 {
-	return msg.get(path).Int()
+	return msg.lookup(path).Int()
 }
 
 func (msg *message) GetFloat(path string) float64 {
-	return msg.get(path).Float()
+	return msg.lookup(path).Float()
 }
 
 func (msg *message) GetRaw(options ...GetOption) (json.RawMessage, error) {
-	return GetRaw(msg.data, options...)
+	return GetRaw(msg.bytes(), options...)
 }
 
-func (msg *message) SetRaw(data json.RawMessage, options ...SetOption) (err error) {
-	msg.data, err = SetRaw(data, options...)
+func (msg *message) SetRaw(data json.RawMessage, options ...SetOption) error {
+	data, err := SetRaw(data, options...)
+	data, _ = SplitMeta(data) // the envelope is not the node's to replace
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	msg.data = data
+	msg.changed()
 	return err
 }
 
 func (msg *message) IsEmpty() bool {
-	return msg.data == nil || len(msg.data) == 0
+	return len(msg.bytes()) == 0
+}
+
+// bytes returns the payload with every Set applied.
+func (msg *message) bytes() []byte {
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	msg.flush()
+	return msg.data
+}
+
+func (msg *message) Context() context.Context {
+	if msg.ctx == nil {
+		return context.Background()
+	}
+	return msg.ctx
 }
 
-// PackedBytes returns the raw internal bytes without BlobRef resolution.
-// Used by the gRPC output path to avoid unnecessary unpack/repack.
+// PackedBytes returns the raw internal bytes without BlobRef resolution,
+// with the envelope under MetaField. Used by the gRPC output path to avoid
+// unnecessary unpack/repack.
 func PackedBytes(ctx Context) json.RawMessage {
 	if m, ok := ctx.(*message); ok {
-		return m.data
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.flush()
+		return appendMeta(m.data, FillMeta(m.meta, m.data))
 	}
 	return nil
 }
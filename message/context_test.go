@@ -0,0 +1,115 @@
+package message
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// benchPayload is a message of about 1 MB, most of it one large field.
+func benchPayload() []byte {
+	var b strings.Builder
+	b.WriteString(`{"id":"bench","rows":[`)
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"n":` + strconv.Itoa(i) + `,"text":"` + strings.Repeat("x", 80) + `"}`)
+	}
+	b.WriteString(`],"result":{}}`)
+	return []byte(b.String())
+}
+
+var benchPaths = func() []string {
+	paths := make([]string, 50)
+	for i := range paths {
+		paths[i] = "field" + strconv.Itoa(i)
+	}
+	return paths
+}()
+
+// BenchmarkSetMany writes 50 top-level fields and packs the message, as a
+// node filling in its outputs does.
+func BenchmarkSetMany(b *testing.B) {
+	data := benchPayload()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := NewContext(data)
+		for _, path := range benchPaths {
+			ctx.Set(path, "value")
+		}
+		_ = PackedBytes(ctx)
+	}
+}
+
+// BenchmarkSetManyNested writes 50 fields under one object.
+func BenchmarkSetManyNested(b *testing.B) {
+	data := benchPayload()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := NewContext(data)
+		for _, path := range benchPaths {
+			ctx.Set("result."+path, i)
+		}
+		_ = PackedBytes(ctx)
+	}
+}
+
+// BenchmarkSetManySjson is BenchmarkSetMany with one sjson.SetBytes per
+// field, as messages did before writes were batched.
+func BenchmarkSetManySjson(b *testing.B) {
+	data := benchPayload()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out := data
+		for _, path := range benchPaths {
+			out, _ = sjson.SetBytes(out, path, "value")
+		}
+	}
+}
+
+// BenchmarkGetRepeated reads the same fields again and again.
+func BenchmarkGetRepeated(b *testing.B) {
+	ctx := NewContext(benchPayload())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ctx.GetString("result")
+		_ = ctx.GetInt("rows.9999.n")
+	}
+}
+
+// BenchmarkGetRepeatedGjson is BenchmarkGetRepeated without the path cache.
+func BenchmarkGetRepeatedGjson(b *testing.B) {
+	data := benchPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = gjson.GetBytes(data, "result").String()
+		_ = gjson.GetBytes(data, "rows.9999.n").Int()
+	}
+}
+
+// BenchmarkSetGetInterleaved alternates writes and reads, which flushes
+// the pending writes every time.
+func BenchmarkSetGetInterleaved(b *testing.B) {
+	data := benchPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := NewContext(data)
+		for _, path := range benchPaths[:10] {
+			ctx.Set(path, i)
+			_ = ctx.GetInt(path)
+		}
+	}
+}
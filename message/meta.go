@@ -0,0 +1,167 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// MetaField is the top-level key the envelope travels under on the wire.
+// A message never shows it: it is taken off the payload when the message is
+// built and put back when the runtime sends it on.
+const MetaField = "__meta__"
+
+// Meta is the envelope of a message: headers the framework keeps about it,
+// apart from the payload nodes read and write. The runtime fills it in as
+// the message leaves a node.
+type Meta struct {
+	ID       string `json:"id,omitempty"`
+	ParentID string `json:"parentId,omitempty"` // the message this one was made from
+	Origin   string `json:"origin,omitempty"`   // GUID of the node that sent it
+
+	Created time.Time `json:"created,omitzero"` // when it was first sent
+	Sent    time.Time `json:"sent,omitzero"`    // when it was last sent
+
+	TraceID string `json:"traceId,omitempty"`
+	SpanID  string `json:"spanId,omitempty"`
+
+	// Type is the kind of message in a tool call, "tool_request" or
+	// "tool_response"; empty for plain flow messages.
+	Type string    `json:"type,omitempty"`
+	Tool *ToolCall `json:"tool,omitempty"`
+}
+
+// ToolCall routes a tool call between an agent node and its tool.
+type ToolCall struct {
+	Name        string `json:"name,omitempty"`
+	CallerID    string `json:"callerId,omitempty"`
+	AgentNodeID string `json:"agentNodeId,omitempty"`
+	SessionID   string `json:"sessionId,omitempty"`
+}
+
+// IsZero reports whether m holds no header at all.
+func (m Meta) IsZero() bool {
+	return m == Meta{}
+}
+
+// The fields messages carried in the payload before the envelope existed.
+const (
+	legacyID          = "id"
+	legacyTrace       = "__trace__"
+	legacyType        = "__message_type__"
+	legacyToolName    = "__tool_name__"
+	legacyToolCaller  = "__tool_caller_id__"
+	legacyAgentNodeID = "__agent_node_id__"
+	legacySessionID   = "session_id"
+)
+
+// MetaOf returns the envelope of a message in its wire form.
+func MetaOf(data []byte) Meta {
+	return FillMeta(parseMeta(gjson.GetBytes(data, MetaField)), data)
+}
+
+// SplitMeta takes the envelope off a message in its wire form, returning
+// the payload without it and the envelope as sent, legacy fields left out.
+func SplitMeta(data []byte) ([]byte, Meta) {
+	field := gjson.GetBytes(data, MetaField)
+	if !field.Exists() {
+		return data, Meta{}
+	}
+	payload, err := sjson.DeleteBytes(data, MetaField)
+	if err != nil {
+		return data, Meta{}
+	}
+	return payload, parseMeta(field)
+}
+
+// FillMeta fills the empty headers of meta from the legacy fields of the
+// payload data: id, __trace__, __message_type__, __tool_name__,
+// __tool_caller_id__, __agent_node_id__ and session_id.
+func FillMeta(meta Meta, data []byte) Meta {
+	if len(data) == 0 {
+		return meta
+	}
+	legacy := gjson.GetManyBytes(data, legacyID, legacyTrace, legacyType,
+		legacyToolName, legacyToolCaller, legacyAgentNodeID, legacySessionID)
+	fill := func(dst *string, v gjson.Result) {
+		if *dst == "" && v.Type == gjson.String {
+			*dst = v.Str
+		}
+	}
+	fill(&meta.ID, legacy[0])
+	fill(&meta.TraceID, legacy[1].Get("traceId"))
+	fill(&meta.SpanID, legacy[1].Get("spanId"))
+	fill(&meta.Type, legacy[2])
+
+	tool := ToolCall{}
+	if meta.Tool != nil {
+		tool = *meta.Tool
+	}
+	fill(&tool.Name, legacy[3])
+	fill(&tool.CallerID, legacy[4])
+	fill(&tool.AgentNodeID, legacy[5])
+	fill(&tool.SessionID, legacy[6])
+	if tool != (ToolCall{}) {
+		meta.Tool = &tool
+	}
+	return meta
+}
+
+func parseMeta(field gjson.Result) Meta {
+	var meta Meta
+	if field.IsObject() {
+		json.Unmarshal([]byte(field.Raw), &meta)
+	}
+	return meta
+}
+
+// appendMeta returns the wire form of payload data with meta, in one copy.
+// data is returned as is when meta is empty or data is not an object.
+func appendMeta(data []byte, meta Meta) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if meta.IsZero() || len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return data
+	}
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return data
+	}
+	body := bytes.TrimSpace(trimmed[1 : len(trimmed)-1])
+	out := make([]byte, 0, len(trimmed)+len(MetaField)+len(raw)+4)
+	out = append(out, trimmed[:len(trimmed)-1]...)
+	if len(body) > 0 {
+		out = append(out, ',')
+	}
+	out = append(out, '"')
+	out = append(out, MetaField...)
+	out = append(out, '"', ':')
+	out = append(out, raw...)
+	return append(out, '}')
+}
+
+func (msg *message) Meta() Meta {
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	msg.flush()
+	return FillMeta(msg.meta, msg.data)
+}
+
+// SetMeta replaces the envelope of ctx. It is meant for the runtime, which
+// owns the envelope; contexts from outside this package take it if they
+// have a SetMeta(Meta) method.
+func SetMeta(ctx Context, meta Meta) {
+	switch c := ctx.(type) {
+	case *message:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.meta = meta
+		if c.ID == "" {
+			c.ID = meta.ID
+		}
+	case interface{ SetMeta(Meta) }:
+		c.SetMeta(meta)
+	}
+}
@@ -0,0 +1,216 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func (msg *message) Has(path string) bool {
+	if path == "" {
+		return !msg.IsEmpty()
+	}
+	return msg.lookup(path).Exists()
+}
+
+func (msg *message) Delete(path string) error {
+	return msg.edit(path, false, func(data []byte, path string) ([]byte, error) {
+		return sjson.DeleteBytes(data, path)
+	})
+}
+
+func (msg *message) Keys(path string) []string {
+	if path == "" {
+		return KeysJSON(msg.bytes(), "")
+	}
+	return objectKeys(msg.lookup(path))
+}
+
+func (msg *message) Clone() Context {
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	msg.flush()
+	return &message{ID: msg.ID, data: append([]byte(nil), msg.data...), ctx: msg.ctx, meta: msg.meta, orig: msg.orig}
+}
+
+func (msg *message) Merge(path string, object interface{}) error {
+	return msg.edit(path, true, func(data []byte, path string) ([]byte, error) {
+		return MergeJSON(data, path, object)
+	})
+}
+
+func (msg *message) Append(path string, value interface{}) error {
+	return msg.edit(path, true, func(data []byte, path string) ([]byte, error) {
+		return AppendJSON(data, path, value)
+	})
+}
+
+// edit applies fn to the whole payload at path, after inlining the
+// BlobRefs on the way (and at path itself with self).
+func (msg *message) edit(path string, self bool, fn func(data []byte, path string) ([]byte, error)) error {
+	msg.mu.Lock()
+	defer msg.mu.Unlock()
+	msg.flush()
+
+	path, err := msg.gjsonPath(path)
+	if err != nil {
+		return err
+	}
+	if err := msg.inline(path, self); err != nil {
+		return err
+	}
+	data, err := fn(msg.data, path)
+	if err != nil {
+		return err
+	}
+	msg.data = data
+	msg.changed()
+	return nil
+}
+
+// inline replaces the BlobRefs on the way to path with the values they
+// stand for, so that a write below one lands in the message instead of
+// missing it. Refs elsewhere in the message are left alone. With self, a
+// ref at path itself is inlined too. msg.mu must be held, with no Sets
+// pending.
+func (msg *message) inline(path string, self bool) error {
+	if Resolve == nil || !bytes.Contains(msg.data, []byte("__ref")) {
+		return nil
+	}
+	parts := splitPath(path)
+	n := len(parts) - 1
+	if self {
+		n = len(parts)
+	}
+	for i := 1; i <= n; i++ {
+		prefix := strings.Join(parts[:i], ".")
+		if !isBlobRef(gjson.GetBytes(msg.data, prefix)) {
+			continue
+		}
+		resolved, err := Resolve(msg.data, prefix)
+		if err != nil {
+			return err
+		}
+		if msg.data, err = sjson.SetRawBytes(msg.data, prefix, []byte(resolved.Raw)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isBlobRef reports whether v is an LMO BlobRef marker.
+func isBlobRef(v gjson.Result) bool {
+	return v.IsObject() && v.Get("__magic").Exists() && v.Get("__ref").String() != ""
+}
+
+// splitPath splits a gjson path at the dots that are not escaped.
+func splitPath(path string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
+			parts = append(parts, path[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, path[start:])
+}
+
+// escapeKey escapes the characters of an object key that gjson, sjson or
+// ConvertPath would read as path syntax.
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '.', '*', '?', '\\', '|', '#', '@', '[', ']', '(', ')', '{', '}', '"':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
+
+// KeysJSON is Context.Keys on a JSON document.
+func KeysJSON(data []byte, path string) []string {
+	if path == "" {
+		return objectKeys(gjson.ParseBytes(data))
+	}
+	return objectKeys(gjson.GetBytes(data, path))
+}
+
+func objectKeys(v gjson.Result) []string {
+	if !v.IsObject() {
+		return nil
+	}
+	keys := []string{}
+	v.ForEach(func(key, _ gjson.Result) bool {
+		keys = append(keys, key.String())
+		return true
+	})
+	return keys
+}
+
+// MergeJSON is Context.Merge on a JSON document, for Context
+// implementations outside this package such as the testing package's
+// MockContext. An empty document is an empty object; anything but an object
+// at path is a *PathError.
+func MergeJSON(data []byte, path string, object interface{}) ([]byte, error) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return nil, &PathError{Path: path, Err: err, Want: "object"}
+	}
+	src := gjson.ParseBytes(raw)
+	if !src.IsObject() {
+		return nil, &PathError{Path: path, Err: ErrWrongType, Want: "object", Got: jsonType(raw)}
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+	target := gjson.ParseBytes(data)
+	if path != "" {
+		target = gjson.GetBytes(data, path)
+	}
+	switch {
+	case path != "" && !target.Exists():
+		return sjson.SetRawBytes(data, path, raw)
+	case !target.IsObject():
+		return nil, &PathError{Path: path, Err: ErrWrongType, Want: "object", Got: jsonType([]byte(target.Raw))}
+	}
+
+	prefix := ""
+	if path != "" {
+		prefix = path + "."
+	}
+	src.ForEach(func(key, value gjson.Result) bool {
+		data, err = sjson.SetRawBytes(data, prefix+escapeKey(key.String()), []byte(value.Raw))
+		return err == nil
+	})
+	return data, err
+}
+
+// AppendJSON is Context.Append on a JSON document. Anything but an array
+// at path is a *PathError.
+func AppendJSON(data []byte, path string, value interface{}) ([]byte, error) {
+	target := gjson.ParseBytes(data)
+	if path != "" {
+		target = gjson.GetBytes(data, path)
+	}
+	if target.Exists() && !target.IsArray() {
+		return nil, &PathError{Path: path, Err: ErrWrongType, Want: "array", Got: jsonType([]byte(target.Raw))}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, &PathError{Path: path, Err: err}
+	}
+	if path == "" {
+		return sjson.SetRawBytes(data, "-1", raw)
+	}
+	return sjson.SetRawBytes(data, path+".-1", raw)
+}
@@ -0,0 +1,174 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Paths
+//
+// Every method that takes a path reads it the same way:
+//
+//	order.items[0].sku    keys separated by dots, array indexes in brackets
+//	order.items.0.sku     an index may also follow a dot
+//	items[-1]             negative indexes count from the end: the last item
+//	["a.b"]['x[1]']       quoted keys, for keys with dots, brackets or spaces;
+//	                      a backslash escapes the next character in quotes
+//	a\.b                  or a backslash before each special character
+//
+// The rest is gjson path syntax, passed on as is: items.# (the length),
+// items.#.sku (a field of every item), wildcards and filters; see Query.
+
+// ErrBadPath is wrapped in the *PathError for a path that does not follow
+// the grammar, e.g. one with an unclosed bracket or quote.
+var ErrBadPath = errors.New("bad path")
+
+// ConvertPath turns path into gjson and sjson syntax. data is the message
+// the path is for, which negative indexes count back in; an index past the
+// start of its array is a *PathError wrapping ErrMissing. Converting a
+// converted path changes nothing.
+func ConvertPath(data []byte, path string) (string, error) {
+	if strings.IndexByte(path, '[') < 0 {
+		return path, nil
+	}
+	var b strings.Builder
+	b.Grow(len(path))
+	depth := 0 // inside a gjson filter, #(...), or a {...} multipath
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\\' && i+1 < len(path):
+			b.WriteString(path[i : i+2])
+			i++
+			continue
+		case depth > 0 && (c == '"' || c == '\''):
+			end := closingQuote(path, i)
+			if end < 0 {
+				return "", badPath(path, "unclosed quote at %d", i)
+			}
+			b.WriteString(path[i : end+1])
+			i = end
+			continue
+		case c == '(' && (depth > 0 || (i > 0 && path[i-1] == '#')),
+			c == '{' && (depth > 0 || i == 0 || strings.IndexByte(".|:", path[i-1]) >= 0):
+			depth++
+		case (c == ')' || c == '}') && depth > 0:
+			depth--
+		case c == '[' && depth == 0:
+			seg, end, err := convertBracket(data, path, i, b.String())
+			if err != nil {
+				return "", err
+			}
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(seg)
+			i = end
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+// convertBracket converts the bracket at path[start], found after prefix,
+// and returns the gjson segment and the index of the closing bracket.
+func convertBracket(data []byte, path string, start int, prefix string) (string, int, error) {
+	i := start + 1
+	if i < len(path) && (path[i] == '"' || path[i] == '\'') {
+		end := closingQuote(path, i)
+		if end < 0 {
+			return "", 0, badPath(path, "unclosed quote at %d", i)
+		}
+		if end+1 >= len(path) || path[end+1] != ']' {
+			return "", 0, badPath(path, "want ] after the quoted key at %d", i)
+		}
+		return escapeKey(unquote(path[i+1 : end])), end + 1, nil
+	}
+
+	end := strings.IndexByte(path[i:], ']')
+	if end < 0 {
+		return "", 0, badPath(path, "unclosed [ at %d", start)
+	}
+	end += i
+	inner := path[i:end]
+	n, err := strconv.Atoi(inner)
+	switch {
+	case inner == "":
+		return "", 0, badPath(path, "empty [] at %d", start)
+	case err != nil || n >= 0:
+		// An index, or gjson syntax such as [#] that older paths used.
+		return inner, end, nil
+	}
+
+	length, ok := arrayLen(data, prefix)
+	if !ok || length+n < 0 {
+		return "", 0, &PathError{Path: path, Err: ErrMissing}
+	}
+	return strconv.Itoa(length + n), end, nil
+}
+
+// arrayLen returns the length of the array at the gjson path prefix,
+// resolving an LMO blob on the way.
+func arrayLen(data []byte, prefix string) (int, bool) {
+	v := gjson.ParseBytes(data)
+	if prefix != "" {
+		v = gjson.GetBytes(data, prefix)
+	}
+	if isBlobRef(v) && v.Get("__type").String() == "array" {
+		return int(v.Get("__len").Int()), true // packed whole
+	}
+	if !v.IsArray() && prefix != "" && Resolve != nil {
+		if resolved, err := Resolve(data, prefix); err == nil {
+			v = resolved
+		}
+	}
+	if !v.IsArray() {
+		return 0, false
+	}
+	return int(v.Get("#").Int()), true
+}
+
+// closingQuote returns the index of the quote closing the one at
+// path[start], or -1.
+func closingQuote(path string, start int) int {
+	for i := start + 1; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case path[start]:
+			return i
+		}
+	}
+	return -1
+}
+
+func unquote(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func badPath(path, format string, args ...interface{}) error {
+	return &PathError{Path: path, Err: ErrBadPath, Cause: fmt.Errorf(format, args...)}
+}
+
+// gjsonPath converts path against the message, msg.mu held.
+func (msg *message) gjsonPath(path string) (string, error) {
+	if strings.Contains(path, "[-") {
+		msg.flush() // negative indexes count in the current payload
+	}
+	return ConvertPath(msg.data, path)
+}
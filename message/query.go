@@ -0,0 +1,72 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/tidwall/gjson"
+)
+
+// Result is the value a Query found. It has gjson's typed accessors
+// (Exists, String, Int, Float, Bool, Time, Array, Map, ForEach) and
+// Decode for anything encoding/json can decode into.
+type Result struct {
+	gjson.Result
+}
+
+// Decode decodes the result into v, as encoding/json does.
+func (r Result) Decode(v interface{}) error {
+	if !r.Exists() {
+		return ErrMissing
+	}
+	return json.Unmarshal([]byte(r.Raw), v)
+}
+
+func (msg *message) Query(expr string) (Result, error) {
+	msg.mu.Lock()
+	msg.flush()
+	path, err := msg.gjsonPath(expr)
+	data := msg.data
+	msg.mu.Unlock()
+	if err != nil {
+		return Result{}, err
+	}
+	return QueryJSON(data, path), nil
+}
+
+// QueryJSON is Context.Query on a JSON document, for an expression already
+// converted with ConvertPath. LMO blobs are resolved first.
+func QueryJSON(data []byte, path string) Result {
+	if Resolve != nil && GetRaw != nil && bytes.Contains(data, []byte("__ref")) {
+		if resolved, err := GetRaw(data); err == nil {
+			data = resolved
+		}
+	}
+	return Result{gjson.GetBytes(data, path)}
+}
+
+// QueryAs runs ctx.Query(expr) and decodes the result into a T, with the
+// errors of GetAs.
+//
+//	skus, err := message.QueryAs[[]string](ctx, `order.items.#(qty>0)#.sku`)
+func QueryAs[T any](ctx Context, expr string) (T, error) {
+	var v T
+	result, err := ctx.Query(expr)
+	if err != nil {
+		return v, err
+	}
+	if !result.Exists() {
+		return v, &PathError{Path: expr, Err: ErrMissing, Want: typeName[T]()}
+	}
+	if err := json.Unmarshal([]byte(result.Raw), &v); err != nil {
+		var zero T
+		return zero, &PathError{
+			Path:  expr,
+			Err:   ErrWrongType,
+			Want:  typeName[T](),
+			Got:   jsonType(json.RawMessage(result.Raw)),
+			Cause: err,
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,55 @@
+package message
+
+// Output is one message a node sends out of one of its output ports.
+type Output struct {
+	Port    int
+	Message Context
+}
+
+// routing records where a message goes after OnMessage returns. The zero
+// value sends the message itself out of port 0, which is what every node did
+// before routing existed.
+type routing struct {
+	routed bool
+	ports  []int
+	extra  []Output
+}
+
+func (r *routing) routeTo(ports []int) {
+	r.routed = true
+	r.ports = append(r.ports[:0], ports...)
+}
+
+func (r *routing) sendTo(port int, msg Context) {
+	r.extra = append(r.extra, Output{Port: port, Message: msg})
+}
+
+func (r *routing) outputs(self Context) []Output {
+	var outs []Output
+	if !r.routed {
+		outs = append(outs, Output{Port: 0, Message: self})
+	}
+	for _, port := range r.ports {
+		outs = append(outs, Output{Port: port, Message: self})
+	}
+	return append(outs, r.extra...)
+}
+
+func (msg *message) RouteTo(ports ...int) {
+	msg.routing.routeTo(ports)
+}
+
+func (msg *message) SendTo(port int, out Context) {
+	msg.routing.sendTo(port, out)
+}
+
+func (msg *message) Outputs() []Output {
+	return msg.routing.outputs(msg)
+}
+
+// IsDefaultRoute reports whether ctx goes out of port 0 alone, with no
+// RouteTo or SendTo calls.
+func IsDefaultRoute(ctx Context) bool {
+	outs := ctx.Outputs()
+	return len(outs) == 1 && outs[0].Port == 0 && outs[0].Message == ctx
+}
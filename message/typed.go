@@ -0,0 +1,153 @@
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/tidwall/gjson"
+)
+
+// Errors GetAs wraps in a *PathError, for errors.Is.
+var (
+	ErrMissing   = errors.New("missing")
+	ErrWrongType = errors.New("wrong type")
+)
+
+// PathError is returned by GetAs and SetAs.
+type PathError struct {
+	Path string
+	Err  error // ErrMissing or ErrWrongType, or the marshal error of SetAs
+
+	Want string // the Go type asked for
+	Got  string // the JSON type found: string, number, boolean, null, object or array
+
+	// Cause is the json error behind ErrWrongType, if any.
+	Cause error
+}
+
+func (e *PathError) Error() string {
+	switch {
+	case e.Err == ErrMissing:
+		return fmt.Sprintf("message: %q is missing", e.Path)
+	case e.Err == ErrWrongType && e.Cause != nil:
+		return fmt.Sprintf("message: %q is %s, want %s: %v", e.Path, e.Got, e.Want, e.Cause)
+	case e.Err == ErrWrongType:
+		return fmt.Sprintf("message: %q is %s, want %s", e.Path, e.Got, e.Want)
+	}
+	if e.Cause != nil {
+		return fmt.Sprintf("message: %q: %v: %v", e.Path, e.Err, e.Cause)
+	}
+	return fmt.Sprintf("message: %q: %v", e.Path, e.Err)
+}
+
+func (e *PathError) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Err, e.Cause}
+	}
+	return []error{e.Err}
+}
+
+// Lookuper is implemented by contexts that can return the JSON at a path
+// as is, BlobRefs resolved, and tell a missing path from a null value.
+// GetAs uses it when present and falls back to Get otherwise.
+type Lookuper interface {
+	Lookup(path string) (raw json.RawMessage, ok bool)
+}
+
+// Lookup returns the JSON at path with BlobRefs resolved.
+func (msg *message) Lookup(path string) (json.RawMessage, bool) {
+	result := msg.lookup(path)
+	if !result.Exists() {
+		return nil, false
+	}
+	return json.RawMessage(result.Raw), true
+}
+
+// GetAs decodes the value at path into a T, which may be any type
+// encoding/json can decode into: a scalar, a struct with json tags, a slice
+// or a map.
+//
+//	type Order struct {
+//	    ID    string  `json:"id"`
+//	    Total float64 `json:"total"`
+//	}
+//	order, err := message.GetAs[Order](ctx, "order")
+//
+// Large values stored as LMO blobs are resolved first. A path that does not
+// exist gives a *PathError wrapping ErrMissing; a value that does not fit T
+// one wrapping ErrWrongType. A JSON null decodes to the zero T.
+func GetAs[T any](ctx Context, path string) (T, error) {
+	var v T
+	raw, ok := lookup(ctx, path)
+	if !ok {
+		return v, &PathError{Path: path, Err: ErrMissing, Want: typeName[T]()}
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		var zero T
+		return zero, &PathError{
+			Path:  path,
+			Err:   ErrWrongType,
+			Want:  typeName[T](),
+			Got:   jsonType(raw),
+			Cause: err,
+		}
+	}
+	return v, nil
+}
+
+// MustGet is GetAs for values a node cannot do without; it panics on error.
+// The runtime turns the panic into an error for the call.
+func MustGet[T any](ctx Context, path string) T {
+	v, err := GetAs[T](ctx, path)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// SetAs stores v at path as encoding/json marshals it, honoring json tags.
+func SetAs[T any](ctx Context, path string, v T) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return &PathError{Path: path, Err: err, Want: typeName[T]()}
+	}
+	return ctx.Set(path, json.RawMessage(raw))
+}
+
+func lookup(ctx Context, path string) (json.RawMessage, bool) {
+	if l, ok := ctx.(Lookuper); ok {
+		return l.Lookup(path)
+	}
+	v := ctx.Get(path)
+	if v == nil {
+		return nil, false
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return raw, true
+}
+
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+func jsonType(raw json.RawMessage) string {
+	switch gjson.ParseBytes(raw).Type {
+	case gjson.String:
+		return "string"
+	case gjson.Number:
+		return "number"
+	case gjson.True, gjson.False:
+		return "boolean"
+	case gjson.Null:
+		return "null"
+	}
+	if r := gjson.ParseBytes(raw); r.IsArray() {
+		return "array"
+	}
+	return "object"
+}
@@ -283,9 +283,13 @@ func (x *OnMessageRequest) GetInMessage() []byte {
 }
 
 type OnMessageResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OutMessage    []byte                 `protobuf:"bytes,1,opt,name=outMessage,proto3" json:"outMessage,omitempty"`
-	Error         *Error                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	OutMessage []byte                 `protobuf:"bytes,1,opt,name=outMessage,proto3" json:"outMessage,omitempty"`
+	Error      *Error                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// outputs carries the messages a node routed to ports other than the
+	// one outMessage goes to. Only the CLI session daemon fills it; the robot
+	// receives them through RuntimeHelper.EmitOutput instead.
+	Outputs       []*PortMessage `protobuf:"bytes,3,rep,name=outputs,proto3" json:"outputs,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -334,6 +338,65 @@ func (x *OnMessageResponse) GetError() *Error {
 	return nil
 }
 
+func (x *OnMessageResponse) GetOutputs() []*PortMessage {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+type PortMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Port          int32                  `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	Message       []byte                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortMessage) Reset() {
+	*x = PortMessage{}
+	mi := &file_plugin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortMessage) ProtoMessage() {}
+
+func (x *PortMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortMessage.ProtoReflect.Descriptor instead.
+func (*PortMessage) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PortMessage) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *PortMessage) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
 type OnCloseRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Guid          string                 `protobuf:"bytes,1,opt,name=guid,proto3" json:"guid,omitempty"`
@@ -343,7 +406,7 @@ type OnCloseRequest struct {
 
 func (x *OnCloseRequest) Reset() {
 	*x = OnCloseRequest{}
-	mi := &file_plugin_proto_msgTypes[6]
+	mi := &file_plugin_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -355,7 +418,7 @@ func (x *OnCloseRequest) String() string {
 func (*OnCloseRequest) ProtoMessage() {}
 
 func (x *OnCloseRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[6]
+	mi := &file_plugin_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -368,7 +431,7 @@ func (x *OnCloseRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OnCloseRequest.ProtoReflect.Descriptor instead.
 func (*OnCloseRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{6}
+	return file_plugin_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *OnCloseRequest) GetGuid() string {
@@ -387,7 +450,7 @@ type OnCloseResponse struct {
 
 func (x *OnCloseResponse) Reset() {
 	*x = OnCloseResponse{}
-	mi := &file_plugin_proto_msgTypes[7]
+	mi := &file_plugin_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -399,7 +462,7 @@ func (x *OnCloseResponse) String() string {
 func (*OnCloseResponse) ProtoMessage() {}
 
 func (x *OnCloseResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[7]
+	mi := &file_plugin_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -412,7 +475,7 @@ func (x *OnCloseResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OnCloseResponse.ProtoReflect.Descriptor instead.
 func (*OnCloseResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{7}
+	return file_plugin_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *OnCloseResponse) GetError() *Error {
@@ -422,16 +485,113 @@ func (x *OnCloseResponse) GetError() *Error {
 	return nil
 }
 
+type OnUpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Guid          string                 `protobuf:"bytes,1,opt,name=guid,proto3" json:"guid,omitempty"`
+	Config        []byte                 `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OnUpdateRequest) Reset() {
+	*x = OnUpdateRequest{}
+	mi := &file_plugin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OnUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OnUpdateRequest) ProtoMessage() {}
+
+func (x *OnUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OnUpdateRequest.ProtoReflect.Descriptor instead.
+func (*OnUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *OnUpdateRequest) GetGuid() string {
+	if x != nil {
+		return x.Guid
+	}
+	return ""
+}
+
+func (x *OnUpdateRequest) GetConfig() []byte {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type OnUpdateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Restarted     bool                   `protobuf:"varint,1,opt,name=restarted,proto3" json:"restarted,omitempty"` // the node was closed and created again
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OnUpdateResponse) Reset() {
+	*x = OnUpdateResponse{}
+	mi := &file_plugin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OnUpdateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OnUpdateResponse) ProtoMessage() {}
+
+func (x *OnUpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OnUpdateResponse.ProtoReflect.Descriptor instead.
+func (*OnUpdateResponse) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *OnUpdateResponse) GetRestarted() bool {
+	if x != nil {
+		return x.Restarted
+	}
+	return false
+}
+
 type PGetCapabilitiesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Capabilities  uint64                 `protobuf:"varint,1,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	Features      map[string]uint32      `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // named features and their versions, bits included
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PGetCapabilitiesResponse) Reset() {
 	*x = PGetCapabilitiesResponse{}
-	mi := &file_plugin_proto_msgTypes[8]
+	mi := &file_plugin_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -443,7 +603,7 @@ func (x *PGetCapabilitiesResponse) String() string {
 func (*PGetCapabilitiesResponse) ProtoMessage() {}
 
 func (x *PGetCapabilitiesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[8]
+	mi := &file_plugin_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -456,7 +616,7 @@ func (x *PGetCapabilitiesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PGetCapabilitiesResponse.ProtoReflect.Descriptor instead.
 func (*PGetCapabilitiesResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{8}
+	return file_plugin_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *PGetCapabilitiesResponse) GetCapabilities() uint64 {
@@ -466,6 +626,13 @@ func (x *PGetCapabilitiesResponse) GetCapabilities() uint64 {
 	return 0
 }
 
+func (x *PGetCapabilitiesResponse) GetFeatures() map[string]uint32 {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
 // OnSetupRequest instantiates one node for an interactive setup action.
 // name + config mirror OnCreateRequest; session_id correlates the
 // SetupEmit/SetupAwait callbacks this run makes back to the host.
@@ -480,7 +647,7 @@ type OnSetupRequest struct {
 
 func (x *OnSetupRequest) Reset() {
 	*x = OnSetupRequest{}
-	mi := &file_plugin_proto_msgTypes[9]
+	mi := &file_plugin_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -492,7 +659,7 @@ func (x *OnSetupRequest) String() string {
 func (*OnSetupRequest) ProtoMessage() {}
 
 func (x *OnSetupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[9]
+	mi := &file_plugin_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -505,7 +672,7 @@ func (x *OnSetupRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OnSetupRequest.ProtoReflect.Descriptor instead.
 func (*OnSetupRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{9}
+	return file_plugin_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *OnSetupRequest) GetName() string {
@@ -539,7 +706,7 @@ type OnSetupResponse struct {
 
 func (x *OnSetupResponse) Reset() {
 	*x = OnSetupResponse{}
-	mi := &file_plugin_proto_msgTypes[10]
+	mi := &file_plugin_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -551,7 +718,7 @@ func (x *OnSetupResponse) String() string {
 func (*OnSetupResponse) ProtoMessage() {}
 
 func (x *OnSetupResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[10]
+	mi := &file_plugin_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -564,7 +731,7 @@ func (x *OnSetupResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OnSetupResponse.ProtoReflect.Descriptor instead.
 func (*OnSetupResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{10}
+	return file_plugin_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *OnSetupResponse) GetResult() []byte {
@@ -589,7 +756,7 @@ type Empty struct {
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	mi := &file_plugin_proto_msgTypes[11]
+	mi := &file_plugin_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -601,7 +768,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[11]
+	mi := &file_plugin_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -614,7 +781,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{11}
+	return file_plugin_proto_rawDescGZIP(), []int{14}
 }
 
 type IsRunningResponse struct {
@@ -626,7 +793,7 @@ type IsRunningResponse struct {
 
 func (x *IsRunningResponse) Reset() {
 	*x = IsRunningResponse{}
-	mi := &file_plugin_proto_msgTypes[12]
+	mi := &file_plugin_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -638,7 +805,7 @@ func (x *IsRunningResponse) String() string {
 func (*IsRunningResponse) ProtoMessage() {}
 
 func (x *IsRunningResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[12]
+	mi := &file_plugin_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -651,7 +818,7 @@ func (x *IsRunningResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IsRunningResponse.ProtoReflect.Descriptor instead.
 func (*IsRunningResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{12}
+	return file_plugin_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *IsRunningResponse) GetIsRunning() bool {
@@ -672,7 +839,7 @@ type DebugRequest struct {
 
 func (x *DebugRequest) Reset() {
 	*x = DebugRequest{}
-	mi := &file_plugin_proto_msgTypes[13]
+	mi := &file_plugin_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -684,7 +851,7 @@ func (x *DebugRequest) String() string {
 func (*DebugRequest) ProtoMessage() {}
 
 func (x *DebugRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[13]
+	mi := &file_plugin_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -697,7 +864,7 @@ func (x *DebugRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DebugRequest.ProtoReflect.Descriptor instead.
 func (*DebugRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{13}
+	return file_plugin_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *DebugRequest) GetGuid() string {
@@ -731,7 +898,7 @@ type EmitFlowEventRequest struct {
 
 func (x *EmitFlowEventRequest) Reset() {
 	*x = EmitFlowEventRequest{}
-	mi := &file_plugin_proto_msgTypes[14]
+	mi := &file_plugin_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -743,7 +910,7 @@ func (x *EmitFlowEventRequest) String() string {
 func (*EmitFlowEventRequest) ProtoMessage() {}
 
 func (x *EmitFlowEventRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[14]
+	mi := &file_plugin_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -756,7 +923,7 @@ func (x *EmitFlowEventRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmitFlowEventRequest.ProtoReflect.Descriptor instead.
 func (*EmitFlowEventRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{14}
+	return file_plugin_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *EmitFlowEventRequest) GetGuid() string {
@@ -783,7 +950,7 @@ type EmitInputRequest struct {
 
 func (x *EmitInputRequest) Reset() {
 	*x = EmitInputRequest{}
-	mi := &file_plugin_proto_msgTypes[15]
+	mi := &file_plugin_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -795,7 +962,7 @@ func (x *EmitInputRequest) String() string {
 func (*EmitInputRequest) ProtoMessage() {}
 
 func (x *EmitInputRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[15]
+	mi := &file_plugin_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -808,7 +975,7 @@ func (x *EmitInputRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmitInputRequest.ProtoReflect.Descriptor instead.
 func (*EmitInputRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{15}
+	return file_plugin_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *EmitInputRequest) GetGuid() string {
@@ -836,7 +1003,7 @@ type EmitOutputRequest struct {
 
 func (x *EmitOutputRequest) Reset() {
 	*x = EmitOutputRequest{}
-	mi := &file_plugin_proto_msgTypes[16]
+	mi := &file_plugin_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -848,7 +1015,7 @@ func (x *EmitOutputRequest) String() string {
 func (*EmitOutputRequest) ProtoMessage() {}
 
 func (x *EmitOutputRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[16]
+	mi := &file_plugin_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -861,7 +1028,7 @@ func (x *EmitOutputRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmitOutputRequest.ProtoReflect.Descriptor instead.
 func (*EmitOutputRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{16}
+	return file_plugin_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *EmitOutputRequest) GetGuid() string {
@@ -896,7 +1063,7 @@ type EmitErrorRequest struct {
 
 func (x *EmitErrorRequest) Reset() {
 	*x = EmitErrorRequest{}
-	mi := &file_plugin_proto_msgTypes[17]
+	mi := &file_plugin_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -908,7 +1075,7 @@ func (x *EmitErrorRequest) String() string {
 func (*EmitErrorRequest) ProtoMessage() {}
 
 func (x *EmitErrorRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[17]
+	mi := &file_plugin_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -921,7 +1088,7 @@ func (x *EmitErrorRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmitErrorRequest.ProtoReflect.Descriptor instead.
 func (*EmitErrorRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{17}
+	return file_plugin_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *EmitErrorRequest) GetGuid() string {
@@ -955,7 +1122,7 @@ type GetVaultItemRequest struct {
 
 func (x *GetVaultItemRequest) Reset() {
 	*x = GetVaultItemRequest{}
-	mi := &file_plugin_proto_msgTypes[18]
+	mi := &file_plugin_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -967,7 +1134,7 @@ func (x *GetVaultItemRequest) String() string {
 func (*GetVaultItemRequest) ProtoMessage() {}
 
 func (x *GetVaultItemRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[18]
+	mi := &file_plugin_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -980,7 +1147,7 @@ func (x *GetVaultItemRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetVaultItemRequest.ProtoReflect.Descriptor instead.
 func (*GetVaultItemRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{18}
+	return file_plugin_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GetVaultItemRequest) GetVaultId() string {
@@ -1006,7 +1173,7 @@ type GetVaultItemResponse struct {
 
 func (x *GetVaultItemResponse) Reset() {
 	*x = GetVaultItemResponse{}
-	mi := &file_plugin_proto_msgTypes[19]
+	mi := &file_plugin_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1018,7 +1185,7 @@ func (x *GetVaultItemResponse) String() string {
 func (*GetVaultItemResponse) ProtoMessage() {}
 
 func (x *GetVaultItemResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[19]
+	mi := &file_plugin_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1031,7 +1198,7 @@ func (x *GetVaultItemResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetVaultItemResponse.ProtoReflect.Descriptor instead.
 func (*GetVaultItemResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{19}
+	return file_plugin_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *GetVaultItemResponse) GetItem() *_struct.Struct {
@@ -1052,7 +1219,7 @@ type SetVaultItemRequest struct {
 
 func (x *SetVaultItemRequest) Reset() {
 	*x = SetVaultItemRequest{}
-	mi := &file_plugin_proto_msgTypes[20]
+	mi := &file_plugin_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1064,7 +1231,7 @@ func (x *SetVaultItemRequest) String() string {
 func (*SetVaultItemRequest) ProtoMessage() {}
 
 func (x *SetVaultItemRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[20]
+	mi := &file_plugin_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1077,7 +1244,7 @@ func (x *SetVaultItemRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetVaultItemRequest.ProtoReflect.Descriptor instead.
 func (*SetVaultItemRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{20}
+	return file_plugin_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *SetVaultItemRequest) GetVaultId() string {
@@ -1110,7 +1277,7 @@ type SetVaultItemResponse struct {
 
 func (x *SetVaultItemResponse) Reset() {
 	*x = SetVaultItemResponse{}
-	mi := &file_plugin_proto_msgTypes[21]
+	mi := &file_plugin_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1122,7 +1289,7 @@ func (x *SetVaultItemResponse) String() string {
 func (*SetVaultItemResponse) ProtoMessage() {}
 
 func (x *SetVaultItemResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[21]
+	mi := &file_plugin_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1135,7 +1302,7 @@ func (x *SetVaultItemResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetVaultItemResponse.ProtoReflect.Descriptor instead.
 func (*SetVaultItemResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{21}
+	return file_plugin_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *SetVaultItemResponse) GetItem() *_struct.Struct {
@@ -1156,7 +1323,7 @@ type Variable struct {
 
 func (x *Variable) Reset() {
 	*x = Variable{}
-	mi := &file_plugin_proto_msgTypes[22]
+	mi := &file_plugin_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1168,7 +1335,7 @@ func (x *Variable) String() string {
 func (*Variable) ProtoMessage() {}
 
 func (x *Variable) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[22]
+	mi := &file_plugin_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1181,7 +1348,7 @@ func (x *Variable) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Variable.ProtoReflect.Descriptor instead.
 func (*Variable) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{22}
+	return file_plugin_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *Variable) GetScope() string {
@@ -1214,7 +1381,7 @@ type GetVariableRequest struct {
 
 func (x *GetVariableRequest) Reset() {
 	*x = GetVariableRequest{}
-	mi := &file_plugin_proto_msgTypes[23]
+	mi := &file_plugin_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1226,7 +1393,7 @@ func (x *GetVariableRequest) String() string {
 func (*GetVariableRequest) ProtoMessage() {}
 
 func (x *GetVariableRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[23]
+	mi := &file_plugin_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1239,7 +1406,7 @@ func (x *GetVariableRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetVariableRequest.ProtoReflect.Descriptor instead.
 func (*GetVariableRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{23}
+	return file_plugin_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *GetVariableRequest) GetVariable() *Variable {
@@ -1258,7 +1425,7 @@ type GetVariableResponse struct {
 
 func (x *GetVariableResponse) Reset() {
 	*x = GetVariableResponse{}
-	mi := &file_plugin_proto_msgTypes[24]
+	mi := &file_plugin_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1270,7 +1437,7 @@ func (x *GetVariableResponse) String() string {
 func (*GetVariableResponse) ProtoMessage() {}
 
 func (x *GetVariableResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[24]
+	mi := &file_plugin_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1283,7 +1450,7 @@ func (x *GetVariableResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetVariableResponse.ProtoReflect.Descriptor instead.
 func (*GetVariableResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{24}
+	return file_plugin_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *GetVariableResponse) GetValue() *_struct.Struct {
@@ -1303,7 +1470,7 @@ type SetVariableRequest struct {
 
 func (x *SetVariableRequest) Reset() {
 	*x = SetVariableRequest{}
-	mi := &file_plugin_proto_msgTypes[25]
+	mi := &file_plugin_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1315,7 +1482,7 @@ func (x *SetVariableRequest) String() string {
 func (*SetVariableRequest) ProtoMessage() {}
 
 func (x *SetVariableRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[25]
+	mi := &file_plugin_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1328,7 +1495,7 @@ func (x *SetVariableRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetVariableRequest.ProtoReflect.Descriptor instead.
 func (*SetVariableRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{25}
+	return file_plugin_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *SetVariableRequest) GetVariable() *Variable {
@@ -1354,7 +1521,7 @@ type GetRobotInfoResponse struct {
 
 func (x *GetRobotInfoResponse) Reset() {
 	*x = GetRobotInfoResponse{}
-	mi := &file_plugin_proto_msgTypes[26]
+	mi := &file_plugin_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1366,7 +1533,7 @@ func (x *GetRobotInfoResponse) String() string {
 func (*GetRobotInfoResponse) ProtoMessage() {}
 
 func (x *GetRobotInfoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[26]
+	mi := &file_plugin_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1379,7 +1546,7 @@ func (x *GetRobotInfoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRobotInfoResponse.ProtoReflect.Descriptor instead.
 func (*GetRobotInfoResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{26}
+	return file_plugin_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *GetRobotInfoResponse) GetRobot() *_struct.Struct {
@@ -1399,7 +1566,7 @@ type AppRequestRequest struct {
 
 func (x *AppRequestRequest) Reset() {
 	*x = AppRequestRequest{}
-	mi := &file_plugin_proto_msgTypes[27]
+	mi := &file_plugin_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1411,7 +1578,7 @@ func (x *AppRequestRequest) String() string {
 func (*AppRequestRequest) ProtoMessage() {}
 
 func (x *AppRequestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[27]
+	mi := &file_plugin_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1424,7 +1591,7 @@ func (x *AppRequestRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppRequestRequest.ProtoReflect.Descriptor instead.
 func (*AppRequestRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{27}
+	return file_plugin_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *AppRequestRequest) GetRequest() []byte {
@@ -1450,7 +1617,7 @@ type AppRequestV2Request struct {
 
 func (x *AppRequestV2Request) Reset() {
 	*x = AppRequestV2Request{}
-	mi := &file_plugin_proto_msgTypes[28]
+	mi := &file_plugin_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1462,7 +1629,7 @@ func (x *AppRequestV2Request) String() string {
 func (*AppRequestV2Request) ProtoMessage() {}
 
 func (x *AppRequestV2Request) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[28]
+	mi := &file_plugin_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1475,7 +1642,7 @@ func (x *AppRequestV2Request) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppRequestV2Request.ProtoReflect.Descriptor instead.
 func (*AppRequestV2Request) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{28}
+	return file_plugin_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *AppRequestV2Request) GetRequest() []byte {
@@ -1494,7 +1661,7 @@ type AppRequestResponse struct {
 
 func (x *AppRequestResponse) Reset() {
 	*x = AppRequestResponse{}
-	mi := &file_plugin_proto_msgTypes[29]
+	mi := &file_plugin_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1506,7 +1673,7 @@ func (x *AppRequestResponse) String() string {
 func (*AppRequestResponse) ProtoMessage() {}
 
 func (x *AppRequestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[29]
+	mi := &file_plugin_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1519,7 +1686,7 @@ func (x *AppRequestResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppRequestResponse.ProtoReflect.Descriptor instead.
 func (*AppRequestResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{29}
+	return file_plugin_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *AppRequestResponse) GetResponse() []byte {
@@ -1538,7 +1705,7 @@ type AppPublishRequest struct {
 
 func (x *AppPublishRequest) Reset() {
 	*x = AppPublishRequest{}
-	mi := &file_plugin_proto_msgTypes[30]
+	mi := &file_plugin_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1550,7 +1717,7 @@ func (x *AppPublishRequest) String() string {
 func (*AppPublishRequest) ProtoMessage() {}
 
 func (x *AppPublishRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[30]
+	mi := &file_plugin_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1563,7 +1730,7 @@ func (x *AppPublishRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppPublishRequest.ProtoReflect.Descriptor instead.
 func (*AppPublishRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{30}
+	return file_plugin_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *AppPublishRequest) GetRequest() []byte {
@@ -1583,7 +1750,7 @@ type DownloadFileRequest struct {
 
 func (x *DownloadFileRequest) Reset() {
 	*x = DownloadFileRequest{}
-	mi := &file_plugin_proto_msgTypes[31]
+	mi := &file_plugin_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1595,7 +1762,7 @@ func (x *DownloadFileRequest) String() string {
 func (*DownloadFileRequest) ProtoMessage() {}
 
 func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[31]
+	mi := &file_plugin_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1608,7 +1775,7 @@ func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DownloadFileRequest.ProtoReflect.Descriptor instead.
 func (*DownloadFileRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{31}
+	return file_plugin_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *DownloadFileRequest) GetUrl() string {
@@ -1636,7 +1803,7 @@ type AppDownloadRequest struct {
 
 func (x *AppDownloadRequest) Reset() {
 	*x = AppDownloadRequest{}
-	mi := &file_plugin_proto_msgTypes[32]
+	mi := &file_plugin_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1648,7 +1815,7 @@ func (x *AppDownloadRequest) String() string {
 func (*AppDownloadRequest) ProtoMessage() {}
 
 func (x *AppDownloadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[32]
+	mi := &file_plugin_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1661,7 +1828,7 @@ func (x *AppDownloadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppDownloadRequest.ProtoReflect.Descriptor instead.
 func (*AppDownloadRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{32}
+	return file_plugin_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *AppDownloadRequest) GetDirectory() string {
@@ -1694,7 +1861,7 @@ type AppDownloadResponse struct {
 
 func (x *AppDownloadResponse) Reset() {
 	*x = AppDownloadResponse{}
-	mi := &file_plugin_proto_msgTypes[33]
+	mi := &file_plugin_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1706,7 +1873,7 @@ func (x *AppDownloadResponse) String() string {
 func (*AppDownloadResponse) ProtoMessage() {}
 
 func (x *AppDownloadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[33]
+	mi := &file_plugin_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1719,7 +1886,7 @@ func (x *AppDownloadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppDownloadResponse.ProtoReflect.Descriptor instead.
 func (*AppDownloadResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{33}
+	return file_plugin_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *AppDownloadResponse) GetPath() string {
@@ -1739,7 +1906,7 @@ type AppUploadRequest struct {
 
 func (x *AppUploadRequest) Reset() {
 	*x = AppUploadRequest{}
-	mi := &file_plugin_proto_msgTypes[34]
+	mi := &file_plugin_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1751,7 +1918,7 @@ func (x *AppUploadRequest) String() string {
 func (*AppUploadRequest) ProtoMessage() {}
 
 func (x *AppUploadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[34]
+	mi := &file_plugin_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1764,7 +1931,7 @@ func (x *AppUploadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppUploadRequest.ProtoReflect.Descriptor instead.
 func (*AppUploadRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{34}
+	return file_plugin_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *AppUploadRequest) GetId() string {
@@ -1790,7 +1957,7 @@ type AppUploadResponse struct {
 
 func (x *AppUploadResponse) Reset() {
 	*x = AppUploadResponse{}
-	mi := &file_plugin_proto_msgTypes[35]
+	mi := &file_plugin_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1802,7 +1969,7 @@ func (x *AppUploadResponse) String() string {
 func (*AppUploadResponse) ProtoMessage() {}
 
 func (x *AppUploadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[35]
+	mi := &file_plugin_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1815,7 +1982,7 @@ func (x *AppUploadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppUploadResponse.ProtoReflect.Descriptor instead.
 func (*AppUploadResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{35}
+	return file_plugin_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *AppUploadResponse) GetUrl() string {
@@ -1837,7 +2004,7 @@ type GatewayRequestRequest struct {
 
 func (x *GatewayRequestRequest) Reset() {
 	*x = GatewayRequestRequest{}
-	mi := &file_plugin_proto_msgTypes[36]
+	mi := &file_plugin_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1849,7 +2016,7 @@ func (x *GatewayRequestRequest) String() string {
 func (*GatewayRequestRequest) ProtoMessage() {}
 
 func (x *GatewayRequestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[36]
+	mi := &file_plugin_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1862,7 +2029,7 @@ func (x *GatewayRequestRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GatewayRequestRequest.ProtoReflect.Descriptor instead.
 func (*GatewayRequestRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{36}
+	return file_plugin_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *GatewayRequestRequest) GetMethod() string {
@@ -1904,7 +2071,7 @@ type GatewayRequestResponse struct {
 
 func (x *GatewayRequestResponse) Reset() {
 	*x = GatewayRequestResponse{}
-	mi := &file_plugin_proto_msgTypes[37]
+	mi := &file_plugin_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1916,7 +2083,7 @@ func (x *GatewayRequestResponse) String() string {
 func (*GatewayRequestResponse) ProtoMessage() {}
 
 func (x *GatewayRequestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[37]
+	mi := &file_plugin_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1929,7 +2096,7 @@ func (x *GatewayRequestResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GatewayRequestResponse.ProtoReflect.Descriptor instead.
 func (*GatewayRequestResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{37}
+	return file_plugin_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *GatewayRequestResponse) GetStatusCode() int32 {
@@ -1965,7 +2132,7 @@ type HttpRequest struct {
 
 func (x *HttpRequest) Reset() {
 	*x = HttpRequest{}
-	mi := &file_plugin_proto_msgTypes[38]
+	mi := &file_plugin_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1977,7 +2144,7 @@ func (x *HttpRequest) String() string {
 func (*HttpRequest) ProtoMessage() {}
 
 func (x *HttpRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[38]
+	mi := &file_plugin_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1990,7 +2157,7 @@ func (x *HttpRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HttpRequest.ProtoReflect.Descriptor instead.
 func (*HttpRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{38}
+	return file_plugin_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *HttpRequest) GetMethod() string {
@@ -2032,7 +2199,7 @@ type HttpResponse struct {
 
 func (x *HttpResponse) Reset() {
 	*x = HttpResponse{}
-	mi := &file_plugin_proto_msgTypes[39]
+	mi := &file_plugin_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2044,7 +2211,7 @@ func (x *HttpResponse) String() string {
 func (*HttpResponse) ProtoMessage() {}
 
 func (x *HttpResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[39]
+	mi := &file_plugin_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2057,7 +2224,7 @@ func (x *HttpResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HttpResponse.ProtoReflect.Descriptor instead.
 func (*HttpResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{39}
+	return file_plugin_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *HttpResponse) GetStatusCode() int32 {
@@ -2093,7 +2260,7 @@ type NodeInfo struct {
 
 func (x *NodeInfo) Reset() {
 	*x = NodeInfo{}
-	mi := &file_plugin_proto_msgTypes[40]
+	mi := &file_plugin_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2105,7 +2272,7 @@ func (x *NodeInfo) String() string {
 func (*NodeInfo) ProtoMessage() {}
 
 func (x *NodeInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[40]
+	mi := &file_plugin_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2118,7 +2285,7 @@ func (x *NodeInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeInfo.ProtoReflect.Descriptor instead.
 func (*NodeInfo) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{40}
+	return file_plugin_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *NodeInfo) GetType() string {
@@ -2153,7 +2320,7 @@ type GetPortConnectionsRequest struct {
 
 func (x *GetPortConnectionsRequest) Reset() {
 	*x = GetPortConnectionsRequest{}
-	mi := &file_plugin_proto_msgTypes[41]
+	mi := &file_plugin_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2165,7 +2332,7 @@ func (x *GetPortConnectionsRequest) String() string {
 func (*GetPortConnectionsRequest) ProtoMessage() {}
 
 func (x *GetPortConnectionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[41]
+	mi := &file_plugin_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2178,7 +2345,7 @@ func (x *GetPortConnectionsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPortConnectionsRequest.ProtoReflect.Descriptor instead.
 func (*GetPortConnectionsRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{41}
+	return file_plugin_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *GetPortConnectionsRequest) GetGuid() string {
@@ -2205,7 +2372,7 @@ type GetPortConnectionsResponse struct {
 
 func (x *GetPortConnectionsResponse) Reset() {
 	*x = GetPortConnectionsResponse{}
-	mi := &file_plugin_proto_msgTypes[42]
+	mi := &file_plugin_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2217,7 +2384,7 @@ func (x *GetPortConnectionsResponse) String() string {
 func (*GetPortConnectionsResponse) ProtoMessage() {}
 
 func (x *GetPortConnectionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[42]
+	mi := &file_plugin_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2230,7 +2397,7 @@ func (x *GetPortConnectionsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPortConnectionsResponse.ProtoReflect.Descriptor instead.
 func (*GetPortConnectionsResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{42}
+	return file_plugin_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *GetPortConnectionsResponse) GetNodes() []*NodeInfo {
@@ -2252,7 +2419,7 @@ type GetInstanceAccessResponse struct {
 
 func (x *GetInstanceAccessResponse) Reset() {
 	*x = GetInstanceAccessResponse{}
-	mi := &file_plugin_proto_msgTypes[43]
+	mi := &file_plugin_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2264,7 +2431,7 @@ func (x *GetInstanceAccessResponse) String() string {
 func (*GetInstanceAccessResponse) ProtoMessage() {}
 
 func (x *GetInstanceAccessResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[43]
+	mi := &file_plugin_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2277,7 +2444,7 @@ func (x *GetInstanceAccessResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetInstanceAccessResponse.ProtoReflect.Descriptor instead.
 func (*GetInstanceAccessResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{43}
+	return file_plugin_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *GetInstanceAccessResponse) GetAmqEndpoint() string {
@@ -2315,7 +2482,7 @@ type SetupEmitRequest struct {
 
 func (x *SetupEmitRequest) Reset() {
 	*x = SetupEmitRequest{}
-	mi := &file_plugin_proto_msgTypes[44]
+	mi := &file_plugin_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2327,7 +2494,7 @@ func (x *SetupEmitRequest) String() string {
 func (*SetupEmitRequest) ProtoMessage() {}
 
 func (x *SetupEmitRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[44]
+	mi := &file_plugin_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2340,7 +2507,7 @@ func (x *SetupEmitRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetupEmitRequest.ProtoReflect.Descriptor instead.
 func (*SetupEmitRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{44}
+	return file_plugin_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *SetupEmitRequest) GetGuid() string {
@@ -2379,7 +2546,7 @@ type SetupAwaitRequest struct {
 
 func (x *SetupAwaitRequest) Reset() {
 	*x = SetupAwaitRequest{}
-	mi := &file_plugin_proto_msgTypes[45]
+	mi := &file_plugin_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2391,7 +2558,7 @@ func (x *SetupAwaitRequest) String() string {
 func (*SetupAwaitRequest) ProtoMessage() {}
 
 func (x *SetupAwaitRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[45]
+	mi := &file_plugin_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2404,7 +2571,7 @@ func (x *SetupAwaitRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetupAwaitRequest.ProtoReflect.Descriptor instead.
 func (*SetupAwaitRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{45}
+	return file_plugin_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *SetupAwaitRequest) GetGuid() string {
@@ -2445,7 +2612,7 @@ type SetupAwaitResponse struct {
 
 func (x *SetupAwaitResponse) Reset() {
 	*x = SetupAwaitResponse{}
-	mi := &file_plugin_proto_msgTypes[46]
+	mi := &file_plugin_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2457,7 +2624,7 @@ func (x *SetupAwaitResponse) String() string {
 func (*SetupAwaitResponse) ProtoMessage() {}
 
 func (x *SetupAwaitResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[46]
+	mi := &file_plugin_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2470,7 +2637,7 @@ func (x *SetupAwaitResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetupAwaitResponse.ProtoReflect.Descriptor instead.
 func (*SetupAwaitResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{46}
+	return file_plugin_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *SetupAwaitResponse) GetInput() []byte {
@@ -2487,6 +2654,94 @@ func (x *SetupAwaitResponse) GetTimedOut() bool {
 	return false
 }
 
+type GetMetricsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetricsResponse) Reset() {
+	*x = GetMetricsResponse{}
+	mi := &file_plugin_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricsResponse) ProtoMessage() {}
+
+func (x *GetMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricsResponse.ProtoReflect.Descriptor instead.
+func (*GetMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *GetMetricsResponse) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type IntrospectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Report        []byte                 `protobuf:"bytes,1,opt,name=report,proto3" json:"report,omitempty"` // JSON runtime.IntrospectReport
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntrospectResponse) Reset() {
+	*x = IntrospectResponse{}
+	mi := &file_plugin_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntrospectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntrospectResponse) ProtoMessage() {}
+
+func (x *IntrospectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntrospectResponse.ProtoReflect.Descriptor instead.
+func (*IntrospectResponse) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *IntrospectResponse) GetReport() []byte {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
 var File_plugin_proto protoreflect.FileDescriptor
 
 const file_plugin_proto_rawDesc = "" +
@@ -2506,18 +2761,31 @@ const file_plugin_proto_rawDesc = "" +
 	"\x05error\x18\x01 \x01(\v2\f.proto.ErrorR\x05error\"D\n" +
 	"\x10OnMessageRequest\x12\x12\n" +
 	"\x04guid\x18\x01 \x01(\tR\x04guid\x12\x1c\n" +
-	"\tinMessage\x18\x02 \x01(\fR\tinMessage\"W\n" +
+	"\tinMessage\x18\x02 \x01(\fR\tinMessage\"\x85\x01\n" +
 	"\x11OnMessageResponse\x12\x1e\n" +
 	"\n" +
 	"outMessage\x18\x01 \x01(\fR\n" +
 	"outMessage\x12\"\n" +
-	"\x05error\x18\x02 \x01(\v2\f.proto.ErrorR\x05error\"$\n" +
+	"\x05error\x18\x02 \x01(\v2\f.proto.ErrorR\x05error\x12,\n" +
+	"\aoutputs\x18\x03 \x03(\v2\x12.proto.PortMessageR\aoutputs\";\n" +
+	"\vPortMessage\x12\x12\n" +
+	"\x04port\x18\x01 \x01(\x05R\x04port\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\fR\amessage\"$\n" +
 	"\x0eOnCloseRequest\x12\x12\n" +
 	"\x04guid\x18\x01 \x01(\tR\x04guid\"5\n" +
 	"\x0fOnCloseResponse\x12\"\n" +
-	"\x05error\x18\x01 \x01(\v2\f.proto.ErrorR\x05error\">\n" +
+	"\x05error\x18\x01 \x01(\v2\f.proto.ErrorR\x05error\"=\n" +
+	"\x0fOnUpdateRequest\x12\x12\n" +
+	"\x04guid\x18\x01 \x01(\tR\x04guid\x12\x16\n" +
+	"\x06config\x18\x02 \x01(\fR\x06config\"0\n" +
+	"\x10OnUpdateResponse\x12\x1c\n" +
+	"\trestarted\x18\x01 \x01(\bR\trestarted\"\xc6\x01\n" +
 	"\x18PGetCapabilitiesResponse\x12\"\n" +
-	"\fcapabilities\x18\x01 \x01(\x04R\fcapabilities\"[\n" +
+	"\fcapabilities\x18\x01 \x01(\x04R\fcapabilities\x12I\n" +
+	"\bfeatures\x18\x02 \x03(\v2-.proto.PGetCapabilitiesResponse.FeaturesEntryR\bfeatures\x1a;\n" +
+	"\rFeaturesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"[\n" +
 	"\x0eOnSetupRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
 	"\x06config\x18\x02 \x01(\fR\x06config\x12\x1d\n" +
@@ -2653,14 +2921,23 @@ const file_plugin_proto_rawDesc = "" +
 	"\atimeout\x18\x04 \x01(\x05R\atimeout\"G\n" +
 	"\x12SetupAwaitResponse\x12\x14\n" +
 	"\x05input\x18\x01 \x01(\fR\x05input\x12\x1b\n" +
-	"\ttimed_out\x18\x02 \x01(\bR\btimedOut2\xe3\x02\n" +
+	"\ttimed_out\x18\x02 \x01(\bR\btimedOut\"(\n" +
+	"\x12GetMetricsResponse\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\",\n" +
+	"\x12IntrospectResponse\x12\x16\n" +
+	"\x06report\x18\x01 \x01(\fR\x06report2\x8e\x04\n" +
 	"\x04Node\x12(\n" +
 	"\x04Init\x12\x12.proto.InitRequest\x1a\f.proto.Empty\x12;\n" +
 	"\bOnCreate\x12\x16.proto.OnCreateRequest\x1a\x17.proto.OnCreateResponse\x12>\n" +
 	"\tOnMessage\x12\x17.proto.OnMessageRequest\x1a\x18.proto.OnMessageResponse\x128\n" +
 	"\aOnClose\x12\x15.proto.OnCloseRequest\x1a\x16.proto.OnCloseResponse\x12@\n" +
 	"\x0fGetCapabilities\x12\f.proto.Empty\x1a\x1f.proto.PGetCapabilitiesResponse\x128\n" +
-	"\aOnSetup\x12\x15.proto.OnSetupRequest\x1a\x16.proto.OnSetupResponse2\xd9\v\n" +
+	"\aOnSetup\x12\x15.proto.OnSetupRequest\x1a\x16.proto.OnSetupResponse\x125\n" +
+	"\n" +
+	"GetMetrics\x12\f.proto.Empty\x1a\x19.proto.GetMetricsResponse\x12;\n" +
+	"\bOnUpdate\x12\x16.proto.OnUpdateRequest\x1a\x17.proto.OnUpdateResponse\x125\n" +
+	"\n" +
+	"Introspect\x12\f.proto.Empty\x1a\x19.proto.IntrospectResponse2\xd9\v\n" +
 	"\rRuntimeHelper\x12#\n" +
 	"\x05Close\x12\f.proto.Empty\x1a\f.proto.Empty\x12*\n" +
 	"\x05Debug\x12\x13.proto.DebugRequest\x1a\f.proto.Empty\x12:\n" +
@@ -2703,7 +2980,7 @@ func file_plugin_proto_rawDescGZIP() []byte {
 	return file_plugin_proto_rawDescData
 }
 
-var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 51)
+var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 57)
 var file_plugin_proto_goTypes = []any{
 	(*Error)(nil),                      // 0: proto.Error
 	(*InitRequest)(nil),                // 1: proto.InitRequest
@@ -2711,135 +2988,149 @@ var file_plugin_proto_goTypes = []any{
 	(*OnCreateResponse)(nil),           // 3: proto.OnCreateResponse
 	(*OnMessageRequest)(nil),           // 4: proto.OnMessageRequest
 	(*OnMessageResponse)(nil),          // 5: proto.OnMessageResponse
-	(*OnCloseRequest)(nil),             // 6: proto.OnCloseRequest
-	(*OnCloseResponse)(nil),            // 7: proto.OnCloseResponse
-	(*PGetCapabilitiesResponse)(nil),   // 8: proto.PGetCapabilitiesResponse
-	(*OnSetupRequest)(nil),             // 9: proto.OnSetupRequest
-	(*OnSetupResponse)(nil),            // 10: proto.OnSetupResponse
-	(*Empty)(nil),                      // 11: proto.Empty
-	(*IsRunningResponse)(nil),          // 12: proto.IsRunningResponse
-	(*DebugRequest)(nil),               // 13: proto.DebugRequest
-	(*EmitFlowEventRequest)(nil),       // 14: proto.EmitFlowEventRequest
-	(*EmitInputRequest)(nil),           // 15: proto.EmitInputRequest
-	(*EmitOutputRequest)(nil),          // 16: proto.EmitOutputRequest
-	(*EmitErrorRequest)(nil),           // 17: proto.EmitErrorRequest
-	(*GetVaultItemRequest)(nil),        // 18: proto.GetVaultItemRequest
-	(*GetVaultItemResponse)(nil),       // 19: proto.GetVaultItemResponse
-	(*SetVaultItemRequest)(nil),        // 20: proto.SetVaultItemRequest
-	(*SetVaultItemResponse)(nil),       // 21: proto.SetVaultItemResponse
-	(*Variable)(nil),                   // 22: proto.Variable
-	(*GetVariableRequest)(nil),         // 23: proto.GetVariableRequest
-	(*GetVariableResponse)(nil),        // 24: proto.GetVariableResponse
-	(*SetVariableRequest)(nil),         // 25: proto.SetVariableRequest
-	(*GetRobotInfoResponse)(nil),       // 26: proto.GetRobotInfoResponse
-	(*AppRequestRequest)(nil),          // 27: proto.AppRequestRequest
-	(*AppRequestV2Request)(nil),        // 28: proto.AppRequestV2Request
-	(*AppRequestResponse)(nil),         // 29: proto.AppRequestResponse
-	(*AppPublishRequest)(nil),          // 30: proto.AppPublishRequest
-	(*DownloadFileRequest)(nil),        // 31: proto.DownloadFileRequest
-	(*AppDownloadRequest)(nil),         // 32: proto.AppDownloadRequest
-	(*AppDownloadResponse)(nil),        // 33: proto.AppDownloadResponse
-	(*AppUploadRequest)(nil),           // 34: proto.AppUploadRequest
-	(*AppUploadResponse)(nil),          // 35: proto.AppUploadResponse
-	(*GatewayRequestRequest)(nil),      // 36: proto.GatewayRequestRequest
-	(*GatewayRequestResponse)(nil),     // 37: proto.GatewayRequestResponse
-	(*HttpRequest)(nil),                // 38: proto.HttpRequest
-	(*HttpResponse)(nil),               // 39: proto.HttpResponse
-	(*NodeInfo)(nil),                   // 40: proto.NodeInfo
-	(*GetPortConnectionsRequest)(nil),  // 41: proto.GetPortConnectionsRequest
-	(*GetPortConnectionsResponse)(nil), // 42: proto.GetPortConnectionsResponse
-	(*GetInstanceAccessResponse)(nil),  // 43: proto.GetInstanceAccessResponse
-	(*SetupEmitRequest)(nil),           // 44: proto.SetupEmitRequest
-	(*SetupAwaitRequest)(nil),          // 45: proto.SetupAwaitRequest
-	(*SetupAwaitResponse)(nil),         // 46: proto.SetupAwaitResponse
-	nil,                                // 47: proto.GatewayRequestRequest.HeadersEntry
-	nil,                                // 48: proto.GatewayRequestResponse.HeadersEntry
-	nil,                                // 49: proto.HttpRequest.HeadersEntry
-	nil,                                // 50: proto.HttpResponse.HeadersEntry
-	(*_struct.Struct)(nil),             // 51: google.protobuf.Struct
+	(*PortMessage)(nil),                // 6: proto.PortMessage
+	(*OnCloseRequest)(nil),             // 7: proto.OnCloseRequest
+	(*OnCloseResponse)(nil),            // 8: proto.OnCloseResponse
+	(*OnUpdateRequest)(nil),            // 9: proto.OnUpdateRequest
+	(*OnUpdateResponse)(nil),           // 10: proto.OnUpdateResponse
+	(*PGetCapabilitiesResponse)(nil),   // 11: proto.PGetCapabilitiesResponse
+	(*OnSetupRequest)(nil),             // 12: proto.OnSetupRequest
+	(*OnSetupResponse)(nil),            // 13: proto.OnSetupResponse
+	(*Empty)(nil),                      // 14: proto.Empty
+	(*IsRunningResponse)(nil),          // 15: proto.IsRunningResponse
+	(*DebugRequest)(nil),               // 16: proto.DebugRequest
+	(*EmitFlowEventRequest)(nil),       // 17: proto.EmitFlowEventRequest
+	(*EmitInputRequest)(nil),           // 18: proto.EmitInputRequest
+	(*EmitOutputRequest)(nil),          // 19: proto.EmitOutputRequest
+	(*EmitErrorRequest)(nil),           // 20: proto.EmitErrorRequest
+	(*GetVaultItemRequest)(nil),        // 21: proto.GetVaultItemRequest
+	(*GetVaultItemResponse)(nil),       // 22: proto.GetVaultItemResponse
+	(*SetVaultItemRequest)(nil),        // 23: proto.SetVaultItemRequest
+	(*SetVaultItemResponse)(nil),       // 24: proto.SetVaultItemResponse
+	(*Variable)(nil),                   // 25: proto.Variable
+	(*GetVariableRequest)(nil),         // 26: proto.GetVariableRequest
+	(*GetVariableResponse)(nil),        // 27: proto.GetVariableResponse
+	(*SetVariableRequest)(nil),         // 28: proto.SetVariableRequest
+	(*GetRobotInfoResponse)(nil),       // 29: proto.GetRobotInfoResponse
+	(*AppRequestRequest)(nil),          // 30: proto.AppRequestRequest
+	(*AppRequestV2Request)(nil),        // 31: proto.AppRequestV2Request
+	(*AppRequestResponse)(nil),         // 32: proto.AppRequestResponse
+	(*AppPublishRequest)(nil),          // 33: proto.AppPublishRequest
+	(*DownloadFileRequest)(nil),        // 34: proto.DownloadFileRequest
+	(*AppDownloadRequest)(nil),         // 35: proto.AppDownloadRequest
+	(*AppDownloadResponse)(nil),        // 36: proto.AppDownloadResponse
+	(*AppUploadRequest)(nil),           // 37: proto.AppUploadRequest
+	(*AppUploadResponse)(nil),          // 38: proto.AppUploadResponse
+	(*GatewayRequestRequest)(nil),      // 39: proto.GatewayRequestRequest
+	(*GatewayRequestResponse)(nil),     // 40: proto.GatewayRequestResponse
+	(*HttpRequest)(nil),                // 41: proto.HttpRequest
+	(*HttpResponse)(nil),               // 42: proto.HttpResponse
+	(*NodeInfo)(nil),                   // 43: proto.NodeInfo
+	(*GetPortConnectionsRequest)(nil),  // 44: proto.GetPortConnectionsRequest
+	(*GetPortConnectionsResponse)(nil), // 45: proto.GetPortConnectionsResponse
+	(*GetInstanceAccessResponse)(nil),  // 46: proto.GetInstanceAccessResponse
+	(*SetupEmitRequest)(nil),           // 47: proto.SetupEmitRequest
+	(*SetupAwaitRequest)(nil),          // 48: proto.SetupAwaitRequest
+	(*SetupAwaitResponse)(nil),         // 49: proto.SetupAwaitResponse
+	(*GetMetricsResponse)(nil),         // 50: proto.GetMetricsResponse
+	(*IntrospectResponse)(nil),         // 51: proto.IntrospectResponse
+	nil,                                // 52: proto.PGetCapabilitiesResponse.FeaturesEntry
+	nil,                                // 53: proto.GatewayRequestRequest.HeadersEntry
+	nil,                                // 54: proto.GatewayRequestResponse.HeadersEntry
+	nil,                                // 55: proto.HttpRequest.HeadersEntry
+	nil,                                // 56: proto.HttpResponse.HeadersEntry
+	(*_struct.Struct)(nil),             // 57: google.protobuf.Struct
 }
 var file_plugin_proto_depIdxs = []int32{
 	0,  // 0: proto.OnCreateResponse.error:type_name -> proto.Error
 	0,  // 1: proto.OnMessageResponse.error:type_name -> proto.Error
-	0,  // 2: proto.OnCloseResponse.error:type_name -> proto.Error
-	0,  // 3: proto.OnSetupResponse.error:type_name -> proto.Error
-	51, // 4: proto.GetVaultItemResponse.item:type_name -> google.protobuf.Struct
-	51, // 5: proto.SetVaultItemResponse.item:type_name -> google.protobuf.Struct
-	22, // 6: proto.GetVariableRequest.variable:type_name -> proto.Variable
-	51, // 7: proto.GetVariableResponse.value:type_name -> google.protobuf.Struct
-	22, // 8: proto.SetVariableRequest.variable:type_name -> proto.Variable
-	51, // 9: proto.SetVariableRequest.value:type_name -> google.protobuf.Struct
-	51, // 10: proto.GetRobotInfoResponse.robot:type_name -> google.protobuf.Struct
-	47, // 11: proto.GatewayRequestRequest.headers:type_name -> proto.GatewayRequestRequest.HeadersEntry
-	48, // 12: proto.GatewayRequestResponse.headers:type_name -> proto.GatewayRequestResponse.HeadersEntry
-	49, // 13: proto.HttpRequest.headers:type_name -> proto.HttpRequest.HeadersEntry
-	50, // 14: proto.HttpResponse.headers:type_name -> proto.HttpResponse.HeadersEntry
-	40, // 15: proto.GetPortConnectionsResponse.nodes:type_name -> proto.NodeInfo
-	1,  // 16: proto.Node.Init:input_type -> proto.InitRequest
-	2,  // 17: proto.Node.OnCreate:input_type -> proto.OnCreateRequest
-	4,  // 18: proto.Node.OnMessage:input_type -> proto.OnMessageRequest
-	6,  // 19: proto.Node.OnClose:input_type -> proto.OnCloseRequest
-	11, // 20: proto.Node.GetCapabilities:input_type -> proto.Empty
-	9,  // 21: proto.Node.OnSetup:input_type -> proto.OnSetupRequest
-	11, // 22: proto.RuntimeHelper.Close:input_type -> proto.Empty
-	13, // 23: proto.RuntimeHelper.Debug:input_type -> proto.DebugRequest
-	14, // 24: proto.RuntimeHelper.EmitFlowEvent:input_type -> proto.EmitFlowEventRequest
-	15, // 25: proto.RuntimeHelper.EmitInput:input_type -> proto.EmitInputRequest
-	16, // 26: proto.RuntimeHelper.EmitOutput:input_type -> proto.EmitOutputRequest
-	17, // 27: proto.RuntimeHelper.EmitError:input_type -> proto.EmitErrorRequest
-	18, // 28: proto.RuntimeHelper.GetVaultItem:input_type -> proto.GetVaultItemRequest
-	20, // 29: proto.RuntimeHelper.SetVaultItem:input_type -> proto.SetVaultItemRequest
-	23, // 30: proto.RuntimeHelper.GetVariable:input_type -> proto.GetVariableRequest
-	25, // 31: proto.RuntimeHelper.SetVariable:input_type -> proto.SetVariableRequest
-	11, // 32: proto.RuntimeHelper.GetRobotInfo:input_type -> proto.Empty
-	27, // 33: proto.RuntimeHelper.AppRequest:input_type -> proto.AppRequestRequest
-	28, // 34: proto.RuntimeHelper.AppRequestV2:input_type -> proto.AppRequestV2Request
-	30, // 35: proto.RuntimeHelper.AppPublish:input_type -> proto.AppPublishRequest
-	31, // 36: proto.RuntimeHelper.DownloadFile:input_type -> proto.DownloadFileRequest
-	32, // 37: proto.RuntimeHelper.AppDownload:input_type -> proto.AppDownloadRequest
-	34, // 38: proto.RuntimeHelper.AppUpload:input_type -> proto.AppUploadRequest
-	36, // 39: proto.RuntimeHelper.GatewayRequest:input_type -> proto.GatewayRequestRequest
-	38, // 40: proto.RuntimeHelper.ProxyRequest:input_type -> proto.HttpRequest
-	41, // 41: proto.RuntimeHelper.GetPortConnections:input_type -> proto.GetPortConnectionsRequest
-	11, // 42: proto.RuntimeHelper.IsRunning:input_type -> proto.Empty
-	11, // 43: proto.RuntimeHelper.GetInstanceAccess:input_type -> proto.Empty
-	44, // 44: proto.RuntimeHelper.SetupEmit:input_type -> proto.SetupEmitRequest
-	45, // 45: proto.RuntimeHelper.SetupAwait:input_type -> proto.SetupAwaitRequest
-	11, // 46: proto.Node.Init:output_type -> proto.Empty
-	3,  // 47: proto.Node.OnCreate:output_type -> proto.OnCreateResponse
-	5,  // 48: proto.Node.OnMessage:output_type -> proto.OnMessageResponse
-	7,  // 49: proto.Node.OnClose:output_type -> proto.OnCloseResponse
-	8,  // 50: proto.Node.GetCapabilities:output_type -> proto.PGetCapabilitiesResponse
-	10, // 51: proto.Node.OnSetup:output_type -> proto.OnSetupResponse
-	11, // 52: proto.RuntimeHelper.Close:output_type -> proto.Empty
-	11, // 53: proto.RuntimeHelper.Debug:output_type -> proto.Empty
-	11, // 54: proto.RuntimeHelper.EmitFlowEvent:output_type -> proto.Empty
-	11, // 55: proto.RuntimeHelper.EmitInput:output_type -> proto.Empty
-	11, // 56: proto.RuntimeHelper.EmitOutput:output_type -> proto.Empty
-	11, // 57: proto.RuntimeHelper.EmitError:output_type -> proto.Empty
-	19, // 58: proto.RuntimeHelper.GetVaultItem:output_type -> proto.GetVaultItemResponse
-	21, // 59: proto.RuntimeHelper.SetVaultItem:output_type -> proto.SetVaultItemResponse
-	24, // 60: proto.RuntimeHelper.GetVariable:output_type -> proto.GetVariableResponse
-	11, // 61: proto.RuntimeHelper.SetVariable:output_type -> proto.Empty
-	26, // 62: proto.RuntimeHelper.GetRobotInfo:output_type -> proto.GetRobotInfoResponse
-	29, // 63: proto.RuntimeHelper.AppRequest:output_type -> proto.AppRequestResponse
-	29, // 64: proto.RuntimeHelper.AppRequestV2:output_type -> proto.AppRequestResponse
-	11, // 65: proto.RuntimeHelper.AppPublish:output_type -> proto.Empty
-	11, // 66: proto.RuntimeHelper.DownloadFile:output_type -> proto.Empty
-	33, // 67: proto.RuntimeHelper.AppDownload:output_type -> proto.AppDownloadResponse
-	35, // 68: proto.RuntimeHelper.AppUpload:output_type -> proto.AppUploadResponse
-	37, // 69: proto.RuntimeHelper.GatewayRequest:output_type -> proto.GatewayRequestResponse
-	39, // 70: proto.RuntimeHelper.ProxyRequest:output_type -> proto.HttpResponse
-	42, // 71: proto.RuntimeHelper.GetPortConnections:output_type -> proto.GetPortConnectionsResponse
-	12, // 72: proto.RuntimeHelper.IsRunning:output_type -> proto.IsRunningResponse
-	43, // 73: proto.RuntimeHelper.GetInstanceAccess:output_type -> proto.GetInstanceAccessResponse
-	11, // 74: proto.RuntimeHelper.SetupEmit:output_type -> proto.Empty
-	46, // 75: proto.RuntimeHelper.SetupAwait:output_type -> proto.SetupAwaitResponse
-	46, // [46:76] is the sub-list for method output_type
-	16, // [16:46] is the sub-list for method input_type
-	16, // [16:16] is the sub-list for extension type_name
-	16, // [16:16] is the sub-list for extension extendee
-	0,  // [0:16] is the sub-list for field type_name
+	6,  // 2: proto.OnMessageResponse.outputs:type_name -> proto.PortMessage
+	0,  // 3: proto.OnCloseResponse.error:type_name -> proto.Error
+	52, // 4: proto.PGetCapabilitiesResponse.features:type_name -> proto.PGetCapabilitiesResponse.FeaturesEntry
+	0,  // 5: proto.OnSetupResponse.error:type_name -> proto.Error
+	57, // 6: proto.GetVaultItemResponse.item:type_name -> google.protobuf.Struct
+	57, // 7: proto.SetVaultItemResponse.item:type_name -> google.protobuf.Struct
+	25, // 8: proto.GetVariableRequest.variable:type_name -> proto.Variable
+	57, // 9: proto.GetVariableResponse.value:type_name -> google.protobuf.Struct
+	25, // 10: proto.SetVariableRequest.variable:type_name -> proto.Variable
+	57, // 11: proto.SetVariableRequest.value:type_name -> google.protobuf.Struct
+	57, // 12: proto.GetRobotInfoResponse.robot:type_name -> google.protobuf.Struct
+	53, // 13: proto.GatewayRequestRequest.headers:type_name -> proto.GatewayRequestRequest.HeadersEntry
+	54, // 14: proto.GatewayRequestResponse.headers:type_name -> proto.GatewayRequestResponse.HeadersEntry
+	55, // 15: proto.HttpRequest.headers:type_name -> proto.HttpRequest.HeadersEntry
+	56, // 16: proto.HttpResponse.headers:type_name -> proto.HttpResponse.HeadersEntry
+	43, // 17: proto.GetPortConnectionsResponse.nodes:type_name -> proto.NodeInfo
+	1,  // 18: proto.Node.Init:input_type -> proto.InitRequest
+	2,  // 19: proto.Node.OnCreate:input_type -> proto.OnCreateRequest
+	4,  // 20: proto.Node.OnMessage:input_type -> proto.OnMessageRequest
+	7,  // 21: proto.Node.OnClose:input_type -> proto.OnCloseRequest
+	14, // 22: proto.Node.GetCapabilities:input_type -> proto.Empty
+	12, // 23: proto.Node.OnSetup:input_type -> proto.OnSetupRequest
+	14, // 24: proto.Node.GetMetrics:input_type -> proto.Empty
+	9,  // 25: proto.Node.OnUpdate:input_type -> proto.OnUpdateRequest
+	14, // 26: proto.Node.Introspect:input_type -> proto.Empty
+	14, // 27: proto.RuntimeHelper.Close:input_type -> proto.Empty
+	16, // 28: proto.RuntimeHelper.Debug:input_type -> proto.DebugRequest
+	17, // 29: proto.RuntimeHelper.EmitFlowEvent:input_type -> proto.EmitFlowEventRequest
+	18, // 30: proto.RuntimeHelper.EmitInput:input_type -> proto.EmitInputRequest
+	19, // 31: proto.RuntimeHelper.EmitOutput:input_type -> proto.EmitOutputRequest
+	20, // 32: proto.RuntimeHelper.EmitError:input_type -> proto.EmitErrorRequest
+	21, // 33: proto.RuntimeHelper.GetVaultItem:input_type -> proto.GetVaultItemRequest
+	23, // 34: proto.RuntimeHelper.SetVaultItem:input_type -> proto.SetVaultItemRequest
+	26, // 35: proto.RuntimeHelper.GetVariable:input_type -> proto.GetVariableRequest
+	28, // 36: proto.RuntimeHelper.SetVariable:input_type -> proto.SetVariableRequest
+	14, // 37: proto.RuntimeHelper.GetRobotInfo:input_type -> proto.Empty
+	30, // 38: proto.RuntimeHelper.AppRequest:input_type -> proto.AppRequestRequest
+	31, // 39: proto.RuntimeHelper.AppRequestV2:input_type -> proto.AppRequestV2Request
+	33, // 40: proto.RuntimeHelper.AppPublish:input_type -> proto.AppPublishRequest
+	34, // 41: proto.RuntimeHelper.DownloadFile:input_type -> proto.DownloadFileRequest
+	35, // 42: proto.RuntimeHelper.AppDownload:input_type -> proto.AppDownloadRequest
+	37, // 43: proto.RuntimeHelper.AppUpload:input_type -> proto.AppUploadRequest
+	39, // 44: proto.RuntimeHelper.GatewayRequest:input_type -> proto.GatewayRequestRequest
+	41, // 45: proto.RuntimeHelper.ProxyRequest:input_type -> proto.HttpRequest
+	44, // 46: proto.RuntimeHelper.GetPortConnections:input_type -> proto.GetPortConnectionsRequest
+	14, // 47: proto.RuntimeHelper.IsRunning:input_type -> proto.Empty
+	14, // 48: proto.RuntimeHelper.GetInstanceAccess:input_type -> proto.Empty
+	47, // 49: proto.RuntimeHelper.SetupEmit:input_type -> proto.SetupEmitRequest
+	48, // 50: proto.RuntimeHelper.SetupAwait:input_type -> proto.SetupAwaitRequest
+	14, // 51: proto.Node.Init:output_type -> proto.Empty
+	3,  // 52: proto.Node.OnCreate:output_type -> proto.OnCreateResponse
+	5,  // 53: proto.Node.OnMessage:output_type -> proto.OnMessageResponse
+	8,  // 54: proto.Node.OnClose:output_type -> proto.OnCloseResponse
+	11, // 55: proto.Node.GetCapabilities:output_type -> proto.PGetCapabilitiesResponse
+	13, // 56: proto.Node.OnSetup:output_type -> proto.OnSetupResponse
+	50, // 57: proto.Node.GetMetrics:output_type -> proto.GetMetricsResponse
+	10, // 58: proto.Node.OnUpdate:output_type -> proto.OnUpdateResponse
+	51, // 59: proto.Node.Introspect:output_type -> proto.IntrospectResponse
+	14, // 60: proto.RuntimeHelper.Close:output_type -> proto.Empty
+	14, // 61: proto.RuntimeHelper.Debug:output_type -> proto.Empty
+	14, // 62: proto.RuntimeHelper.EmitFlowEvent:output_type -> proto.Empty
+	14, // 63: proto.RuntimeHelper.EmitInput:output_type -> proto.Empty
+	14, // 64: proto.RuntimeHelper.EmitOutput:output_type -> proto.Empty
+	14, // 65: proto.RuntimeHelper.EmitError:output_type -> proto.Empty
+	22, // 66: proto.RuntimeHelper.GetVaultItem:output_type -> proto.GetVaultItemResponse
+	24, // 67: proto.RuntimeHelper.SetVaultItem:output_type -> proto.SetVaultItemResponse
+	27, // 68: proto.RuntimeHelper.GetVariable:output_type -> proto.GetVariableResponse
+	14, // 69: proto.RuntimeHelper.SetVariable:output_type -> proto.Empty
+	29, // 70: proto.RuntimeHelper.GetRobotInfo:output_type -> proto.GetRobotInfoResponse
+	32, // 71: proto.RuntimeHelper.AppRequest:output_type -> proto.AppRequestResponse
+	32, // 72: proto.RuntimeHelper.AppRequestV2:output_type -> proto.AppRequestResponse
+	14, // 73: proto.RuntimeHelper.AppPublish:output_type -> proto.Empty
+	14, // 74: proto.RuntimeHelper.DownloadFile:output_type -> proto.Empty
+	36, // 75: proto.RuntimeHelper.AppDownload:output_type -> proto.AppDownloadResponse
+	38, // 76: proto.RuntimeHelper.AppUpload:output_type -> proto.AppUploadResponse
+	40, // 77: proto.RuntimeHelper.GatewayRequest:output_type -> proto.GatewayRequestResponse
+	42, // 78: proto.RuntimeHelper.ProxyRequest:output_type -> proto.HttpResponse
+	45, // 79: proto.RuntimeHelper.GetPortConnections:output_type -> proto.GetPortConnectionsResponse
+	15, // 80: proto.RuntimeHelper.IsRunning:output_type -> proto.IsRunningResponse
+	46, // 81: proto.RuntimeHelper.GetInstanceAccess:output_type -> proto.GetInstanceAccessResponse
+	14, // 82: proto.RuntimeHelper.SetupEmit:output_type -> proto.Empty
+	49, // 83: proto.RuntimeHelper.SetupAwait:output_type -> proto.SetupAwaitResponse
+	51, // [51:84] is the sub-list for method output_type
+	18, // [18:51] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_plugin_proto_init() }
@@ -2853,7 +3144,7 @@ func file_plugin_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_plugin_proto_rawDesc), len(file_plugin_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   51,
+			NumMessages:   57,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -25,6 +25,9 @@ const (
 	Node_OnClose_FullMethodName         = "/proto.Node/OnClose"
 	Node_GetCapabilities_FullMethodName = "/proto.Node/GetCapabilities"
 	Node_OnSetup_FullMethodName         = "/proto.Node/OnSetup"
+	Node_GetMetrics_FullMethodName      = "/proto.Node/GetMetrics"
+	Node_OnUpdate_FullMethodName        = "/proto.Node/OnUpdate"
+	Node_Introspect_FullMethodName      = "/proto.Node/Introspect"
 )
 
 // NodeClient is the client API for Node service.
@@ -42,8 +45,17 @@ type NodeClient interface {
 	// duration while emitting prompts via RuntimeHelper.SetupEmit and awaiting
 	// user input via RuntimeHelper.SetupAwait, then returns a result. Only
 	// nodes that implement the SetupHandler interface respond; others report
-	// an unsupported error. Capability-gated by CapabilitySetup.
+	// an unsupported error. Gated by the SetupHandler interface (no capability bit).
 	OnSetup(ctx context.Context, in *OnSetupRequest, opts ...grpc.CallOption) (*OnSetupResponse, error)
+	// GetMetrics returns the package's metrics in the Prometheus text format.
+	GetMetrics(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GetMetricsResponse, error)
+	// OnUpdate hands a running node a new configuration. Nodes implementing
+	// UpdateHandler apply it in place or ask for a restart; others are
+	// closed and created again from it.
+	OnUpdate(ctx context.Context, in *OnUpdateRequest, opts ...grpc.CallOption) (*OnUpdateResponse, error)
+	// Introspect reports what the package process is doing: its nodes,
+	// in-flight calls, goroutines, memory and capabilities.
+	Introspect(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*IntrospectResponse, error)
 }
 
 type nodeClient struct {
@@ -114,6 +126,36 @@ func (c *nodeClient) OnSetup(ctx context.Context, in *OnSetupRequest, opts ...gr
 	return out, nil
 }
 
+func (c *nodeClient) GetMetrics(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GetMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMetricsResponse)
+	err := c.cc.Invoke(ctx, Node_GetMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) OnUpdate(ctx context.Context, in *OnUpdateRequest, opts ...grpc.CallOption) (*OnUpdateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OnUpdateResponse)
+	err := c.cc.Invoke(ctx, Node_OnUpdate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Introspect(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*IntrospectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IntrospectResponse)
+	err := c.cc.Invoke(ctx, Node_Introspect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodeServer is the server API for Node service.
 // All implementations must embed UnimplementedNodeServer
 // for forward compatibility.
@@ -129,8 +171,17 @@ type NodeServer interface {
 	// duration while emitting prompts via RuntimeHelper.SetupEmit and awaiting
 	// user input via RuntimeHelper.SetupAwait, then returns a result. Only
 	// nodes that implement the SetupHandler interface respond; others report
-	// an unsupported error. Capability-gated by CapabilitySetup.
+	// an unsupported error. Gated by the SetupHandler interface (no capability bit).
 	OnSetup(context.Context, *OnSetupRequest) (*OnSetupResponse, error)
+	// GetMetrics returns the package's metrics in the Prometheus text format.
+	GetMetrics(context.Context, *Empty) (*GetMetricsResponse, error)
+	// OnUpdate hands a running node a new configuration. Nodes implementing
+	// UpdateHandler apply it in place or ask for a restart; others are
+	// closed and created again from it.
+	OnUpdate(context.Context, *OnUpdateRequest) (*OnUpdateResponse, error)
+	// Introspect reports what the package process is doing: its nodes,
+	// in-flight calls, goroutines, memory and capabilities.
+	Introspect(context.Context, *Empty) (*IntrospectResponse, error)
 	mustEmbedUnimplementedNodeServer()
 }
 
@@ -159,6 +210,15 @@ func (UnimplementedNodeServer) GetCapabilities(context.Context, *Empty) (*PGetCa
 func (UnimplementedNodeServer) OnSetup(context.Context, *OnSetupRequest) (*OnSetupResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method OnSetup not implemented")
 }
+func (UnimplementedNodeServer) GetMetrics(context.Context, *Empty) (*GetMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetrics not implemented")
+}
+func (UnimplementedNodeServer) OnUpdate(context.Context, *OnUpdateRequest) (*OnUpdateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OnUpdate not implemented")
+}
+func (UnimplementedNodeServer) Introspect(context.Context, *Empty) (*IntrospectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Introspect not implemented")
+}
 func (UnimplementedNodeServer) mustEmbedUnimplementedNodeServer() {}
 func (UnimplementedNodeServer) testEmbeddedByValue()              {}
 
@@ -288,6 +348,60 @@ func _Node_OnSetup_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Node_GetMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_GetMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetMetrics(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_OnUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OnUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).OnUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_OnUpdate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).OnUpdate(ctx, req.(*OnUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Introspect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Introspect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Introspect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Introspect(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Node_ServiceDesc is the grpc.ServiceDesc for Node service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -319,6 +433,18 @@ var Node_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "OnSetup",
 			Handler:    _Node_OnSetup_Handler,
 		},
+		{
+			MethodName: "GetMetrics",
+			Handler:    _Node_GetMetrics_Handler,
+		},
+		{
+			MethodName: "OnUpdate",
+			Handler:    _Node_OnUpdate_Handler,
+		},
+		{
+			MethodName: "Introspect",
+			Handler:    _Node_Introspect_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "plugin.proto",
@@ -1,5 +1,7 @@
 package runtime
 
+import "sync/atomic"
+
 type Capability uint64
 
 // Capability bit allocation. THIS MUST STAY IN SYNC WITH
@@ -24,14 +26,17 @@ const (
 	CapabilityLazyMessage                            // bit 6: deskbot Go-backed lazy msg bridge (robot→package; defined for parity)
 )
 
-var (
-	robotCapabilities   uint64     = 0
-	packageCapabilities Capability = CapabilityLMO
-)
+// The capability sets are read by RPCs and node goroutines while Init and
+// package code set them, so they are atomic.
+var robotCapabilities, packageCapabilities atomic.Uint64
+
+func init() {
+	packageCapabilities.Store(uint64(CapabilityLMO))
+}
 
 // GetCapabilities returns the intersection of robot and package capabilities.
 func GetCapabilities() uint64 {
-	return robotCapabilities & uint64(packageCapabilities)
+	return robotCapabilities.Load() & packageCapabilities.Load()
 }
 
 // HasCapability returns true when both robot and package support the capability.
@@ -40,25 +45,30 @@ func HasCapability(capability Capability) bool {
 }
 
 func HasRobotCapability(capability Capability) bool {
-	return (robotCapabilities & uint64(capability)) > 0
+	return (robotCapabilities.Load() & uint64(capability)) > 0
 }
 
 func GetRobotCapabilities() uint64 {
-	return robotCapabilities
+	return robotCapabilities.Load()
+}
+
+// GetPackageCapabilities returns the capabilities the package advertises.
+func GetPackageCapabilities() uint64 {
+	return packageCapabilities.Load()
 }
 
 func SetRobotCapabilities(cap uint64) {
-	robotCapabilities = cap
+	robotCapabilities.Store(cap)
 }
 
 func SetPackageCapabilities(cap uint64) {
-	packageCapabilities = Capability(cap)
+	packageCapabilities.Store(cap)
 }
 
 func SetPackageCapability(cap Capability) {
-	packageCapabilities |= cap
+	packageCapabilities.Or(uint64(cap))
 }
 
 func SetRobotCapability(cap Capability) {
-	robotCapabilities |= uint64(cap)
+	robotCapabilities.Or(uint64(cap))
 }
@@ -1,11 +1,15 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"reflect"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/iancoleman/strcase"
 	"github.com/robomotionio/robomotion-go/message"
@@ -191,7 +195,8 @@ func RunCLI(args []string) {
 		cliHelper.SetCredentials(creds)
 	}
 
-	// Set the global client so all existing code (InVariable.Get, Credential.Get, etc.) works
+	// Also make it the package-level fallback for package functions such as
+	// EmitDebug; the node itself gets it through Node.Helper and its message
 	client = cliHelper
 
 	// Instantiate the node
@@ -225,32 +230,65 @@ func RunCLI(args []string) {
 		cliError("failed to initialize node: %v", err)
 		return
 	}
+	setNodeHelper(handler, cliHelper)
+
+	// Ctrl-C / SIGTERM cancels the node's context so long calls return early
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	runCtx = WithRuntimeHelper(runCtx, cliHelper)
 
 	// Build message context for Message-scope variables
 	msgJSON, _ := json.Marshal(msgData)
-	ctx := message.NewContext(msgJSON)
 
-	// Run node lifecycle: OnCreate → OnMessage → OnClose
-	if err := handler.OnCreate(); err != nil {
-		cliError("OnCreate failed: %v", err)
+	// Run node lifecycle through the package's middleware: OnCreate → OnMessage → OnClose
+	wrapped := applyMiddleware(handler)
+	if err := safeCall("OnCreate", wrapped.OnCreate); err != nil {
+		cliFail("OnCreate failed: ", err)
 		return
 	}
 
-	err = handler.OnMessage(ctx)
+	node := NodeOf(handler)
+	policy := retryPolicyOf(handler).withOverrides(node)
+	runCtx, span := StartSpan(withMessageTrace(runCtx, msgJSON), "OnMessage")
+	span.SetAttribute("guid", node.GUID).SetAttribute("node.name", node.Name)
+	runCtx = WithRuntimeHelper(runCtx, traceHelper(runCtx, cliHelper))
+	start := time.Now()
+	var ctx message.Context
+	err = validateInput(inputSchemaOf(handler), msgJSON)
+	if err == nil {
+		ctx, err = retryMessage(runCtx, policy, node.GUID, func() (message.Context, error) {
+			return runWithTimeout(runCtx, timeoutOf(handler, node), node.GUID, node.Name, msgJSON, func(msgCtx message.Context) error {
+				return safeCall("OnMessage", func() error { return wrapped.OnMessage(msgCtx) })
+			})
+		})
+	}
+	if err == nil {
+		outputs := outputsOf(handler)
+		err = checkPorts(routedOutputs(ctx, outputs), outputs)
+	}
+	span.Finish(err)
+	observeMessage(nodeTypeOf(handler), start, err)
+	if ctx == nil {
+		ctx = message.NewContextWith(runCtx, msgJSON)
+	}
 
-	closeErr := handler.OnClose()
+	closeErr := safeCall("OnClose", wrapped.OnClose)
+	closeTracing()
 
 	if err != nil {
-		cliError("%v", err)
+		cliFail("", err)
 		return
 	}
 	if closeErr != nil {
-		cliError("OnClose failed: %v", closeErr)
+		cliFail("OnClose failed: ", closeErr)
 		return
 	}
 
 	// Collect output variables from the context
 	output := collectCLIOutput(cmd.nodeType, handler, ctx)
+	if !message.IsDefaultRoute(ctx) {
+		output["outputs"] = cliOutputs(ctx.Outputs())
+	}
 
 	// Print JSON result to stdout
 	result, _ := json.Marshal(output)
@@ -769,11 +807,14 @@ func printCLIUsage() {
 	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "--session-id ID", "Reuse an existing session")
 	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "--session-close ID", "Close a session and stop the daemon")
 	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "--session-timeout DURATION", "Inactivity timeout (default: 30m)")
+	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "--session-metrics ID", "Print a session's metrics (Prometheus text)")
+	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "--session-introspect ID", "Print a session's nodes, in-flight calls and memory (JSON)")
 
 	fmt.Fprintf(os.Stderr, "\nEnvironment:\n")
 	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "ROBOMOTION_API_TOKEN", "API bearer token (from runner, skips robomotion login)")
 	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "ROBOMOTION_ROBOT_ID", "Robot UUID (for private key lookup in keys dir)")
 	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "ROBOMOTION_API_URL", "API base URL (default: https://api.robomotion.io)")
+	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "ROBOMOTION_METRICS_ADDR", "Serve /metrics on this address (e.g. 127.0.0.1:9464)")
 
 	fmt.Fprintf(os.Stderr, "\nUse --list-commands <command> for details on a specific command.\n")
 	fmt.Fprintf(os.Stderr, "Use --help or -h to show this help.\n")
@@ -786,3 +827,45 @@ func cliError(format string, args ...interface{}) {
 	fmt.Fprintln(os.Stderr, string(errJSON))
 	os.Exit(1)
 }
+
+// cliFail prints err as a JSON error to stderr and exits with the code
+// cliExitCode assigns to it. A *runtime.Error contributes its code,
+// retryable flag and details next to the message.
+func cliFail(prefix string, err error) {
+	rerr := AsError(err)
+	out := map[string]interface{}{
+		"error": prefix + errorMessage(err),
+		"code":  rerr.Code,
+	}
+	if rerr.Retryable {
+		out["retryable"] = true
+	}
+	if len(rerr.Details) > 0 {
+		out["details"] = rerr.Details
+	}
+	errJSON, _ := json.Marshal(out)
+	fmt.Fprintln(os.Stderr, string(errJSON))
+	os.Exit(cliExitCode(rerr.Code))
+}
+
+// cliExitCode maps an error code to the process exit code, so scripts and
+// agents can branch on the failure kind without parsing stderr. The codes
+// start at 10 to stay clear of the Go runtime's 2 and of the exit codes
+// Start uses.
+func cliExitCode(code string) int {
+	switch code {
+	case ErrInvalidInput, ErrInvalidArg:
+		return 10
+	case ErrUnauthorized:
+		return 11
+	case ErrNotFound:
+		return 12
+	case ErrRateLimited:
+		return 13
+	case ErrTimeout:
+		return 14
+	case ErrCanceled:
+		return 130 // same as a shell's Ctrl-C
+	}
+	return 1
+}
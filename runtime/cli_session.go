@@ -7,16 +7,20 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sync"
-	"sync/atomic"
+	"syscall"
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/robomotionio/robomotion-go/message"
 	"github.com/robomotionio/robomotion-go/proto"
 )
 
@@ -37,9 +41,21 @@ type sessionMetadata struct {
 	Nodes        []string `json:"nodes,omitempty"` // active node guids
 }
 
-// sessionTimeoutInterceptor resets the inactivity timer on each gRPC call.
+// probeMethods watch the session rather than use it, and do not keep it
+// alive.
+var probeMethods = map[string]bool{
+	grpc_health_v1.Health_Check_FullMethodName: true,
+	proto.Node_Introspect_FullMethodName:       true,
+	proto.Node_GetMetrics_FullMethodName:       true,
+}
+
+// sessionTimeoutInterceptor resets the inactivity timer on each gRPC call
+// other than a probe.
 func sessionTimeoutInterceptor(timer *time.Timer, timeout time.Duration, mu *sync.Mutex) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if probeMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
 		mu.Lock()
 		timer.Reset(timeout)
 		mu.Unlock()
@@ -50,7 +66,8 @@ func sessionTimeoutInterceptor(timer *time.Timer, timeout time.Duration, mu *syn
 // RunSessionDaemon starts a plain gRPC server reusing the existing Node service.
 // Called internally via --session-daemon <id> [flags].
 func RunSessionDaemon(sessionID string, timeout time.Duration, vaultID, itemID string) {
-	// Set up CLI runtime helper as the global client
+	// Set up the CLI runtime helper for the server's nodes, and as the
+	// package-level fallback
 	cliHelper := NewCLIRuntimeHelper()
 
 	if vaultID != "" && itemID != "" {
@@ -92,25 +109,32 @@ func RunSessionDaemon(sessionID string, timeout time.Duration, vaultID, itemID s
 	grpcServer := grpc.NewServer(
 		grpc.MaxRecvMsgSize(maxSessionMsgSize),
 		grpc.MaxSendMsgSize(maxSessionMsgSize),
-		grpc.UnaryInterceptor(sessionTimeoutInterceptor(timer, timeout, &timerMu)),
+		grpc.ChainUnaryInterceptor(sessionTimeoutInterceptor(timer, timeout, &timerMu), healthInterceptor),
 	)
-	proto.RegisterNodeServer(grpcServer, &GRPCServer{Impl: &Node{}})
+	proto.RegisterNodeServer(grpcServer, &GRPCServer{Impl: &Node{}, helper: cliHelper})
+	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewServer())
 
 	// Write metadata
 	writeSessionMetadata(sessionID, lis)
+	startMetricsServer()
 
-	// Timeout goroutine — graceful stop on inactivity
+	// Timeout goroutine — drain and stop on inactivity
 	go func() {
 		<-timer.C
 		hclog.Default().Info("session.timeout", "session_id", sessionID)
-		closeAllSessionNodes()
-		grpcServer.GracefulStop()
-		sessionCleanup(sessionID)
+		requestShutdown(exitOK)
 	}()
 
-	// Also listen for done signal (triggered when nc reaches 0 after OnClose)
+	// SIGTERM/SIGINT drain the session like the inactivity timeout does
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		<-done
+		<-sc
+		requestShutdown(exitOK)
+	}()
+	// Drain closes every node in the pool, then the server stops
+	go func() {
+		drain(<-done)
 		timer.Stop()
 		grpcServer.GracefulStop()
 		sessionCleanup(sessionID)
@@ -194,7 +218,7 @@ func RunSessionClient(sessionID, commandName string, flags map[string]string) {
 			Config: configJSON,
 		})
 		if err != nil {
-			cliError("session OnCreate failed: %v", err)
+			cliFail("session OnCreate failed: ", errorFromStatus(err))
 			return
 		}
 
@@ -222,20 +246,32 @@ func RunSessionClient(sessionID, commandName string, flags map[string]string) {
 		InMessage: compressed,
 	})
 	if err != nil {
-		cliError("session OnMessage failed: %v", err)
+		cliFail("session OnMessage failed: ", errorFromStatus(err))
 		return
 	}
 
 	// Print response with session_id included
 	var result map[string]interface{}
 	if resp.OutMessage != nil {
-		if err := json.Unmarshal(resp.OutMessage, &result); err != nil {
+		payload, _ := message.SplitMeta(resp.OutMessage)
+		if err := json.Unmarshal(payload, &result); err != nil {
 			// Not valid JSON — wrap it
-			result = map[string]interface{}{"result": string(resp.OutMessage)}
+			result = map[string]interface{}{"result": string(payload)}
 		}
 	} else {
 		result = map[string]interface{}{"status": "completed"}
 	}
+	if len(resp.Outputs) > 0 {
+		// The node routed: list every message with the port it went to.
+		var outputs []map[string]interface{}
+		if resp.OutMessage != nil {
+			outputs = append(outputs, map[string]interface{}{"port": 0, "message": decodeCLIMessage(resp.OutMessage)})
+		}
+		for _, out := range resp.Outputs {
+			outputs = append(outputs, map[string]interface{}{"port": out.Port, "message": decodeCLIMessage(out.Message)})
+		}
+		result["outputs"] = outputs
+	}
 	result["session_id"] = sessionID
 	out, _ := json.Marshal(result)
 	fmt.Println(string(out))
@@ -278,6 +314,44 @@ func CloseSession(sessionID string) {
 	fmt.Println(string(result))
 }
 
+// SessionMetrics prints the metrics of a running session daemon in the
+// Prometheus text format.
+func SessionMetrics(sessionID string) {
+	addr := sessionDialAddr(sessionID)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		cliError("session dial failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	resp, err := proto.NewNodeClient(conn).GetMetrics(context.Background(), &proto.Empty{})
+	if err != nil {
+		cliError("session metrics failed: %v", err)
+		return
+	}
+	fmt.Print(resp.Text)
+}
+
+// SessionIntrospect prints the introspection report of a running session
+// daemon as JSON.
+func SessionIntrospect(sessionID string) {
+	addr := sessionDialAddr(sessionID)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		cliError("session dial failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	resp, err := proto.NewNodeClient(conn).Introspect(context.Background(), &proto.Empty{})
+	if err != nil {
+		cliError("session introspect failed: %v", err)
+		return
+	}
+	fmt.Println(string(resp.Report))
+}
+
 // StartDaemonProcess forks the current binary as a session daemon, waits for
 // the socket/port file to appear, then runs the first command as a client.
 func StartDaemonProcess(sessionID string, timeout time.Duration, vaultID, itemID string, origArgs []string) {
@@ -377,18 +451,6 @@ func saveSessionMetadata(sessionID string, meta *sessionMetadata) {
 	os.WriteFile(metaPath, data, 0600)
 }
 
-// closeAllSessionNodes calls OnClose on all active handlers in the pool.
-func closeAllSessionNodes() {
-	guids := listNodeHandlerGUIDs()
-	for _, guid := range guids {
-		node := GetNodeHandler(guid)
-		if node != nil {
-			node.Handler.OnClose()
-			atomic.AddInt32(&nc, -1)
-		}
-	}
-}
-
 // sessionReady checks if the session socket/port file exists.
 func sessionReady(sessionID string) bool {
 	addr := sessionDialAddr(sessionID)
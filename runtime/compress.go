@@ -8,6 +8,8 @@ import (
 
 func Compress(data []byte) ([]byte, error) {
 	if !Props.GetBool("robomotion.compress", true) {
+		countBytes("out", "raw", len(data))
+		countBytes("out", "wire", len(data))
 		return data, nil
 	}
 
@@ -23,11 +25,15 @@ func Compress(data []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	countBytes("out", "raw", len(data))
+	countBytes("out", "wire", b.Len())
 	return b.Bytes(), nil
 }
 
 func Decompress(data []byte) ([]byte, error) {
 	if !Props.GetBool("robomotion.compress", true) {
+		countBytes("in", "wire", len(data))
+		countBytes("in", "raw", len(data))
 		return data, nil
 	}
 
@@ -43,5 +49,7 @@ func Decompress(data []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	countBytes("in", "wire", len(data))
+	countBytes("in", "raw", len(raw))
 	return raw, nil
 }
@@ -0,0 +1,219 @@
+package runtime
+
+import (
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/connectivity"
+)
+
+// A dropped robot connection no longer stops the package at once. The
+// runtime tells the nodes (ConnectionHandler.OnDisconnect), asks gRPC to
+// reconnect up to ReconnectAttempts times, and holds Emit* calls until the
+// connection is back; only if it is still down after ReconnectGrace does
+// the package shut down. The "robomotion.reconnect_grace" (e.g. "1m") and
+// "robomotion.reconnect_attempts" properties override the defaults.
+var (
+	ReconnectGrace    = 30 * time.Second
+	ReconnectAttempts = 5
+
+	// EmitBufferSize bounds the Emit* calls held while disconnected; the
+	// oldest are dropped beyond it.
+	EmitBufferSize = 1000
+)
+
+// ConnectionHandler is implemented by nodes that want to know when the
+// robot connection drops and when it is back, e.g. to pause a poller.
+// OnReconnect runs after the held Emit* calls have been sent.
+type ConnectionHandler interface {
+	OnDisconnect()
+	OnReconnect()
+}
+
+// AsConnectionHandler returns the ConnectionHandler for a stored handler,
+// unwrapping the ToolInterceptor and middleware, or nil.
+func AsConnectionHandler(h MessageHandler) ConnectionHandler {
+	found, ok := findHandler(h, func(h MessageHandler) bool {
+		_, ok := h.(ConnectionHandler)
+		return ok
+	})
+	if !ok {
+		return nil
+	}
+	return found.(ConnectionHandler)
+}
+
+// watchedConn is the part of *grpc.ClientConn the monitor uses.
+type watchedConn interface {
+	GetState() connectivity.State
+	WaitForStateChange(ctx context.Context, source connectivity.State) bool
+	Connect()
+}
+
+func reconnectGrace() time.Duration {
+	return Props.GetParsedDuration("robomotion.reconnect_grace", ReconnectGrace)
+}
+
+func reconnectAttempts() int {
+	if n := Props.GetInt("robomotion.reconnect_attempts", ReconnectAttempts); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// monitorConn watches the robot connection until it is shut down or lost
+// for longer than the grace period, then requests a shutdown.
+func monitorConn(c watchedConn, queue *emitQueue) {
+	for {
+		state := c.GetState()
+		switch state {
+		case connectivity.Shutdown:
+			requestShutdown(exitConnLost)
+			return
+
+		case connectivity.TransientFailure:
+			hclog.Default().Info("runtime.conn", "state", state.String(), "grace", reconnectGrace())
+			queue.setDown()
+			notifyConnection(ConnectionHandler.OnDisconnect)
+
+			if !awaitReconnect(c, reconnectGrace(), reconnectAttempts()) {
+				hclog.Default().Info("runtime.conn", "err", "robot connection lost", "dropped_emits", queue.discard())
+				requestShutdown(exitConnLost)
+				return
+			}
+
+			hclog.Default().Info("runtime.conn", "state", "reconnected")
+			queue.replay()
+			notifyConnection(ConnectionHandler.OnReconnect)
+			continue
+		}
+
+		c.WaitForStateChange(context.Background(), state)
+	}
+}
+
+// awaitReconnect nudges c to reconnect attempts times, spread over grace,
+// and reports whether it became Ready before grace ran out.
+func awaitReconnect(c watchedConn, grace time.Duration, attempts int) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	interval := grace / time.Duration(attempts)
+	for attempt := 1; ; attempt++ {
+		if attempt <= attempts {
+			c.Connect()
+		}
+		attemptCtx, attemptCancel := context.WithTimeout(ctx, interval)
+		ready := waitReady(attemptCtx, c)
+		attemptCancel()
+		if ready {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+// waitReady blocks until c is Ready or ctx is done.
+func waitReady(ctx context.Context, c watchedConn) bool {
+	for {
+		state := c.GetState()
+		switch state {
+		case connectivity.Ready:
+			return true
+		case connectivity.Shutdown:
+			return false
+		}
+		if !c.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
+
+// notifyConnection calls hook on every registered node that implements
+// ConnectionHandler.
+func notifyConnection(hook func(ConnectionHandler)) {
+	for _, guid := range listNodeHandlerGUIDs() {
+		node := GetNodeHandler(guid)
+		if node == nil {
+			continue
+		}
+		ch := AsConnectionHandler(node.Handler)
+		if ch == nil {
+			continue
+		}
+		if err := safeCall("ConnectionHandler", func() error { hook(ch); return nil }); err != nil {
+			hclog.Default().Info("runtime.conn.hook", "guid", guid, "err", err)
+		}
+	}
+}
+
+// emitQueue holds Emit* calls while the robot connection is down and sends
+// them, in order, once it is back.
+type emitQueue struct {
+	mu      sync.Mutex
+	down    bool
+	calls   []func() error
+	dropped int
+}
+
+// do runs call, or holds it while the connection is down.
+func (q *emitQueue) do(call func() error) error {
+	if q == nil {
+		return call()
+	}
+	q.mu.Lock()
+	if q.down {
+		if len(q.calls) >= EmitBufferSize {
+			q.calls = q.calls[1:]
+			q.dropped++
+		}
+		q.calls = append(q.calls, call)
+		q.mu.Unlock()
+		return nil
+	}
+	q.mu.Unlock()
+	return call()
+}
+
+func (q *emitQueue) setDown() {
+	q.mu.Lock()
+	q.down = true
+	q.mu.Unlock()
+}
+
+// replay sends the held calls and lets new ones through. Calls made during
+// the replay queue up behind it, so the order is kept.
+func (q *emitQueue) replay() {
+	for {
+		q.mu.Lock()
+		if len(q.calls) == 0 {
+			q.down = false
+			if q.dropped > 0 {
+				hclog.Default().Info("runtime.conn.replay", "dropped", q.dropped)
+				q.dropped = 0
+			}
+			q.mu.Unlock()
+			return
+		}
+		call := q.calls[0]
+		q.calls = q.calls[1:]
+		q.mu.Unlock()
+
+		if err := call(); err != nil {
+			hclog.Default().Info("runtime.conn.replay", "err", err)
+		}
+	}
+}
+
+// discard drops the held calls and returns how many there were.
+func (q *emitQueue) discard() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.calls) + q.dropped
+	q.calls, q.dropped = nil, 0
+	return n
+}
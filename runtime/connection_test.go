@@ -0,0 +1,204 @@
+package runtime
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/connectivity"
+)
+
+// fakeConn is a watchedConn whose state the test sets.
+type fakeConn struct {
+	mu       sync.Mutex
+	state    connectivity.State
+	changed  chan struct{}
+	connects int
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{state: connectivity.Ready, changed: make(chan struct{})}
+}
+
+func (c *fakeConn) set(s connectivity.State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state = s
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *fakeConn) GetState() connectivity.State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *fakeConn) WaitForStateChange(ctx context.Context, source connectivity.State) bool {
+	c.mu.Lock()
+	if c.state != source {
+		c.mu.Unlock()
+		return true
+	}
+	ch := c.changed
+	c.mu.Unlock()
+	select {
+	case <-ch:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *fakeConn) Connect() {
+	c.mu.Lock()
+	c.connects++
+	c.mu.Unlock()
+}
+
+// fxConnAware records its connection hooks.
+type fxConnAware struct {
+	fxNode
+	mu     sync.Mutex
+	events []string
+}
+
+func (n *fxConnAware) OnDisconnect() { n.record("disconnect") }
+func (n *fxConnAware) OnReconnect()  { n.record("reconnect") }
+
+func (n *fxConnAware) record(e string) {
+	n.mu.Lock()
+	n.events = append(n.events, e)
+	n.mu.Unlock()
+}
+
+func (n *fxConnAware) seen() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.events...)
+}
+
+func useReconnectProps(t *testing.T, grace string) {
+	t.Helper()
+	old := Props
+	Props = properties.LoadMap(map[string]string{"robomotion.reconnect_grace": grace})
+	t.Cleanup(func() { Props = old })
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMonitorConn_RidesOutDropWithinGrace(t *testing.T) {
+	useReconnectProps(t, "5s")
+	n := &fxConnAware{}
+	addTestHandler(t, "conn-aware", Node{}, n)
+
+	c := newFakeConn()
+	q := &emitQueue{}
+	go monitorConn(c, q)
+
+	c.set(connectivity.TransientFailure)
+	waitFor(t, "OnDisconnect", func() bool { return len(n.seen()) == 1 })
+
+	var mu sync.Mutex
+	var sent []int
+	for i := 0; i < 3; i++ {
+		i := i
+		if err := q.do(func() error {
+			mu.Lock()
+			sent = append(sent, i)
+			mu.Unlock()
+			return nil
+		}); err != nil {
+			t.Fatalf("do while down: %v", err)
+		}
+	}
+	mu.Lock()
+	if len(sent) != 0 {
+		t.Fatalf("emits sent while disconnected: %v", sent)
+	}
+	mu.Unlock()
+
+	c.set(connectivity.Connecting)
+	c.set(connectivity.Ready)
+	waitFor(t, "OnReconnect", func() bool { return len(n.seen()) == 2 })
+
+	if got := n.seen(); got[0] != "disconnect" || got[1] != "reconnect" {
+		t.Errorf("hooks = %v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 3 || sent[0] != 0 || sent[1] != 1 || sent[2] != 2 {
+		t.Errorf("replayed %v, want [0 1 2]", sent)
+	}
+	select {
+	case code := <-done:
+		t.Fatalf("shutdown requested with %d", code)
+	default:
+	}
+	if c.connects == 0 {
+		t.Error("no reconnect attempt made")
+	}
+	c.set(connectivity.Shutdown) // stop the monitor
+	if code := <-done; code != exitConnLost {
+		t.Errorf("exit code = %d, want %d", code, exitConnLost)
+	}
+}
+
+func TestMonitorConn_ShutsDownAfterGrace(t *testing.T) {
+	useReconnectProps(t, "50ms")
+	c := newFakeConn()
+	q := &emitQueue{}
+	go monitorConn(c, q)
+
+	start := time.Now()
+	c.set(connectivity.TransientFailure)
+	waitFor(t, "emits held", func() bool { q.mu.Lock(); defer q.mu.Unlock(); return q.down })
+	q.do(func() error { t.Error("held emit sent after the connection was lost"); return nil })
+
+	select {
+	case code := <-done:
+		if code != exitConnLost {
+			t.Errorf("exit code = %d, want %d", code, exitConnLost)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no shutdown after the grace period")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("shut down after %v, before the grace period", elapsed)
+	}
+}
+
+func TestEmitQueue_BoundedAndPassesThroughWhenUp(t *testing.T) {
+	q := &emitQueue{}
+	errSent := errors.New("sent")
+	if err := q.do(func() error { return errSent }); err != errSent {
+		t.Fatalf("do while up = %v, want the call's error", err)
+	}
+
+	old := EmitBufferSize
+	EmitBufferSize = 2
+	t.Cleanup(func() { EmitBufferSize = old })
+
+	q.setDown()
+	var sent []int
+	for i := 0; i < 3; i++ {
+		i := i
+		q.do(func() error { sent = append(sent, i); return nil })
+	}
+	q.replay()
+	if len(sent) != 2 || sent[0] != 1 || sent[1] != 2 {
+		t.Errorf("replayed %v, want the newest two [1 2]", sent)
+	}
+}
@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"context"
+	"time"
+)
+
+// mergeContext returns a context that is done as soon as either parent is.
+// Values and the deadline come from a; b only contributes cancellation.
+func mergeContext(a, b context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(a)
+	stop := context.AfterFunc(b, func() { cancel(context.Cause(b)) })
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// Sleep pauses for d, or until ctx is done. It returns ctx.Err() when the
+// wait was cut short, so node code can bail out of polling loops and
+// rate-limit pauses as soon as the flow stops.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delayDuration converts the Designer's DelayBefore/DelayAfter seconds.
+func delayDuration(seconds float32) time.Duration {
+	return time.Duration(seconds*1000) * time.Millisecond
+}
@@ -0,0 +1,614 @@
+package runtime
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How InVariable.Get converts the value it finds, as decoded from JSON, to
+// the variable's type, and how OutVariable.Set and ToValue encode it back:
+//
+//	time.Time         an RFC 3339 string, or epoch seconds (milliseconds from
+//	                  1e11 on, as JavaScript's Date.now() gives); out as RFC 3339
+//	time.Duration     a duration string such as "1m30s", or seconds; out as seconds,
+//	                  or nanoseconds inside a struct, as encoding/json reads it
+//	[]byte            a base64 string; out as one
+//	json.RawMessage   any value, as its JSON
+//	map[string]T      an object, or a string holding one, converted per entry
+//	[]T               an array, a string holding one, or a comma-separated
+//	                  string, converted per element
+//	ints, uints       whole numbers or numeric strings; a fraction or out of
+//	                  range is an error
+//	encoding.TextUnmarshaler, json.Unmarshaler
+//	                  a string through UnmarshalText, else UnmarshalJSON
+//
+// Numbers, booleans and strings convert into each other where the text
+// allows, and an object with a single entry stands for its value. Other
+// types go through encoding/json.
+
+// timeType, rawType and marshalerType are in schema.go.
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// epochMillis is where epoch numbers switch from seconds to milliseconds:
+// 1e11 seconds is in the year 5138, 1e11 milliseconds in 1973.
+const epochMillis = 1e11
+
+var (
+	errOutOfRange = errors.New("out of range")
+	errFraction   = errors.New("not a whole number")
+)
+
+// conversionError is a value that does not convert to the type asked for.
+type conversionError struct {
+	path  string // inside the value, e.g. ".limits[2]"; empty for the value
+	got   interface{}
+	want  reflect.Type
+	cause error
+}
+
+func (e *conversionError) Error() string {
+	msg := "cannot convert " + describeValue(e.got)
+	if e.path != "" {
+		msg += " at " + e.path
+	}
+	msg += " to " + e.want.String()
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+func (e *conversionError) Unwrap() error { return e.cause }
+
+// describeValue names the JSON type of val, with the value for scalars.
+func describeValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		if len(v) > 40 {
+			v = v[:40] + "..."
+		}
+		return fmt.Sprintf("string %q", v)
+	case float64, int64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return typeName(val)
+}
+
+// convertTo converts val, as decoded from JSON or a protobuf Struct, to typ.
+// nil is the zero value.
+func convertTo(val interface{}, typ reflect.Type) (reflect.Value, error) {
+	out := reflect.New(typ).Elem()
+	if val == nil {
+		return out, nil
+	}
+	if reflect.TypeOf(val).AssignableTo(typ) {
+		out.Set(reflect.ValueOf(val))
+		return out, nil
+	}
+	fail := func(cause error) (reflect.Value, error) {
+		if cause == errWrongType {
+			cause = nil // the message says it all
+		}
+		return out, &conversionError{got: val, want: typ, cause: cause}
+	}
+
+	s, isString := val.(string)
+	ptr := reflect.PointerTo(typ)
+	switch {
+	case typ == timeType:
+		t, err := toTime(val)
+		if err != nil {
+			return fail(err)
+		}
+		out.Set(reflect.ValueOf(t))
+		return out, nil
+	case typ == durationType:
+		d, err := toDuration(val)
+		if err != nil {
+			return fail(err)
+		}
+		out.SetInt(int64(d))
+		return out, nil
+	case typ == rawType:
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return fail(err)
+		}
+		out.SetBytes(raw)
+		return out, nil
+	case isString && ptr.Implements(textUnmarshalerType):
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fail(err)
+		}
+		return out, nil
+	case ptr.Implements(unmarshalerType):
+		raw, err := json.Marshal(val)
+		if err == nil {
+			err = out.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(raw)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		return out, nil
+	case isString && typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fail(errors.New("want base64"))
+		}
+		out.SetBytes(data)
+		return out, nil
+	}
+
+	if m, ok := val.(map[string]interface{}); ok && isScalar(typ.Kind()) {
+		if len(m) != 1 {
+			return fail(nil)
+		}
+		for _, one := range m {
+			return convertTo(one, typ)
+		}
+	}
+
+	var err error
+	switch typ.Kind() {
+	case reflect.Ptr:
+		if isString && s == "" && typ.Elem().Kind() != reflect.String {
+			return out, nil // an empty field leaves the pointer nil
+		}
+		elem, err := convertTo(val, typ.Elem())
+		if err != nil {
+			return out, err
+		}
+		p := reflect.New(typ.Elem())
+		p.Elem().Set(elem)
+		out.Set(p)
+		return out, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		err = setInt(out, val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		err = setUint(out, val)
+	case reflect.Float32, reflect.Float64:
+		err = setFloat(out, val)
+	case reflect.Bool:
+		err = setBool(out, val)
+	case reflect.String:
+		err = setString(out, val)
+	case reflect.Slice:
+		return out, setSlice(out, val)
+	case reflect.Map:
+		return out, setMap(out, val)
+	case reflect.Interface:
+		return fail(nil)
+	default:
+		err = setJSON(out, val)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return out, nil
+}
+
+func isScalar(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+		return true
+	}
+	return false
+}
+
+// errWrongType is what the set functions return for a JSON type they do
+// not take.
+var errWrongType = errors.New("wrong type")
+
+func setInt(out reflect.Value, val interface{}) error {
+	var n int64
+	switch v := val.(type) {
+	case int64:
+		n = v
+	case float64:
+		if v != math.Trunc(v) {
+			return errFraction
+		}
+		if v < math.MinInt64 || v >= math.MaxInt64 {
+			return errOutOfRange
+		}
+		n = int64(v)
+	case string:
+		var err error
+		if n, err = strconv.ParseInt(strings.TrimSpace(v), 10, 64); err != nil {
+			return numError(err)
+		}
+	default:
+		return errWrongType
+	}
+	if out.OverflowInt(n) {
+		return errOutOfRange
+	}
+	out.SetInt(n)
+	return nil
+}
+
+func setUint(out reflect.Value, val interface{}) error {
+	var n uint64
+	switch v := val.(type) {
+	case int64:
+		if v < 0 {
+			return errOutOfRange
+		}
+		n = uint64(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return errFraction
+		}
+		if v < 0 || v >= math.MaxUint64 {
+			return errOutOfRange
+		}
+		n = uint64(v)
+	case string:
+		s := strings.TrimSpace(v)
+		if strings.HasPrefix(s, "-") {
+			return errOutOfRange
+		}
+		var err error
+		if n, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return numError(err)
+		}
+	default:
+		return errWrongType
+	}
+	if out.OverflowUint(n) {
+		return errOutOfRange
+	}
+	out.SetUint(n)
+	return nil
+}
+
+func setFloat(out reflect.Value, val interface{}) error {
+	var f float64
+	switch v := val.(type) {
+	case int64:
+		f = float64(v)
+	case float64:
+		f = v
+	case string:
+		var err error
+		if f, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+			return numError(err)
+		}
+	default:
+		return errWrongType
+	}
+	if out.OverflowFloat(f) {
+		return errOutOfRange
+	}
+	out.SetFloat(f)
+	return nil
+}
+
+func setBool(out reflect.Value, val interface{}) error {
+	switch v := val.(type) {
+	case int64:
+		out.SetBool(v > 0)
+	case float64:
+		out.SetBool(v > 0)
+	case bool:
+		out.SetBool(v)
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return numError(err)
+		}
+		out.SetBool(b)
+	default:
+		return errWrongType
+	}
+	return nil
+}
+
+func setString(out reflect.Value, val interface{}) error {
+	switch v := val.(type) {
+	case string:
+		out.SetString(v)
+	case int64:
+		out.SetString(strconv.FormatInt(v, 10))
+	case float64:
+		out.SetString(strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		out.SetString(strconv.FormatBool(v))
+	default:
+		return errWrongType
+	}
+	return nil
+}
+
+// setSlice converts an array, a string holding a JSON array or a
+// comma-separated string, element by element.
+func setSlice(out reflect.Value, val interface{}) error {
+	typ := out.Type()
+	var items []interface{}
+	switch v := val.(type) {
+	case []interface{}:
+		items = v
+	case string:
+		s := strings.TrimSpace(v)
+		if strings.HasPrefix(s, "[") && json.Unmarshal([]byte(s), &items) == nil {
+			break
+		}
+		items = nil
+		for _, p := range strings.Split(s, ",") {
+			items = append(items, strings.TrimSpace(p))
+		}
+	default:
+		if err := setJSON(out, val); err != nil {
+			return &conversionError{got: val, want: typ, cause: err}
+		}
+		return nil
+	}
+
+	slice := reflect.MakeSlice(typ, len(items), len(items))
+	for i, item := range items {
+		elem, err := convertTo(item, typ.Elem())
+		if err != nil {
+			return within(fmt.Sprintf("[%d]", i), err)
+		}
+		slice.Index(i).Set(elem)
+	}
+	out.Set(slice)
+	return nil
+}
+
+// setMap converts an object, or a string holding one, entry by entry.
+func setMap(out reflect.Value, val interface{}) error {
+	typ := out.Type()
+	m, ok := val.(map[string]interface{})
+	if s, isString := val.(string); isString {
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			return &conversionError{got: val, want: typ, cause: err}
+		}
+		ok = true
+	}
+	if !ok || typ.Key().Kind() != reflect.String {
+		if err := setJSON(out, val); err != nil {
+			return &conversionError{got: val, want: typ, cause: err}
+		}
+		return nil
+	}
+
+	result := reflect.MakeMapWithSize(typ, len(m))
+	for key, item := range m {
+		elem, err := convertTo(item, typ.Elem())
+		if err != nil {
+			return within("."+key, err)
+		}
+		result.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), elem)
+	}
+	out.Set(result)
+	return nil
+}
+
+// setJSON converts val through encoding/json. A string holding a JSON object
+// or array is decoded as that.
+func setJSON(out reflect.Value, val interface{}) error {
+	var raw []byte
+	if s, ok := val.(string); ok && (strings.HasPrefix(strings.TrimSpace(s), "{") || strings.HasPrefix(strings.TrimSpace(s), "[")) {
+		raw = []byte(s)
+	} else {
+		var err error
+		if raw, err = json.Marshal(val); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, out.Addr().Interface())
+}
+
+// within places a conversion error of an entry or element at path inside
+// its container.
+func within(path string, err error) error {
+	var cerr *conversionError
+	if errors.As(err, &cerr) {
+		cerr.path = path + cerr.path
+	}
+	return err
+}
+
+// numError drops the strconv wrapping that repeats the input.
+func numError(err error) error {
+	var nerr *strconv.NumError
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		return errOutOfRange
+	case errors.As(err, &nerr):
+		return nerr.Err
+	}
+	return err
+}
+
+func toTime(val interface{}) (time.Time, error) {
+	switch v := val.(type) {
+	case string:
+		s := strings.TrimSpace(v)
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, errors.New("want an RFC 3339 time or epoch seconds")
+		}
+		return epochTime(f), nil
+	case float64:
+		return epochTime(v), nil
+	case int64:
+		return epochTime(float64(v)), nil
+	}
+	return time.Time{}, errWrongType
+}
+
+func epochTime(secs float64) time.Time {
+	if math.Abs(secs) >= epochMillis {
+		secs /= 1000
+	}
+	whole, frac := math.Modf(secs)
+	return time.Unix(int64(whole), int64(math.Round(frac*1e9))).UTC()
+}
+
+func toDuration(val interface{}) (time.Duration, error) {
+	var secs float64
+	switch v := val.(type) {
+	case string:
+		s := strings.TrimSpace(v)
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, errors.New("want a duration such as 1m30s, or seconds")
+		}
+		secs = f
+	case float64:
+		secs = v
+	case int64:
+		secs = float64(v)
+	default:
+		return 0, errWrongType
+	}
+	if math.Abs(secs) >= math.MaxInt64/float64(time.Second) {
+		return 0, errOutOfRange
+	}
+	return time.Duration(math.Round(secs * float64(time.Second))), nil
+}
+
+// encodeValue returns value in the form OutVariable.Set and ToValue send,
+// the one InVariable.Get reads back: times as RFC 3339, durations as
+// seconds, []byte as base64, json.RawMessage and marshalers as the JSON
+// they hold, inside maps, slices and pointers too. Other values are
+// returned as they are.
+func encodeValue(value interface{}) interface{} {
+	return encodeIn(value, false)
+}
+
+// encodeIn is encodeValue with durations in nanoseconds when inStruct: a
+// struct is read back through encoding/json, which has them so.
+func encodeIn(value interface{}, inStruct bool) interface{} {
+	if value == nil {
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	if !needsEncoding(v.Type(), nil) {
+		return value
+	}
+	return encode(v, inStruct)
+}
+
+// needsEncoding reports whether values of typ, or in them, change in
+// encodeValue. An interface may hold any of those, so encode looks at what
+// it holds, as in a map[string]interface{}. seen stops recursive types.
+func needsEncoding(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	switch {
+	case typ.Kind() == reflect.Interface,
+		typ == timeType, typ == durationType, typ == rawType,
+		typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8,
+		typ.Implements(marshalerType), typ.Implements(textMarshalerType):
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		if seen[typ] {
+			return false
+		}
+		if seen == nil {
+			seen = map[reflect.Type]bool{}
+		}
+		seen[typ] = true
+		return needsEncoding(typ.Elem(), seen)
+	}
+	return false
+}
+
+func encode(v reflect.Value, inStruct bool) interface{} {
+	typ := v.Type()
+	switch {
+	case (typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Interface) && v.IsNil():
+		return nil
+	case typ == timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano)
+	case typ == durationType:
+		if inStruct {
+			return v.Int()
+		}
+		return time.Duration(v.Int()).Seconds()
+	case typ == rawType:
+		return decodeJSON(v.Bytes())
+	case typ.Implements(marshalerType):
+		raw, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return v.Interface()
+		}
+		return decodeJSON(raw)
+	case typ.Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return v.Interface()
+		}
+		return string(text)
+	case typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Interface:
+		return encodeIn(v.Elem().Interface(), inStruct)
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		if v.IsNil() {
+			return nil
+		}
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	case typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array:
+		if typ.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = encodeIn(v.Index(i).Interface(), inStruct)
+		}
+		return items
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = encodeIn(iter.Value().Interface(), inStruct)
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+// decodeJSON decodes raw into plain values, or returns it as is when it
+// is not valid JSON.
+func decodeJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	var val interface{}
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return json.RawMessage(raw)
+	}
+	return val
+}
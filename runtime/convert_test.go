@@ -0,0 +1,239 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getCustom reads val, as a node config decodes it, through an InVariable[T].
+func getCustom[T any](val interface{}) (T, error) {
+	v := InVariable[T]{Variable: Variable[T]{Scope: "Custom", Name: val}}
+	return v.Get(newCtx(`{}`))
+}
+
+// fxLevel is a json.Unmarshaler that takes a number or a name.
+type fxLevel int
+
+func (l *fxLevel) UnmarshalJSON(b []byte) error {
+	switch string(b) {
+	case `"low"`:
+		*l = 1
+	case `"high"`:
+		*l = 9
+	default:
+		var n int
+		if err := json.Unmarshal(b, &n); err != nil {
+			return err
+		}
+		*l = fxLevel(n)
+	}
+	return nil
+}
+
+func TestInVariable_Conversions(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	check := func(name string, got, want interface{}, err error) {
+		t.Helper()
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s = %v, %v; want %v", name, got, err, want)
+		}
+	}
+
+	tm, err := getCustom[time.Time]("2026-03-04T05:06:07Z")
+	check("time from RFC 3339", tm, at, err)
+	tm, err = getCustom[time.Time](float64(at.Unix()))
+	check("time from epoch seconds", tm, at, err)
+	tm, err = getCustom[time.Time](float64(at.UnixMilli()))
+	check("time from epoch milliseconds", tm, at, err)
+	ptm, err := getCustom[*time.Time](fmt.Sprint(at.Unix()))
+	check("*time from a numeric string", *ptm, at, err)
+
+	d, err := getCustom[time.Duration]("1m30s")
+	check("duration from a string", d, 90*time.Second, err)
+	d, err = getCustom[time.Duration](1.5)
+	check("duration from seconds", d, 1500*time.Millisecond, err)
+	ds, err := getCustom[[]time.Duration]("1s, 2m")
+	check("durations from a list", ds, []time.Duration{time.Second, 2 * time.Minute}, err)
+
+	b, err := getCustom[[]byte]("aGVsbG8=")
+	check("[]byte from base64", string(b), "hello", err)
+	raw, err := getCustom[json.RawMessage](map[string]interface{}{"a": []interface{}{1.0, "x"}})
+	check("json.RawMessage", string(raw), `{"a":[1,"x"]}`, err)
+
+	m, err := getCustom[map[string]int](map[string]interface{}{"a": 1.0, "b": "2"})
+	check("map[string]int", m, map[string]int{"a": 1, "b": 2}, err)
+	md, err := getCustom[map[string]time.Duration](`{"short":"5s","long":60}`)
+	check("map[string]time.Duration from a JSON string", md, map[string]time.Duration{"short": 5 * time.Second, "long": time.Minute}, err)
+
+	u8, err := getCustom[uint8](255.0)
+	check("uint8", u8, 255, err)
+	pu, err := getCustom[*uint32]("7")
+	check("*uint32", *pu, 7, err)
+	i8, err := getCustom[int8](-128.0)
+	check("int8", i8, -128, err)
+
+	addr, err := getCustom[netip.Addr]("10.0.0.1")
+	check("TextUnmarshaler", addr, netip.MustParseAddr("10.0.0.1"), err)
+	lvl, err := getCustom[fxLevel]("high")
+	check("json.Unmarshaler", lvl, 9, err)
+
+	n, err := getCustom[int](map[string]interface{}{"value": 3.0})
+	check("int from a single-entry object", n, 3, err)
+	s, err := getCustom[string](12.5)
+	check("string from a number", s, "12.5", err)
+}
+
+func TestInVariable_ConversionErrors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		get  func() error
+		want string
+	}{
+		{"uint8 overflow", func() error { _, err := getCustom[uint8](256.0); return err }, "to uint8: out of range"},
+		{"negative uint", func() error { _, err := getCustom[uint](-1.0); return err }, "to uint: out of range"},
+		{"uint64 from a string", func() error { _, err := getCustom[uint64]("18446744073709551616"); return err }, "out of range"},
+		{"int16 overflow", func() error { _, err := getCustom[int16]("40000"); return err }, "to int16: out of range"},
+		{"int from a fraction", func() error { _, err := getCustom[int](3.7); return err }, "number 3.7 to int: not a whole number"},
+		{"uint from a fraction", func() error { _, err := getCustom[uint8](0.5); return err }, "to uint8: not a whole number"},
+		{"bad int", func() error { _, err := getCustom[int]("abc"); return err }, `string "abc" to int: invalid syntax`},
+		{"bad time", func() error { _, err := getCustom[time.Time]("yesterday"); return err }, "want an RFC 3339 time"},
+		{"bad base64", func() error { _, err := getCustom[[]byte]("%%%"); return err }, "want base64"},
+		{"object for an int", func() error {
+			_, err := getCustom[int](map[string]interface{}{"a": 1.0, "b": 2.0})
+			return err
+		}, "cannot convert object to int"},
+		{"bad map entry", func() error {
+			_, err := getCustom[map[string][]int](map[string]interface{}{"a": []interface{}{1.0, "x"}})
+			return err
+		}, `string "x" at .a[1] to int`},
+	}
+	for _, c := range cases {
+		err := c.get()
+		if err == nil || !strings.Contains(AsError(err).Message, c.want) {
+			t.Errorf("%s: err = %v, want %q", c.name, err, c.want)
+			continue
+		}
+		if AsError(err).Code != ErrInvalidInput {
+			t.Errorf("%s: code = %s, want %s", c.name, AsError(err).Code, ErrInvalidInput)
+		}
+	}
+}
+
+// roundTrip sets value through an OutVariable[T] and reads it back through
+// an InVariable[T], returning what it read and the JSON in between.
+func roundTrip[T any](t *testing.T, value T) (T, string) {
+	t.Helper()
+	ctx := newCtx(`{}`)
+	v := Variable[T]{Scope: "Message", Name: "v"}
+	if err := (&OutVariable[T]{v}).Set(ctx, value); err != nil {
+		t.Fatalf("Set(%v): %v", value, err)
+	}
+	back, err := (&InVariable[T]{v}).Get(ctx)
+	if err != nil {
+		t.Fatalf("Get after Set(%v): %v", value, err)
+	}
+	raw, _ := ctx.Query("v")
+	return back, raw.Raw
+}
+
+func TestOutVariable_EncodingsRoundTrip(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2026, 3, 4, 5, 6, 7, 8, time.UTC)
+	if back, raw := roundTrip(t, at); !back.Equal(at) || raw != `"2026-03-04T05:06:07.000000008Z"` {
+		t.Errorf("time.Time: %v via %s", back, raw)
+	}
+	if back, raw := roundTrip(t, 90*time.Second); back != 90*time.Second || raw != `90` {
+		t.Errorf("time.Duration: %v via %s", back, raw)
+	}
+	if back, raw := roundTrip(t, []byte("hi\x00")); string(back) != "hi\x00" || raw != `"aGkA"` {
+		t.Errorf("[]byte: %q via %s", back, raw)
+	}
+	limits := map[string]time.Duration{"a": time.Second}
+	if back, raw := roundTrip(t, limits); fmt.Sprint(back) != fmt.Sprint(limits) || raw != `{"a":1}` {
+		t.Errorf("map[string]time.Duration: %v via %s", back, raw)
+	}
+	// Values held in interfaces are encoded too, so a typed Get reads them.
+	ctx := newCtx(`{}`)
+	loose := map[string]interface{}{"wait": time.Second, "limit": time.Minute}
+	if err := (&OutVariable[map[string]interface{}]{Variable[map[string]interface{}]{Scope: "Message", Name: "v"}}).Set(ctx, loose); err != nil {
+		t.Fatalf("Set(%v): %v", loose, err)
+	}
+	waits, err := (&InVariable[map[string]time.Duration]{Variable[map[string]time.Duration]{Scope: "Message", Name: "v"}}).Get(ctx)
+	if raw, _ := ctx.Query("v"); err != nil || waits["wait"] != time.Second || waits["limit"] != time.Minute {
+		t.Errorf("map[string]interface{}: %v, %v via %s", waits, err, raw.Raw)
+	}
+	if _, raw := roundTrip(t, []interface{}{time.Minute, []byte("hi")}); raw != `[60,"aGk="]` {
+		t.Errorf("[]interface{}: written as %s", raw)
+	}
+	if back, raw := roundTrip(t, json.RawMessage(`{"x":[1]}`)); string(back) != `{"x":[1]}` || raw != `{"x":[1]}` {
+		t.Errorf("json.RawMessage: %s via %s", back, raw)
+	}
+	if back, raw := roundTrip(t, netip.MustParseAddr("::1")); back.String() != "::1" || raw != `"::1"` {
+		t.Errorf("TextMarshaler: %v via %s", back, raw)
+	}
+	if back, raw := roundTrip(t, uint64(1<<53)); back != 1<<53 || raw != `9007199254740992` {
+		t.Errorf("uint64: %v via %s", back, raw)
+	}
+}
+
+func TestToValue_Encodings(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	got := convert(ToValue(map[string]interface{}{
+		"at":    at,
+		"wait":  2 * time.Second,
+		"data":  []byte("hi"),
+		"n":     1,
+		"raw":   json.RawMessage(`{"a":true}`),
+		"addr":  netip.MustParseAddr("10.0.0.1"),
+		"times": []time.Time{at},
+	}))
+	data, _ := json.Marshal(got)
+	want := `{"addr":"10.0.0.1","at":"2026-03-04T05:06:07Z","data":"aGk=","n":1,"raw":{"a":true},"times":["2026-03-04T05:06:07Z"],"wait":2}`
+	if string(data) != want {
+		t.Fatalf("ToValue = %s\nwant      %s", data, want)
+	}
+
+	// What ToValue sends, InVariable reads back; in a struct, a duration
+	// is in nanoseconds as encoding/json has it.
+	type fxTimes struct {
+		At    time.Time
+		Wait  time.Duration
+		Times []time.Time
+	}
+	in := fxTimes{At: at, Wait: 2 * time.Second, Times: []time.Time{at}}
+	back, err := getCustom[fxTimes](convert(ToValue(in)))
+	if err != nil || !back.At.Equal(at) || back.Wait != 2*time.Second || len(back.Times) != 1 {
+		t.Fatalf("struct read back = %+v, %v", back, err)
+	}
+	wait, err := getCustom[time.Duration](got.(map[string]interface{})["wait"])
+	if err != nil || wait != 2*time.Second {
+		t.Fatalf("duration read back = %v, %v", wait, err)
+	}
+
+	// Durations below a struct field are nanoseconds too.
+	type fxStep struct{ Wait time.Duration }
+	type fxNested struct {
+		L     []time.Duration
+		P     *time.Duration
+		M     map[string]time.Duration
+		Steps []fxStep
+	}
+	p := 2 * time.Second
+	nested := fxNested{
+		L:     []time.Duration{time.Second},
+		P:     &p,
+		M:     map[string]time.Duration{"a": time.Minute},
+		Steps: []fxStep{{Wait: time.Millisecond}},
+	}
+	nestedBack, err := getCustom[fxNested](convert(ToValue(nested)))
+	if err != nil || fmt.Sprint(nestedBack.L, *nestedBack.P, nestedBack.M, nestedBack.Steps) != fmt.Sprint(nested.L, p, nested.M, nested.Steps) {
+		t.Fatalf("nested durations read back = %+v, %v", nestedBack, err)
+	}
+}
@@ -1,8 +1,6 @@
 package runtime
 
 import (
-	"fmt"
-
 	"github.com/mitchellh/mapstructure"
 	"github.com/robomotionio/robomotion-go/message"
 )
@@ -23,12 +21,13 @@ type credential struct {
 }
 
 func (c *Credential) Set(ctx message.Context, data []byte) (map[string]interface{}, error) {
-	if client == nil {
-		return nil, fmt.Errorf("Runtime was not initialized")
+	h := helperOf(ctx)
+	if h == nil {
+		return nil, errNotInitialized
 	}
 
 	if c.VaultID != "" && c.ItemID != "" {
-		return client.SetVaultItem(c.VaultID, c.ItemID, data)
+		return h.SetVaultItem(c.VaultID, c.ItemID, data)
 	}
 
 	var (
@@ -38,7 +37,7 @@ func (c *Credential) Set(ctx message.Context, data []byte) (map[string]interface
 	)
 
 	if c.Scope == "Message" {
-		v := &InVariable[any]{Variable: Variable[any]{Scope: c.Scope, Name: c.Name.(string)}}
+		v := &InVariable[any]{Variable: Variable[any]{Scope: c.Scope, Name: c.Name}}
 		ci, err = v.Get(ctx)
 		if err != nil {
 			return nil, err
@@ -50,16 +49,17 @@ func (c *Credential) Set(ctx message.Context, data []byte) (map[string]interface
 		return nil, err
 	}
 
-	return client.SetVaultItem(creds.VaultID, creds.ItemID, data)
+	return h.SetVaultItem(creds.VaultID, creds.ItemID, data)
 }
 
 func (c *Credential) Get(ctx message.Context) (map[string]interface{}, error) {
-	if client == nil {
-		return nil, fmt.Errorf("Runtime was not initialized")
+	h := helperOf(ctx)
+	if h == nil {
+		return nil, errNotInitialized
 	}
 
 	if c.VaultID != "" && c.ItemID != "" {
-		return client.GetVaultItem(c.VaultID, c.ItemID)
+		return h.GetVaultItem(c.VaultID, c.ItemID)
 	}
 
 	var (
@@ -69,7 +69,7 @@ func (c *Credential) Get(ctx message.Context) (map[string]interface{}, error) {
 	)
 
 	if c.Scope == "Message" {
-		v := &InVariable[any]{Variable: Variable[any]{Scope: c.Scope, Name: c.Name.(string)}}
+		v := &InVariable[any]{Variable: Variable[any]{Scope: c.Scope, Name: c.Name}}
 		ci, err = v.Get(ctx)
 		if err != nil {
 			return nil, err
@@ -81,5 +81,5 @@ func (c *Credential) Get(ctx message.Context) (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	return client.GetVaultItem(creds.VaultID, creds.ItemID)
+	return h.GetVaultItem(creds.VaultID, creds.ItemID)
 }
@@ -1,20 +1,233 @@
 package runtime
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Well-known error codes. Nodes may use any code string; these are the ones
+// the runtime maps to gRPC status codes and CLI exit codes.
+const (
+	ErrInvalidInput = "ErrInvalidInput"
+	ErrInvalidArg   = "ErrInvalidArg" // older spelling of ErrInvalidInput
+	ErrUnauthorized = "ErrUnauthorized"
+	ErrRateLimited  = "ErrRateLimited"
+	ErrNotFound     = "ErrNotFound"
+	ErrTimeout      = "ErrTimeout"
+	ErrCanceled     = "ErrCanceled"
+	ErrUnknown      = "ErrUnknown"
+
+	// ErrPanic is the code of the Error the runtime reports when a node's
+	// lifecycle call panics.
+	ErrPanic = "ErrPanic"
+)
+
+// Error is the structured error nodes return. It serializes to JSON in
+// Error(), which is what the robot and CLI mode show, and supports
+// errors.Is (by Code) and errors.As/Unwrap (through Err).
 type Error struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Retryable bool                   `json:"retryable,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Stack     string                 `json:"stack,omitempty"`
+
+	// Err is the wrapped cause. It is not serialized; its text is already
+	// in Message when built with WrapError.
+	Err error `json:"-"`
 }
 
+// NewError returns an Error with code and message. RateLimited and Timeout
+// errors start out retryable.
 func NewError(code, message string) *Error {
 	return &Error{
-		Code:    code,
-		Message: message,
+		Code:      code,
+		Message:   message,
+		Retryable: code == ErrRateLimited || code == ErrTimeout,
+	}
+}
+
+// WrapError returns an Error with code that wraps err, using err's text as
+// the message.
+func WrapError(code string, err error) *Error {
+	e := NewError(code, "")
+	if err != nil {
+		e.Message = err.Error()
+		e.Err = err
 	}
+	return e
 }
 
 func (e *Error) Error() string {
 	err, _ := json.Marshal(e)
 	return string(err)
 }
+
+// Unwrap returns the wrapped cause, if any.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches another *Error by Code, and by Message too when the target sets
+// one, so errors.Is(err, runtime.NewError(runtime.ErrNotFound, "")) tests for
+// a code anywhere in the chain.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && (t.Message == "" || t.Message == e.Message)
+}
+
+// WithRetryable sets the retryable flag and returns e.
+func (e *Error) WithRetryable(retryable bool) *Error {
+	e.Retryable = retryable
+	return e
+}
+
+// WithDetail adds one detail entry and returns e.
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// AsError returns the first *Error in err's chain, or converts err into one:
+// context cancellation and deadlines get ErrCanceled and ErrTimeout, any
+// other error ErrUnknown. Returns nil for a nil err.
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var rerr *Error
+	if errors.As(err, &rerr) {
+		return rerr
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return WrapError(ErrTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return WrapError(ErrCanceled, err)
+	}
+	return WrapError(ErrUnknown, err)
+}
+
+// IsRetryable reports whether err carries a retryable *Error.
+func IsRetryable(err error) bool {
+	var rerr *Error
+	return errors.As(err, &rerr) && rerr.Retryable
+}
+
+// ErrorCode returns the code of the *Error in err's chain, or "" if none.
+func ErrorCode(err error) string {
+	var rerr *Error
+	if errors.As(err, &rerr) {
+		return rerr.Code
+	}
+	return ""
+}
+
+// grpcCode maps an error code to the gRPC status code the robot receives.
+func grpcCode(code string) codes.Code {
+	switch code {
+	case ErrInvalidInput, ErrInvalidArg:
+		return codes.InvalidArgument
+	case ErrUnauthorized:
+		return codes.Unauthenticated
+	case ErrRateLimited:
+		return codes.ResourceExhausted
+	case ErrNotFound:
+		return codes.NotFound
+	case ErrTimeout:
+		return codes.DeadlineExceeded
+	case ErrCanceled:
+		return codes.Canceled
+	case ErrPanic:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// grpcError converts a lifecycle error into what the gRPC server returns.
+// Errors carrying a *Error, and context errors, become statuses whose code
+// follows grpcCode and whose message is unchanged; other errors and errors
+// that already are statuses are returned as they are.
+func grpcError(err error) error {
+	if err == nil {
+		return err
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var rerr *Error
+	if !errors.As(err, &rerr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return status.Error(grpcCode(AsError(err).Code), err.Error())
+}
+
+// errorFromStatus reverses grpcError on the client side: a status whose
+// message holds a JSON-encoded *Error, alone or with the text of errors
+// that wrapped it around, yields an error with that text whose chain holds
+// the *Error.
+func errorFromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	msg := st.Message()
+	for i := 0; i < len(msg); i++ {
+		j := strings.Index(msg[i:], `{"code":`)
+		if j < 0 {
+			break
+		}
+		i += j
+		var rerr Error
+		dec := json.NewDecoder(strings.NewReader(msg[i:]))
+		if dec.Decode(&rerr) != nil || rerr.Code == "" {
+			continue
+		}
+		prefix, suffix := msg[:i], msg[i+int(dec.InputOffset()):]
+		if prefix == "" && suffix == "" {
+			return &rerr
+		}
+		return fmt.Errorf("%s%w%s", prefix, &rerr, suffix)
+	}
+	return err
+}
+
+// errorMessage returns the text of err for people to read: err.Error(),
+// with the first *Error in its chain shown by its Message rather than as
+// JSON, so the text of errors wrapping it is kept.
+func errorMessage(err error) string {
+	var rerr *Error
+	if !errors.As(err, &rerr) {
+		return err.Error()
+	}
+	return strings.Replace(err.Error(), rerr.Error(), rerr.Message, 1)
+}
+
+// errorPayload is the __error__ object written into the outgoing message
+// when ContinueOnError lets a failed node pass its message on.
+func errorPayload(err error, node Node) map[string]interface{} {
+	rerr := AsError(err)
+	payload := map[string]interface{}{
+		"code":    rerr.Code,
+		"message": errorMessage(err),
+		"guid":    node.GUID,
+		"name":    node.Name,
+	}
+	if rerr.Retryable {
+		payload["retryable"] = true
+	}
+	if len(rerr.Details) > 0 {
+		payload["details"] = rerr.Details
+	}
+	return payload
+}
@@ -0,0 +1,141 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+func TestError_IsAndAs(t *testing.T) {
+	t.Parallel()
+	err := fmt.Errorf("fetching user: %w", WrapError(ErrNotFound, io.EOF))
+
+	if !errors.Is(err, NewError(ErrNotFound, "")) {
+		t.Fatal("errors.Is did not match by code")
+	}
+	if errors.Is(err, NewError(ErrTimeout, "")) {
+		t.Fatal("errors.Is matched a different code")
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Fatal("errors.Is did not reach the wrapped cause")
+	}
+
+	var rerr *Error
+	if !errors.As(err, &rerr) || rerr.Message != io.EOF.Error() {
+		t.Fatalf("errors.As = %+v", rerr)
+	}
+	if ErrorCode(err) != ErrNotFound {
+		t.Fatalf("ErrorCode = %q", ErrorCode(err))
+	}
+}
+
+func TestError_Retryable(t *testing.T) {
+	t.Parallel()
+	if !IsRetryable(NewError(ErrRateLimited, "slow down")) {
+		t.Fatal("RateLimited should start out retryable")
+	}
+	if IsRetryable(NewError(ErrRateLimited, "slow down").WithRetryable(false)) {
+		t.Fatal("WithRetryable(false) did not override the default")
+	}
+	if IsRetryable(NewError(ErrNotFound, "gone")) {
+		t.Fatal("NotFound should not be retryable")
+	}
+}
+
+func TestGRPCError_Mapping(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		err  error
+		want codes.Code
+	}{
+		{NewError(ErrInvalidInput, "x"), codes.InvalidArgument},
+		{NewError(ErrInvalidArg, "x"), codes.InvalidArgument},
+		{NewError(ErrUnauthorized, "x"), codes.Unauthenticated},
+		{NewError(ErrRateLimited, "x"), codes.ResourceExhausted},
+		{NewError(ErrNotFound, "x"), codes.NotFound},
+		{NewError(ErrTimeout, "x"), codes.DeadlineExceeded},
+		{NewError("ErrCustom", "x"), codes.Unknown},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+	}
+	for _, c := range cases {
+		st, ok := status.FromError(grpcError(c.err))
+		if !ok || st.Code() != c.want {
+			t.Errorf("grpcError(%v) code = %v, want %v", c.err, st.Code(), c.want)
+		}
+	}
+
+	// Plain errors keep their exact text.
+	plain := errors.New("plain")
+	if grpcError(plain) != plain {
+		t.Fatal("grpcError rewrote a plain error")
+	}
+
+	// The client side recovers the structured error.
+	rerr, ok := errorFromStatus(grpcError(NewError(ErrNotFound, "gone").WithDetail("id", "42"))).(*Error)
+	if !ok || rerr.Code != ErrNotFound || rerr.Details["id"] != "42" {
+		t.Fatalf("errorFromStatus = %#v", rerr)
+	}
+
+	// Wrapping text survives the status and the CLI message, and the code is
+	// still found behind it.
+	wrapped := fmt.Errorf("fetch page 2: %w", NewError(ErrRateLimited, "slow down"))
+	st, _ := status.FromError(grpcError(wrapped))
+	if st.Code() != codes.ResourceExhausted || st.Message() != wrapped.Error() {
+		t.Fatalf("grpcError(wrapped) = %v %q", st.Code(), st.Message())
+	}
+	back := errorFromStatus(grpcError(wrapped))
+	if ErrorCode(back) != ErrRateLimited || !IsRetryable(back) || back.Error() != wrapped.Error() {
+		t.Fatalf("errorFromStatus(wrapped) = %v", back)
+	}
+	if got := errorMessage(back); got != "fetch page 2: slow down" {
+		t.Fatalf("errorMessage = %q", got)
+	}
+}
+
+func TestOnMessage_ContinueOnErrorWritesErrorObject(t *testing.T) {
+	t.Parallel()
+	addTestHandler(t, "continue", Node{Name: "Failing", ContinueOnError: true},
+		fxFunc(func(message.Context) error { return NewError(ErrRateLimited, "slow down") }))
+
+	s := &GRPCServer{}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "continue", `{"a":1}`))
+	if err != nil {
+		t.Fatalf("OnMessage err = %v, want nil with ContinueOnError", err)
+	}
+	out := message.NewContext(resp.OutMessage)
+	if out.GetInt("a") != 1 {
+		t.Fatalf("payload lost: %s", resp.OutMessage)
+	}
+	if out.GetString("__error__.code") != ErrRateLimited ||
+		out.GetString("__error__.message") != "slow down" ||
+		!out.GetBool("__error__.retryable") ||
+		out.GetString("__error__.guid") != "continue" {
+		t.Fatalf("unexpected __error__: %s", resp.OutMessage)
+	}
+}
+
+func TestCLIExitCode(t *testing.T) {
+	t.Parallel()
+	cases := map[string]int{
+		ErrInvalidInput: 10,
+		ErrInvalidArg:   10,
+		ErrUnauthorized: 11,
+		ErrNotFound:     12,
+		ErrRateLimited:  13,
+		ErrTimeout:      14,
+		ErrCanceled:     130,
+		ErrUnknown:      1,
+	}
+	for code, want := range cases {
+		if got := cliExitCode(code); got != want {
+			t.Errorf("cliExitCode(%s) = %d, want %d", code, got, want)
+		}
+	}
+}
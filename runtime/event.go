@@ -3,9 +3,14 @@ package runtime
 import (
 	"fmt"
 
+	"github.com/robomotionio/robomotion-go/message"
 	"github.com/robomotionio/robomotion-go/proto"
 )
 
+// The package-level Emit functions go through the package-level helper,
+// the one of the runtime that called Init last. Inside a node call use the
+// With variants, which go through the helper of the runtime that sent ctx.
+
 func EmitDebug(guid, name string, message interface{}) error {
 	if client == nil {
 		return fmt.Errorf("Runtime was not initialized")
@@ -41,6 +46,54 @@ func EmitFlowEvent(guid, name string) error {
 
 	return client.EmitFlowEvent(guid, name)
 }
+
+// EmitDebugWith is EmitDebug through the helper of the runtime that sent ctx.
+func EmitDebugWith(ctx message.Context, guid, name string, msg interface{}) error {
+	h := helperOf(ctx)
+	if h == nil {
+		return errNotInitialized
+	}
+	return h.Debug(guid, name, msg)
+}
+
+// EmitOutputWith is EmitOutput through the helper of the runtime that sent
+// ctx.
+func EmitOutputWith(ctx message.Context, guid string, output []byte, port int32) error {
+	h := helperOf(ctx)
+	if h == nil {
+		return errNotInitialized
+	}
+	return h.EmitOutput(guid, output, port)
+}
+
+// EmitInputWith is EmitInput through the helper of the runtime that sent ctx.
+func EmitInputWith(ctx message.Context, guid string, input []byte) error {
+	h := helperOf(ctx)
+	if h == nil {
+		return errNotInitialized
+	}
+	return h.EmitInput(guid, input)
+}
+
+// EmitErrorWith is EmitError through the helper of the runtime that sent ctx.
+func EmitErrorWith(ctx message.Context, guid, name, msg string) error {
+	h := helperOf(ctx)
+	if h == nil {
+		return errNotInitialized
+	}
+	return h.EmitError(guid, name, msg)
+}
+
+// EmitFlowEventWith is EmitFlowEvent through the helper of the runtime that
+// sent ctx.
+func EmitFlowEventWith(ctx message.Context, guid, name string) error {
+	h := helperOf(ctx)
+	if h == nil {
+		return errNotInitialized
+	}
+	return h.EmitFlowEvent(guid, name)
+}
+
 func AppRequest(request []byte, timeout int32) ([]byte, error) {
 	if client == nil {
 		return nil, fmt.Errorf("Runtime was not initialized")
@@ -36,6 +36,11 @@ func (f *NodeFactory) OnCreate(ctx context.Context, config []byte) error {
 		return fmt.Errorf("Missing node common properties")
 	}
 
+	// Hand the node the helper of the runtime creating it.
+	if h := helperIn(ctx); h != nil {
+		field.Addr().Interface().(*Node).helper = h
+	}
+
 	node := field.Interface().(Node)
 	AddNodeHandler(node, handler)
 	return nil
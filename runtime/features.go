@@ -0,0 +1,187 @@
+package runtime
+
+import (
+	"sort"
+	"sync"
+)
+
+// Named features are negotiated next to the capability bits. Each side
+// advertises a set of feature names with a version; the robot sends its set
+// as "features" in GetRobotInfo and the package returns its own from the
+// GetCapabilities RPC. Unlike bits, a name needs no registry shared between
+// repos to stay unambiguous, so new features should be named, not numbered:
+//
+//	func init() {
+//	    runtime.DeclareFeature("stream.output", 1)
+//	}
+//
+//	if runtime.Feature("stream.output") { ... }
+//
+// Every capability bit also appears under its name (see capabilityFeatures),
+// at version 1, on whichever side sets the bit.
+
+// capabilityFeatures names the existing capability bits.
+var capabilityFeatures = []struct {
+	bit  Capability
+	name string
+}{
+	{CapabilityIgnoreVersionCheck, "robot.ignore_version_check"},
+	{CapabilityTerminateOnStop, "robot.terminate_on_stop"},
+	{CapabilityUseS3, "assets.s3"},
+	{CapabilityLMO, "lmo"},
+	{CapabilityDiagnostics, "robot.diagnostics"},
+	{CapabilityLazyMessage, "message.lazy"},
+}
+
+var (
+	featuresMu      sync.RWMutex
+	packageFeatures = map[string]int{}
+	robotFeatures   = map[string]int{}
+)
+
+// FeatureName returns the feature name of a capability bit, or "".
+func FeatureName(capability Capability) string {
+	for _, cf := range capabilityFeatures {
+		if cf.bit == capability {
+			return cf.name
+		}
+	}
+	return ""
+}
+
+// DeclareFeature advertises a feature of the package at version. Declaring
+// the name of a capability bit sets the bit too. A version of 0 or less
+// withdraws the feature, clearing its bit. Call it from init, before the
+// robot asks for the package's features.
+func DeclareFeature(name string, version int) {
+	for _, cf := range capabilityFeatures {
+		if cf.name != name {
+			continue
+		}
+		if version > 0 {
+			packageCapabilities.Or(uint64(cf.bit))
+		} else {
+			packageCapabilities.And(^uint64(cf.bit))
+		}
+	}
+	featuresMu.Lock()
+	if version > 0 {
+		packageFeatures[name] = version
+	} else {
+		delete(packageFeatures, name)
+	}
+	featuresMu.Unlock()
+}
+
+// SetRobotFeatures replaces the features the robot advertised.
+func SetRobotFeatures(features map[string]int) {
+	featuresMu.Lock()
+	robotFeatures = make(map[string]int, len(features))
+	for name, version := range features {
+		robotFeatures[name] = version
+	}
+	featuresMu.Unlock()
+}
+
+// Feature reports whether both the robot and the package support name.
+func Feature(name string) bool {
+	return FeatureVersion(name) > 0
+}
+
+// FeatureVersion returns the version of name both sides support, the lower
+// of the two, or 0 when either side lacks it.
+func FeatureVersion(name string) int {
+	p, r := PackageFeatures()[name], RobotFeatures()[name]
+	if p < r {
+		return p
+	}
+	return r
+}
+
+// RobotFeature reports whether the robot supports name, whatever the
+// package declared; the named form of HasRobotCapability.
+func RobotFeature(name string) bool {
+	return RobotFeatures()[name] > 0
+}
+
+// PackageFeatures returns the package's features, bits included.
+func PackageFeatures() map[string]int {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	return withCapabilityFeatures(packageFeatures, packageCapabilities.Load())
+}
+
+// RobotFeatures returns the robot's features, bits included.
+func RobotFeatures() map[string]int {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	return withCapabilityFeatures(robotFeatures, robotCapabilities.Load())
+}
+
+// NegotiatedFeatures returns the features both sides support, at the
+// version FeatureVersion would give.
+func NegotiatedFeatures() map[string]int {
+	p, r := PackageFeatures(), RobotFeatures()
+	both := map[string]int{}
+	for name, version := range p {
+		if rv := r[name]; rv > 0 && version > 0 {
+			if rv < version {
+				version = rv
+			}
+			both[name] = version
+		}
+	}
+	return both
+}
+
+// FeatureNames returns the names in features, sorted.
+func FeatureNames(features map[string]int) []string {
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func withCapabilityFeatures(features map[string]int, bits uint64) map[string]int {
+	out := make(map[string]int, len(features)+len(capabilityFeatures))
+	for _, cf := range capabilityFeatures {
+		if bits&uint64(cf.bit) != 0 {
+			out[cf.name] = 1
+		}
+	}
+	for name, version := range features {
+		out[name] = version
+	}
+	return out
+}
+
+// parseRobotFeatures reads the "features" entry of GetRobotInfo: an object
+// of name to version, as protobuf Struct numbers. A list of names is taken
+// as version 1 of each.
+func parseRobotFeatures(v interface{}) (map[string]int, bool) {
+	features := map[string]int{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, version := range v {
+			switch version := version.(type) {
+			case float64:
+				features[name] = int(version)
+			case bool:
+				if version {
+					features[name] = 1
+				}
+			}
+		}
+	case []interface{}:
+		for _, name := range v {
+			if name, ok := name.(string); ok {
+				features[name] = 1
+			}
+		}
+	default:
+		return nil, false
+	}
+	return features, true
+}
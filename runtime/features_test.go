@@ -0,0 +1,86 @@
+package runtime
+
+import "testing"
+
+// useFeatures restores the capability bits and feature sets after t.
+func useFeatures(t *testing.T) {
+	t.Helper()
+	oldRobot, oldPackage := GetRobotCapabilities(), GetPackageCapabilities()
+	featuresMu.Lock()
+	oldRobotFeatures, oldPackageFeatures := robotFeatures, packageFeatures
+	packageFeatures = map[string]int{}
+	featuresMu.Unlock()
+	t.Cleanup(func() {
+		SetRobotCapabilities(oldRobot)
+		SetPackageCapabilities(oldPackage)
+		featuresMu.Lock()
+		robotFeatures, packageFeatures = oldRobotFeatures, oldPackageFeatures
+		featuresMu.Unlock()
+	})
+}
+
+func TestFeature_NegotiatesLowerVersion(t *testing.T) {
+	useFeatures(t)
+	DeclareFeature("stream.output", 2)
+	DeclareFeature("package.only", 1)
+
+	features, ok := parseRobotFeatures(map[string]interface{}{
+		"stream.output": float64(1),
+		"robot.only":    float64(3),
+	})
+	if !ok {
+		t.Fatal("parseRobotFeatures rejected an object")
+	}
+	SetRobotFeatures(features)
+
+	if !Feature("stream.output") || FeatureVersion("stream.output") != 1 {
+		t.Errorf("stream.output: Feature=%v version=%d, want true 1", Feature("stream.output"), FeatureVersion("stream.output"))
+	}
+	if Feature("package.only") || Feature("robot.only") {
+		t.Error("a feature only one side has was negotiated")
+	}
+	if !RobotFeature("robot.only") {
+		t.Error("RobotFeature(robot.only) = false")
+	}
+}
+
+func TestFeature_CapabilityBitsMapToNames(t *testing.T) {
+	useFeatures(t)
+	SetPackageCapabilities(uint64(CapabilityLMO))
+	SetRobotCapabilities(uint64(CapabilityLMO | CapabilityTerminateOnStop))
+	SetRobotFeatures(nil)
+
+	if !Feature("lmo") {
+		t.Error("Feature(lmo) = false with the LMO bit on both sides")
+	}
+	if Feature("robot.terminate_on_stop") {
+		t.Error("a robot-only bit was negotiated")
+	}
+	if !RobotFeature(FeatureName(CapabilityTerminateOnStop)) {
+		t.Error("RobotFeature does not see the TerminateOnStop bit")
+	}
+
+	SetRobotCapabilities(0)
+	DeclareFeature("message.lazy", 1)
+	if GetPackageCapabilities()&uint64(CapabilityLazyMessage) == 0 {
+		t.Error("declaring a bit's feature name did not set the bit")
+	}
+}
+
+func TestDeclareFeature_ZeroVersionWithdraws(t *testing.T) {
+	useFeatures(t)
+	SetPackageCapabilities(uint64(CapabilityLMO))
+	DeclareFeature("stream.output", 2)
+
+	DeclareFeature("stream.output", 0)
+	DeclareFeature("lmo", -1)
+	if _, ok := PackageFeatures()["stream.output"]; ok {
+		t.Error("stream.output is still declared at version 0")
+	}
+	if GetPackageCapabilities()&uint64(CapabilityLMO) != 0 {
+		t.Error("withdrawing lmo left its bit set")
+	}
+	if _, ok := PackageFeatures()["lmo"]; ok {
+		t.Error("lmo is still declared after it was withdrawn")
+	}
+}
@@ -0,0 +1,131 @@
+package runtime
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/robomotionio/robomotion-go/message"
+	"github.com/robomotionio/robomotion-go/proto"
+)
+
+// Most runtime tests drive GRPCServer directly, without a robot on the other
+// end of the plugin connection. Each registers a fixture node under its own
+// GUID, compresses an input message the way the robot does, and inspects the
+// OnMessage response and the calls the node made through its RuntimeHelper.
+
+// fxNode is the fixture node: OnMessage runs onMessage, when set, and counts
+// its calls, and OnClose records that it ran.
+type fxNode struct {
+	Node
+	onMessage func(ctx message.Context) error
+	calls     atomic.Int32
+	closed    atomic.Bool
+}
+
+func (n *fxNode) OnCreate() error { return nil }
+func (n *fxNode) OnClose() error  { n.closed.Store(true); return nil }
+func (n *fxNode) OnMessage(ctx message.Context) error {
+	n.calls.Add(1)
+	if n.onMessage == nil {
+		return nil
+	}
+	return n.onMessage(ctx)
+}
+
+// fxFunc returns an fxNode running fn.
+func fxFunc(fn func(ctx message.Context) error) *fxNode {
+	return &fxNode{onMessage: fn}
+}
+
+// fxBlocking returns an fxNode that blocks in OnMessage until the message
+// context is done, closing started when it begins and sending the context's
+// error to seen.
+func fxBlocking() (n *fxNode, started chan struct{}, seen chan error) {
+	started, seen = make(chan struct{}), make(chan error, 1)
+	return fxFunc(func(ctx message.Context) error {
+		close(started)
+		<-ctx.Context().Done()
+		seen <- ctx.Context().Err()
+		return ctx.Context().Err()
+	}), started, seen
+}
+
+// fxLifecycle gives fixtures that need their own type, for a spec tag or an
+// optional interface, no-op OnCreate and OnClose.
+type fxLifecycle struct{}
+
+func (fxLifecycle) OnCreate() error { return nil }
+func (fxLifecycle) OnClose() error  { return nil }
+
+// fxHelper is the fixture RuntimeHelper: it records Debug and EmitOutput
+// calls, and every variable it is asked for holds variable.
+type fxHelper struct {
+	testClient
+	variable interface{}
+
+	mu      sync.Mutex
+	debugs  []fxCall
+	outputs []fxCall
+}
+
+// fxCall is one recorded call: the node and, for Debug, the message or, for
+// EmitOutput, the output message and its port.
+type fxCall struct {
+	guid string
+	msg  interface{}
+	out  []byte
+	port int32
+}
+
+func (h *fxHelper) Debug(guid, _ string, msg interface{}) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.debugs = append(h.debugs, fxCall{guid: guid, msg: msg})
+	return nil
+}
+
+func (h *fxHelper) EmitOutput(guid string, output []byte, port int32) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.outputs = append(h.outputs, fxCall{guid: guid, out: output, port: port})
+	return nil
+}
+
+func (h *fxHelper) GetVariable(*variable) (interface{}, error) { return h.variable, nil }
+
+func (h *fxHelper) debugCalls() []fxCall {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]fxCall(nil), h.debugs...)
+}
+
+func (h *fxHelper) outputCalls() []fxCall {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]fxCall(nil), h.outputs...)
+}
+
+// addTestHandler registers handler under guid and removes it when the test
+// ends.
+func addTestHandler(t *testing.T, guid string, node Node, handler MessageHandler) {
+	t.Helper()
+	node.GUID = guid
+	AddNodeHandler(node, handler)
+	t.Cleanup(func() { RemoveNodeHandler(guid) })
+}
+
+func onMessageRequest(t *testing.T, guid, raw string) *proto.OnMessageRequest {
+	t.Helper()
+	in, err := Compress([]byte(raw))
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	return &proto.OnMessageRequest{Guid: guid, InMessage: in}
+}
+
+// payloadOf returns an output message without its envelope.
+func payloadOf(out []byte) string {
+	payload, _ := message.SplitMeta(out)
+	return string(payload)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -13,12 +14,16 @@ import (
 	"github.com/tidwall/gjson"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/robomotionio/robomotion-go/message"
 	"github.com/robomotionio/robomotion-go/proto"
 )
 
+// errShuttingDown refuses calls that arrive while the package is draining.
+var errShuttingDown = status.Error(codes.Unavailable, "package is shutting down")
+
 var (
 	conn      *grpc.ClientConn
 	client    RuntimeHelper
@@ -32,29 +37,62 @@ type GRPCServer struct {
 	// Concrete implementation, written in Go. This is only used for plugins
 	// that are written in Go.
 	Impl INode
+
+	// helper is this server's RuntimeHelper, handed to the nodes it creates.
+	// Nil falls back to the package-level helper.
+	helper RuntimeHelper
+}
+
+// withHelper returns ctx carrying the server's helper, if it has one.
+func (m *GRPCServer) withHelper(ctx context.Context) context.Context {
+	if m.helper == nil {
+		return ctx
+	}
+	return WithRuntimeHelper(ctx, m.helper)
+}
+
+// helperFor returns the helper for calls into node: the one it was created
+// with, else the server's, else the package-level one.
+func (m *GRPCServer) helperFor(node *NodeHandler) RuntimeHelper {
+	if node != nil && node.helper != nil {
+		return node.helper
+	}
+	if m.helper != nil {
+		return m.helper
+	}
+	return client
 }
 
 func (m *GRPCServer) Init(ctx context.Context, req *proto.InitRequest) (*proto.Empty, error) {
 
 	var err error
+	_, span := StartSpan(ctx, "Init")
+	defer func() { span.Finish(err) }()
+
 	conn, err = m.broker.Dial(req.EventServer)
 	if err != nil {
 		hclog.Default().Info("grpc.server.init", "err", err)
 		return nil, err
 	}
 
-	go checkConnState()
+	queue := &emitQueue{}
+	go monitorConn(conn, queue)
+
+	// The handshake is done; stray prints to stdout go to the log now.
+	captureStdout()
 
 	if err := InitLMOStore(); err != nil {
 		hclog.Default().Info("grpc.server.init.lmo", "err", err)
 	}
 
-	e := &GRPCRuntimeHelperClient{proto.NewRuntimeHelperClient(conn)}
+	e := &GRPCRuntimeHelperClient{client: proto.NewRuntimeHelperClient(timedConn{conn}), queue: queue}
 
+	m.helper = e
 	m.Impl.Init(e)
 
 	// Fetch robot info for 2-way capability negotiation and LMO store path.
-	// Robot info already contains "capabilities" (uint64), "id", and "flow_id".
+	// Robot info already contains "capabilities" (uint64), "features" (name to
+	// version), "id", and "flow_id".
 	// NOTE: This runs in a goroutine to avoid deadlock — the robot host may not
 	// handle GetRobotInfo callbacks until Init returns.
 	initReady = make(chan struct{})
@@ -65,6 +103,9 @@ func (m *GRPCServer) Init(ctx context.Context, req *proto.InitRequest) (*proto.E
 			if bits, ok := info["capabilities"].(float64); ok {
 				SetRobotCapabilities(uint64(bits))
 			}
+			if features, ok := parseRobotFeatures(info["features"]); ok {
+				SetRobotFeatures(features)
+			}
 			// Use the opaque store path provided by the robot.
 			if storePath, ok := info["lmo_store_path"].(string); ok && storePath != "" {
 				if setErr := SetLMOStorePath(storePath); setErr != nil {
@@ -82,28 +123,44 @@ func (m *GRPCServer) OnCreate(ctx context.Context, req *proto.OnCreateRequest) (
 
 	resp := &proto.OnCreateResponse{}
 
+	if isDraining() {
+		return resp, errShuttingDown
+	}
+
 	f := GetNodeFactory(req.Name)
 	if f == nil {
 		return nil, fmt.Errorf("%s factory not found", req.Name)
 	}
 
-	err := f.OnCreate(context.TODO(), req.Config)
+	guid := gjson.Get(string(req.Config), "guid").String()
+
+	_, span := StartSpan(ctx, "OnCreate")
+	span.SetAttribute("guid", guid).SetAttribute("node.name", req.Name)
+
+	err := safeCall("factory.OnCreate", func() error {
+		return f.OnCreate(m.withHelper(context.TODO()), req.Config)
+	})
 	if err != nil {
 		hclog.Default().Info("grpc.server.oncreate.factory", "err", err)
-		return resp, err
+		reportPanic(m.helperFor(nil), guid, req.Name, err)
+		span.Finish(err)
+		return resp, grpcError(err)
 	}
 
-	guid := gjson.Get(string(req.Config), "guid").String()
-
 	node := GetNodeHandler(guid)
 	if node == nil {
 		hclog.Default().Info("grpc.server.oncreate.node", "err", "no handler")
+		err = fmt.Errorf("node handler not found")
+		span.Finish(err)
+		return resp, err
 	}
 
-	err = node.Handler.OnCreate()
+	err = safeCall("OnCreate", node.Handler.OnCreate)
+	span.Finish(err)
 	if err != nil {
 		hclog.Default().Info("grpc.server.oncreate.node", "err", err)
-		return resp, err
+		reportPanic(m.helperFor(node), guid, node.Name, err)
+		return resp, grpcError(err)
 	}
 
 	atomic.AddInt32(&nc, 1)
@@ -115,41 +172,94 @@ func (m *GRPCServer) OnMessage(ctx context.Context, req *proto.OnMessageRequest)
 
 	resp := &proto.OnMessageResponse{OutMessage: nil}
 
+	if !beginCall() {
+		return resp, errShuttingDown
+	}
+	defer endCall()
+
 	data, err := Decompress(req.InMessage)
 	if err != nil {
 		hclog.Default().Info("grpc.server.onmessage", "err", err)
 		return resp, err
 	}
 
-	node := GetNodeHandler(req.Guid)
+	node, unlock, err := lockNodeHandler(ctx, req.Guid, false)
+	if err != nil {
+		return resp, grpcError(err)
+	}
 	if node == nil {
 		hclog.Default().Info("grpc.server.oncreate.node", "err", "no handler")
 		return nil, fmt.Errorf("node handler not found")
 	}
-
-	msgCtx := message.NewContext(data)
-
-	time.Sleep(time.Duration(node.DelayBefore*1000) * time.Millisecond)
-	err = node.Handler.OnMessage(msgCtx)
+	defer unlock()
+	defer trackCall(req.Guid, node.nodeType)()
+
+	// The node sees a context that ends when the robot cancels this call
+	// (flow stop, deadline, dropped connection) or when the node is closed.
+	runCtx, cancel := mergeContext(ctx, node.Context())
+	defer cancel()
+
+	// The span continues the trace of the node that sent this message.
+	runCtx, span := StartSpan(withMessageTrace(runCtx, data), "OnMessage")
+	span.SetAttribute("guid", req.Guid).SetAttribute("node.name", node.Name)
+
+	// The node reaches its helper through the message context.
+	helper := traceHelper(runCtx, m.helperFor(node))
+	runCtx = WithRuntimeHelper(runCtx, helper)
+
+	if err := Sleep(runCtx, delayDuration(node.DelayBefore)); err != nil {
+		span.Finish(err)
+		return resp, grpcError(err)
+	}
+	start := time.Now()
+	var msgCtx message.Context
+	// A message that breaks the node's input schema never reaches it.
+	err = validateInput(node.inputSchema, data)
+	if err == nil {
+		msgCtx, err = retryMessage(runCtx, node.retry, req.Guid, func() (message.Context, error) {
+			return runWithTimeout(runCtx, node.timeout, req.Guid, node.Name, data, func(msgCtx message.Context) error {
+				err := safeCall("OnMessage", func() error {
+					return node.Handler.OnMessage(msgCtx)
+				})
+				reportPanic(m.helperFor(node), req.Guid, node.Name, err)
+				return err
+			})
+		})
+	}
+	observeMessage(node.nodeType, start, err)
+	if msgCtx == nil {
+		// The message was rejected, or the node is stuck past its timeout
+		// and may still write to its message; pass the input on instead.
+		msgCtx = message.NewContextWith(runCtx, data)
+	}
+	debugChanges(helper, node.Node, req.Guid, msgCtx)
 	if err != nil && node.ContinueOnError {
+		// Pass the message on, but let downstream nodes see what failed.
+		if !msgCtx.IsEmpty() {
+			msgCtx.Set("__error__", errorPayload(err, node.Node))
+		}
 		err = nil
 	}
 
-	if !msgCtx.IsEmpty() {
-		msg := message.PackedBytes(msgCtx)
-
-		if HasCapability(CapabilityLMO) {
-			if packed, packErr := LMOPack(msg); packErr == nil {
-				msg = packed
-			}
+	// A failed call sends nothing, not even what the node sent before failing.
+	if err == nil {
+		in := message.MetaOf(data)
+		for _, out := range msgCtx.Outputs() {
+			stampMeta(out.Message, in, req.Guid, span)
 		}
-
-		resp.OutMessage = msg
+		err = routeOutputs(helper, req.Guid, node.outputs, msgCtx, resp)
 	}
 
-	time.Sleep(time.Duration(node.DelayAfter*1000) * time.Millisecond)
+	// The message is handled; an update need not wait out the delay, and
+	// one that recreates the node does not cut it short.
+	delay := delayDuration(node.DelayAfter)
+	unlock()
+	if sleepErr := Sleep(ctx, delay); sleepErr != nil && err == nil {
+		err = sleepErr
+	}
 
-	return resp, err
+	span.Finish(err)
+	return resp, grpcError(err)
 }
 
 func (m *GRPCServer) OnClose(ctx context.Context, req *proto.OnCloseRequest) (*proto.OnCloseResponse, error) {
@@ -159,23 +269,86 @@ func (m *GRPCServer) OnClose(ctx context.Context, req *proto.OnCloseRequest) (*p
 		return nil, fmt.Errorf("No handler")
 	}
 
-	err := node.Handler.OnClose()
+	_, span := StartSpan(ctx, "OnClose")
+	span.SetAttribute("guid", req.Guid).SetAttribute("node.name", node.Name)
+
+	// Unblock any OnMessage still running for this node before closing it.
+	node.stop()
+	err := safeCall("OnClose", node.Handler.OnClose)
+	span.Finish(err)
+	reportPanic(m.helperFor(node), req.Guid, node.Name, err)
 	atomic.AddInt32(&nc, -1)
 	RemoveNodeHandler(req.Guid)
 	defer func() {
 		if atomic.LoadInt32(&nc) == 0 && !sessionMode {
-			CloseLMOStore()
-			defer func() {
-				done <- true
-			}()
+			requestShutdown(exitOK)
 		}
 	}()
-	return &proto.OnCloseResponse{}, err
+	return &proto.OnCloseResponse{}, grpcError(err)
+}
+
+func (m *GRPCServer) OnUpdate(ctx context.Context, req *proto.OnUpdateRequest) (*proto.OnUpdateResponse, error) {
+
+	resp := &proto.OnUpdateResponse{}
+
+	if !beginCall() {
+		return resp, errShuttingDown
+	}
+	defer endCall()
+
+	// Wait for in-flight messages; new ones wait for the update.
+	node, unlock, err := lockNodeHandler(ctx, req.Guid, true)
+	if err != nil {
+		return resp, grpcError(err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node handler not found")
+	}
+	defer unlock()
+
+	_, span := StartSpan(ctx, "OnUpdate")
+	span.SetAttribute("guid", req.Guid).SetAttribute("node.name", node.Name)
+
+	restart := true
+	if uh := AsUpdateHandler(node.Handler); uh != nil {
+		restart, err = updateInPlace(node, uh, req.Config)
+		reportPanic(m.helperFor(node), req.Guid, node.Name, err)
+	}
+	if err == nil && restart {
+		err = m.recreateNode(ctx, node, req.Config)
+		resp.Restarted = err == nil
+	}
+	if err != nil {
+		hclog.Default().Info("grpc.server.onupdate", "guid", req.Guid, "err", err)
+	}
+
+	span.SetAttribute("restarted", resp.Restarted).Finish(err)
+	return resp, grpcError(err)
 }
 
 func (m *GRPCServer) GetCapabilities(ctx context.Context, req *proto.Empty) (*proto.PGetCapabilitiesResponse, error) {
 	<-initReady
-	return &proto.PGetCapabilitiesResponse{Capabilities: uint64(packageCapabilities)}, nil
+	features := map[string]uint32{}
+	for name, version := range PackageFeatures() {
+		features[name] = uint32(version)
+	}
+	return &proto.PGetCapabilitiesResponse{Capabilities: GetPackageCapabilities(), Features: features}, nil
+}
+
+func (m *GRPCServer) Introspect(ctx context.Context, req *proto.Empty) (*proto.IntrospectResponse, error) {
+	report, err := json.Marshal(introspect())
+	if err != nil {
+		return nil, err
+	}
+	return &proto.IntrospectResponse{Report: report}, nil
+}
+
+func (m *GRPCServer) GetMetrics(ctx context.Context, req *proto.Empty) (*proto.GetMetricsResponse, error) {
+	var b strings.Builder
+	if err := DefaultMetrics.WritePrometheus(&b); err != nil {
+		return nil, err
+	}
+	return &proto.GetMetricsResponse{Text: b.String()}, nil
 }
 
 // OnSetup instantiates one node from config (like OnCreate) and runs its
@@ -193,12 +366,17 @@ func (m *GRPCServer) OnSetup(ctx context.Context, req *proto.OnSetupRequest) (*p
 		return resp, fmt.Errorf("%s factory not found", req.Name)
 	}
 
-	if err := f.OnCreate(context.TODO(), req.Config); err != nil {
+	guid := gjson.Get(string(req.Config), "guid").String()
+
+	err := safeCall("factory.OnCreate", func() error {
+		return f.OnCreate(m.withHelper(context.TODO()), req.Config)
+	})
+	if err != nil {
 		hclog.Default().Info("grpc.server.onsetup.factory", "err", err)
-		return resp, err
+		reportPanic(m.helperFor(nil), guid, req.Name, err)
+		return resp, grpcError(err)
 	}
 
-	guid := gjson.Get(string(req.Config), "guid").String()
 	node := GetNodeHandler(guid)
 	if node == nil {
 		return resp, fmt.Errorf("node handler not found")
@@ -211,20 +389,27 @@ func (m *GRPCServer) OnSetup(ctx context.Context, req *proto.OnSetupRequest) (*p
 	}
 
 	sctx := &setupContext{
+		helper:    m.helperFor(node),
 		guid:      guid,
 		sessionID: req.SessionId,
 		config:    req.Config,
 		ctx:       ctx,
 	}
-	if err := sh.OnSetup(sctx); err != nil {
+	err = safeCall("OnSetup", func() error { return sh.OnSetup(sctx) })
+	if err != nil {
 		hclog.Default().Info("grpc.server.onsetup.node", "err", err)
-		return resp, err
+		reportPanic(m.helperFor(node), guid, node.Name, err)
+		return resp, grpcError(err)
 	}
 	return resp, nil
 }
 
 // GRPCClient is an implementation of KV that talks over RPC.
-type GRPCRuntimeHelperClient struct{ client proto.RuntimeHelperClient }
+type GRPCRuntimeHelperClient struct {
+	client proto.RuntimeHelperClient
+	// queue holds Emit* calls while the robot connection is down.
+	queue *emitQueue
+}
 
 func (m *GRPCRuntimeHelperClient) SetupEmit(guid, sessionID string, event []byte) error {
 	_, err := m.client.SetupEmit(context.Background(), &proto.SetupEmitRequest{
@@ -286,65 +471,69 @@ func (m *GRPCRuntimeHelperClient) Debug(guid, name string, message interface{})
 }
 
 func (m *GRPCRuntimeHelperClient) EmitFlowEvent(guid, name string) error {
+	return m.queue.do(func() error {
+		_, err := m.client.EmitFlowEvent(context.Background(), &proto.EmitFlowEventRequest{
+			Guid: guid,
+			Name: name,
+		})
 
-	_, err := m.client.EmitFlowEvent(context.Background(), &proto.EmitFlowEventRequest{
-		Guid: guid,
-		Name: name,
-	})
-
-	if err != nil {
-		hclog.Default().Info("runtime.flow", "err", err)
-		return err
-	}
+		if err != nil {
+			hclog.Default().Info("runtime.flow", "err", err)
+			return err
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (m *GRPCRuntimeHelperClient) EmitInput(guid string, input []byte) error {
+	return m.queue.do(func() error {
+		_, err := m.client.EmitInput(context.Background(), &proto.EmitInputRequest{
+			Guid:  guid,
+			Input: input,
+		})
 
-	_, err := m.client.EmitInput(context.Background(), &proto.EmitInputRequest{
-		Guid:  guid,
-		Input: input,
-	})
-
-	if err != nil {
-		hclog.Default().Info("runtime.input", "err", err)
-		return err
-	}
+		if err != nil {
+			hclog.Default().Info("runtime.input", "err", err)
+			return err
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (m *GRPCRuntimeHelperClient) EmitOutput(guid string, output []byte, port int32) error {
+	return m.queue.do(func() error {
+		_, err := m.client.EmitOutput(context.Background(), &proto.EmitOutputRequest{
+			Guid:   guid,
+			Output: output,
+			Port:   port,
+		})
 
-	_, err := m.client.EmitOutput(context.Background(), &proto.EmitOutputRequest{
-		Guid:   guid,
-		Output: output,
-		Port:   port,
-	})
-
-	if err != nil {
-		hclog.Default().Info("runtime.output", "err", err)
-		return err
-	}
+		if err != nil {
+			hclog.Default().Info("runtime.output", "err", err)
+			return err
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (m *GRPCRuntimeHelperClient) EmitError(guid, name, message string) error {
+	return m.queue.do(func() error {
+		_, err := m.client.EmitError(context.Background(), &proto.EmitErrorRequest{
+			Guid:    guid,
+			Name:    name,
+			Message: message,
+		})
 
-	_, err := m.client.EmitError(context.Background(), &proto.EmitErrorRequest{
-		Guid:    guid,
-		Name:    name,
-		Message: message,
-	})
-
-	if err != nil {
-		hclog.Default().Info("runtime.error", "err", err)
-		return err
-	}
+		if err != nil {
+			hclog.Default().Info("runtime.error", "err", err)
+			return err
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (m *GRPCRuntimeHelperClient) GetVaultItem(vaultID, itemID string) (map[string]interface{}, error) {
@@ -512,22 +701,6 @@ func (m *GRPCRuntimeHelperClient) GatewayRequest(method, endpoint, body string,
 	return resp, nil
 }
 
-func checkConnState() {
-
-	for {
-		state := conn.GetState()
-
-		switch state {
-		case connectivity.Connecting, connectivity.Idle, connectivity.Ready:
-			break
-		default:
-			done <- true
-		}
-
-		time.Sleep(1 * time.Second)
-	}
-}
-
 func (m *GRPCRuntimeHelperClient) GetRobotInfo() (map[string]interface{}, error) {
 	resp, err := m.client.GetRobotInfo(context.Background(), &proto.Empty{})
 
@@ -585,7 +758,8 @@ func (m *GRPCRuntimeHelperClient) GetPortConnections(guid string, port int) ([]N
 	for i, node := range resp.Nodes {
 		var config map[string]interface{}
 		json.Unmarshal(node.Config, &config)
-		decoded, err := base64.StdEncoding.DecodeString(config["config"].(string))
+		encoded, _ := config["config"].(string)
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
 		if err != nil {
 			hclog.Default().Info("runtime.GetPortConnections", "err", err)
 			continue
@@ -0,0 +1,139 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/robomotionio/robomotion-go/message"
+	"github.com/robomotionio/robomotion-go/proto"
+)
+
+func TestOnMessage_CancelledByRPCContext(t *testing.T) {
+	t.Parallel()
+	n, started, seen := fxBlocking()
+	addTestHandler(t, "cancel-rpc", Node{}, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	s := &GRPCServer{}
+	_, err := s.OnMessage(ctx, onMessageRequest(t, "cancel-rpc", `{}`))
+	if st, _ := status.FromError(err); st.Code() != codes.Canceled {
+		t.Fatalf("OnMessage err = %v, want codes.Canceled", err)
+	}
+	if err := <-seen; !errors.Is(err, context.Canceled) {
+		t.Fatalf("node saw %v, want context.Canceled", err)
+	}
+}
+
+func TestOnMessage_CancelledByNodeClose(t *testing.T) {
+	t.Parallel()
+	n, started, _ := fxBlocking()
+	addTestHandler(t, "cancel-close", Node{}, n)
+
+	go func() {
+		<-started
+		RemoveNodeHandler("cancel-close")
+	}()
+
+	s := &GRPCServer{}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "cancel-close", `{}`))
+	if st, _ := status.FromError(err); st.Code() != codes.Canceled {
+		t.Fatalf("OnMessage err = %v, want codes.Canceled", err)
+	}
+}
+
+func TestOnMessage_DelayBeforeHonoursDeadline(t *testing.T) {
+	t.Parallel()
+	n, _, _ := fxBlocking()
+	addTestHandler(t, "delay", Node{DelayBefore: 60}, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	s := &GRPCServer{}
+	_, err := s.OnMessage(ctx, onMessageRequest(t, "delay", `{}`))
+	if st, _ := status.FromError(err); st.Code() != codes.DeadlineExceeded {
+		t.Fatalf("OnMessage err = %v, want codes.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("DelayBefore ignored the deadline (took %s)", elapsed)
+	}
+	if n.calls.Load() != 0 {
+		t.Fatal("OnMessage ran although the deadline expired during DelayBefore")
+	}
+}
+
+func TestSleep(t *testing.T) {
+	t.Parallel()
+	if err := Sleep(context.Background(), time.Millisecond); err != nil {
+		t.Fatalf("Sleep = %v, want nil", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Sleep on cancelled ctx = %v, want context.Canceled", err)
+	}
+}
+
+func TestOnMessage_PanicBecomesInternalStatus(t *testing.T) {
+	t.Parallel()
+	addTestHandler(t, "panic", Node{}, fxFunc(func(message.Context) error { panic("boom") }))
+
+	s := &GRPCServer{}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "panic", `{}`))
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Internal {
+		t.Fatalf("OnMessage err = %v, want codes.Internal status", err)
+	}
+
+	var rerr Error
+	if jerr := json.Unmarshal([]byte(st.Message()), &rerr); jerr != nil {
+		t.Fatalf("status message is not a runtime.Error: %q", st.Message())
+	}
+	if rerr.Code != ErrPanic || !strings.Contains(rerr.Message, "boom") || rerr.Stack == "" {
+		t.Fatalf("unexpected panic error: %+v", rerr)
+	}
+}
+
+func TestOnCreate_MissingHandlerIsAnError(t *testing.T) {
+	initReady = make(chan struct{})
+	close(initReady)
+	RegisterNodeFactory("Test.NoNode", &fxNoHandlerFactory{})
+
+	s := &GRPCServer{}
+	if _, err := s.OnCreate(context.Background(), &proto.OnCreateRequest{
+		Name:   "Test.NoNode",
+		Config: []byte(`{"guid":"no-handler"}`),
+	}); err == nil {
+		t.Fatal("OnCreate succeeded without a registered handler")
+	}
+}
+
+// fxNoHandlerFactory "creates" a node without registering a handler.
+type fxNoHandlerFactory struct{}
+
+func (f *fxNoHandlerFactory) OnCreate(context.Context, []byte) error { return nil }
+
+func TestInVariable_NonStringNameIsAnError(t *testing.T) {
+	t.Parallel()
+	v := InVariable[string]{Variable: Variable[string]{Scope: "Message", Name: 42.0}}
+	if _, err := v.Get(newCtx(`{}`)); err == nil {
+		t.Fatal("Get with a numeric Message-scope name succeeded")
+	}
+	o := OutVariable[string]{Variable: Variable[string]{Scope: "Message", Name: 42.0}}
+	if err := o.Set(newCtx(`{}`), "x"); err == nil {
+		t.Fatal("Set with a numeric Message-scope name succeeded")
+	}
+}
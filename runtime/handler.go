@@ -1,7 +1,11 @@
 package runtime
 
 import (
+	"context"
+	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/robomotionio/robomotion-go/message"
 )
@@ -15,6 +19,37 @@ var (
 type NodeHandler struct {
 	Node
 	Handler MessageHandler
+
+	// ctx lives as long as the handler is registered. It is cancelled by
+	// RemoveNodeHandler so in-flight OnMessage calls of a closing node see
+	// the stop even when the robot keeps their RPC open.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// retry is the node's RetryPolicy with the instance's overrides applied.
+	retry RetryPolicy
+	// timeout bounds each OnMessage attempt; zero means no bound.
+	timeout time.Duration
+	// nodeType is the spec id of the node, the label of its metrics.
+	nodeType string
+	// outputs is the number of output ports the node declares.
+	outputs int
+	// inputSchema is checked against each message before OnMessage; nil
+	// when the node declares none.
+	inputSchema *inputSchema
+
+	// mu is held for reading by each OnMessage call and for writing while
+	// OnUpdate changes the node's configuration.
+	mu sync.RWMutex
+}
+
+// Context returns the handler's lifetime context. It is cancelled when the
+// node is closed.
+func (n *NodeHandler) Context() context.Context {
+	if n.ctx == nil {
+		return context.Background()
+	}
+	return n.ctx
 }
 
 type MessageHandler interface {
@@ -23,16 +58,37 @@ type MessageHandler interface {
 	OnClose() error
 }
 
+// stop cancels the handler's lifetime context.
+func (n *NodeHandler) stop() {
+	if n.cancel != nil {
+		n.cancel()
+	}
+}
+
 func AddNodeHandler(node Node, handler MessageHandler) {
 	hMux.Lock()
 	defer hMux.Unlock()
 	
-	// Automatically wrap handler with tool interceptor if needed
-	wrappedHandler := NewToolInterceptor(handler)
-	
+	// Automatically wrap handler with tool interceptor if needed, then with
+	// the middleware registered through Use
+	wrappedHandler := applyMiddleware(NewToolInterceptor(handler))
+
+	if prev, ok := handlers[node.GUID]; ok {
+		prev.stop()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	handlers[node.GUID] = &NodeHandler{
 		Handler: wrappedHandler,
 		Node:    node,
+		ctx:     ctx,
+		cancel:  cancel,
+		retry:    retryPolicyOf(handler).withOverrides(node),
+		timeout:  timeoutOf(handler, node),
+		nodeType: nodeTypeOf(handler),
+		outputs:  outputsOf(handler),
+
+		inputSchema: inputSchemaOf(handler),
 	}
 }
 
@@ -43,9 +99,62 @@ func GetNodeHandler(guid string) *NodeHandler {
 	return h
 }
 
+// lockNodeHandler returns the handler registered under guid with its mu
+// held, for reading or, with write, for writing, and the function that
+// releases it, which may be called more than once; nil when none is
+// registered. A call that waited out an update which created the node again
+// gets the new handler, not the closed one. It gives up with ctx's error
+// when ctx ends before the lock is taken.
+func lockNodeHandler(ctx context.Context, guid string, write bool) (*NodeHandler, func(), error) {
+	for {
+		node := GetNodeHandler(guid)
+		if node == nil {
+			return nil, nil, nil
+		}
+		lock, unlock := node.mu.RLock, node.mu.RUnlock
+		if write {
+			lock, unlock = node.mu.Lock, node.mu.Unlock
+		}
+		if err := lockContext(ctx, lock, unlock); err != nil {
+			return nil, nil, fmt.Errorf("waiting for node %s: %w", guid, err)
+		}
+		if GetNodeHandler(guid) == node {
+			return node, sync.OnceFunc(unlock), nil
+		}
+		unlock()
+	}
+}
+
+// lockContext calls lock and returns once it does, or with ctx's error once
+// ctx ends; the lock is then released with unlock as soon as it is taken.
+func lockContext(ctx context.Context, lock, unlock func()) error {
+	if ctx.Done() == nil {
+		lock()
+		return nil
+	}
+	locked := make(chan struct{})
+	go func() {
+		lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-locked
+			unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
 func RemoveNodeHandler(guid string) {
 	hMux.Lock()
 	defer hMux.Unlock()
+	if h, ok := handlers[guid]; ok {
+		h.stop()
+	}
 	delete(handlers, guid)
 }
 
@@ -57,6 +166,26 @@ func RegisteredNodes() []MessageHandler {
 	return handlerList
 }
 
+// nodeTypeOf returns the spec id of handler's node, e.g.
+// "Robomotion.Example.Hello", or its Go type name when it declares none.
+func nodeTypeOf(handler MessageHandler) string {
+	t := reflect.TypeOf(handler)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		if field, ok := t.FieldByName("Node"); ok {
+			if id := parseSpec(field.Tag.Get("spec"))["id"]; id != "" {
+				return id
+			}
+		}
+	}
+	return t.String()
+}
+
 // listNodeHandlerGUIDs returns the GUIDs of all active node handlers.
 func listNodeHandlerGUIDs() []string {
 	hMux.Lock()
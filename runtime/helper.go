@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// The RuntimeHelper is the node's line back to the robot (variables, vault,
+// events). Each GRPCServer, CLI run and test gets its own and hands it to
+// the nodes it creates: through their embedded Node (Node.Helper) and
+// through the context of every message they receive (HelperFrom). The
+// package-level helper set by Init, SetTestClient and CLI mode is only the
+// fallback for code that runs outside a node call, and for the package-level
+// functions such as EmitDebug.
+
+type helperKey struct{}
+
+// WithRuntimeHelper returns a copy of ctx carrying h. Pass the result to
+// message.NewContextWith to run a node against its own helper, e.g. in a
+// test that must not touch the package-level one.
+func WithRuntimeHelper(ctx context.Context, h RuntimeHelper) context.Context {
+	return context.WithValue(ctx, helperKey{}, h)
+}
+
+// HelperFrom returns the RuntimeHelper carried by ctx, or the package-level
+// one when ctx carries none. It returns nil when neither is set.
+func HelperFrom(ctx context.Context) RuntimeHelper {
+	if ctx != nil {
+		if h, ok := ctx.Value(helperKey{}).(RuntimeHelper); ok && h != nil {
+			return h
+		}
+	}
+	return client
+}
+
+// helperIn returns the RuntimeHelper ctx carries, without the fallback.
+func helperIn(ctx context.Context) RuntimeHelper {
+	if ctx == nil {
+		return nil
+	}
+	h, _ := ctx.Value(helperKey{}).(RuntimeHelper)
+	return h
+}
+
+// helperOf returns the helper for a call handling msg.
+func helperOf(msg message.Context) RuntimeHelper {
+	if msg == nil {
+		return client
+	}
+	return HelperFrom(msg.Context())
+}
+
+// errNotInitialized is returned when no helper is reachable.
+var errNotInitialized = fmt.Errorf("Runtime was not initialized")
+
+// Helper returns the RuntimeHelper of the runtime that created this node,
+// or the package-level one for nodes created by hand.
+func (n *Node) Helper() RuntimeHelper {
+	if n.helper != nil {
+		return n.helper
+	}
+	return client
+}
+
+// SetHelper sets the RuntimeHelper returned by Helper.
+func (n *Node) SetHelper(h RuntimeHelper) {
+	n.helper = h
+}
+
+// setNodeHelper stores h in the Node embedded in handler, if any.
+func setNodeHelper(handler MessageHandler, h RuntimeHelper) {
+	if h == nil {
+		return
+	}
+	if n := embeddedNode(handler); n != nil {
+		n.helper = h
+	}
+}
@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+func TestHelper_RuntimesCoexist(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("robot-%d", i)
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			// Each simulated robot's Flow-scope variables all hold its name.
+			robot := &fxHelper{variable: name}
+			inRobot := InVariable[string]{Variable: Variable[string]{Scope: "Flow", Name: "robot"}}
+			addTestHandler(t, "helper-"+name, Node{}, fxFunc(func(ctx message.Context) error {
+				robot, err := inRobot.Get(ctx)
+				if err != nil {
+					return err
+				}
+				return ctx.Set("robot", robot)
+			}))
+
+			s := &GRPCServer{helper: robot}
+			resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "helper-"+name, `{}`))
+			if err != nil {
+				t.Fatalf("OnMessage: %v", err)
+			}
+			if got := message.NewContext(resp.OutMessage).GetString("robot"); got != name {
+				t.Fatalf("node read %q through its helper, want %q", got, name)
+			}
+		})
+	}
+}
+
+func TestHelper_FactoryHandsHelperToNode(t *testing.T) {
+	t.Parallel()
+	robot := &fxHelper{variable: "factory"}
+	f := &NodeFactory{Type: reflect.TypeOf(fxNode{})}
+
+	err := f.OnCreate(WithRuntimeHelper(context.Background(), robot), []byte(`{"guid":"helper-factory"}`))
+	if err != nil {
+		t.Fatalf("factory OnCreate: %v", err)
+	}
+	t.Cleanup(func() { RemoveNodeHandler("helper-factory") })
+
+	node := GetNodeHandler("helper-factory")
+	if node.Helper() != robot {
+		t.Fatal("NodeHandler did not keep the factory's helper")
+	}
+	if n := NodeOf(node.Handler); n.Helper() != robot {
+		t.Fatal("the node's embedded Node did not get the factory's helper")
+	}
+}
+
+func TestHelperFrom_FallsBackToPackageLevel(t *testing.T) {
+	prev := client
+	t.Cleanup(func() { client = prev })
+	global := &fxHelper{variable: "global"}
+	client = global
+
+	if HelperFrom(context.Background()) != global {
+		t.Fatal("HelperFrom without a helper did not fall back")
+	}
+	own := NewTestHelper(nil)
+	if HelperFrom(WithRuntimeHelper(context.Background(), own)) != own {
+		t.Fatal("HelperFrom ignored the context's helper")
+	}
+}
+
+func TestEmitWith_UsesTheMessagesHelper(t *testing.T) {
+	prev := client
+	t.Cleanup(func() { client = prev })
+	global, own := &fxHelper{}, &fxHelper{}
+	client = global
+
+	ctx := message.NewContextWith(WithRuntimeHelper(context.Background(), own), []byte(`{}`))
+	if err := EmitDebugWith(ctx, "emit-with", "Node", "hello"); err != nil {
+		t.Fatalf("EmitDebugWith: %v", err)
+	}
+	if err := EmitOutputWith(ctx, "emit-with", []byte(`{}`), 1); err != nil {
+		t.Fatalf("EmitOutputWith: %v", err)
+	}
+	if len(own.debugCalls()) != 1 || len(own.outputCalls()) != 1 {
+		t.Fatalf("the message's helper got %v %v", own.debugCalls(), own.outputCalls())
+	}
+	if len(global.debugCalls()) != 0 || len(global.outputCalls()) != 0 {
+		t.Fatal("EmitDebugWith went through the package-level helper")
+	}
+}
@@ -0,0 +1,185 @@
+package runtime
+
+import (
+	"os"
+	goruntime "runtime"
+	rtdebug "runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	plugin "github.com/robomotionio/go-plugin"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// IntrospectReport is what Node.Introspect returns, as JSON: a snapshot of
+// what the package process is doing, for diagnosing a stalled flow.
+type IntrospectReport struct {
+	SDKVersion   string           `json:"sdk_version"`
+	GoVersion    string           `json:"go_version"`
+	PID          int              `json:"pid"`
+	UptimeSec    float64          `json:"uptime_sec"`
+	Mode         string           `json:"mode"` // "robot" or "session"
+	Draining     bool             `json:"draining"`
+	Handlers     []HandlerInfo    `json:"handlers"`
+	InFlight     []InFlightCall   `json:"in_flight"`
+	Goroutines   int              `json:"goroutines"`
+	Memory       MemoryInfo       `json:"memory"`
+	Capabilities CapabilitiesInfo `json:"capabilities"`
+	LMOStorePath string           `json:"lmo_store_path,omitempty"`
+}
+
+// HandlerInfo describes a registered node instance.
+type HandlerInfo struct {
+	GUID string `json:"guid"`
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// InFlightCall is an OnMessage call that has not returned yet.
+type InFlightCall struct {
+	GUID        string    `json:"guid"`
+	Type        string    `json:"type"`
+	Started     time.Time `json:"started"`
+	DurationSec float64   `json:"duration_sec"`
+}
+
+// MemoryInfo is a subset of runtime.MemStats, in bytes.
+type MemoryInfo struct {
+	Alloc      uint64 `json:"alloc"`
+	TotalAlloc uint64 `json:"total_alloc"`
+	Sys        uint64 `json:"sys"`
+	HeapInuse  uint64 `json:"heap_inuse"`
+	NumGC      uint32 `json:"num_gc"`
+}
+
+// CapabilitiesInfo lists the capability bits and named features on each
+// side.
+type CapabilitiesInfo struct {
+	Package    uint64 `json:"package"`
+	Robot      uint64 `json:"robot"`
+	Negotiated uint64 `json:"negotiated"`
+
+	PackageFeatures    map[string]int `json:"package_features"`
+	RobotFeatures      map[string]int `json:"robot_features"`
+	NegotiatedFeatures map[string]int `json:"negotiated_features"`
+}
+
+var (
+	processStart = time.Now()
+
+	inflightMu sync.Mutex
+	inflight   = make(map[*InFlightCall]struct{})
+)
+
+// trackCall lists an OnMessage call as in flight until the returned
+// function is called.
+func trackCall(guid, nodeType string) (done func()) {
+	call := &InFlightCall{GUID: guid, Type: nodeType, Started: time.Now()}
+	inflightMu.Lock()
+	inflight[call] = struct{}{}
+	inflightMu.Unlock()
+	return func() {
+		inflightMu.Lock()
+		delete(inflight, call)
+		inflightMu.Unlock()
+	}
+}
+
+// SDKVersion returns the version of this module the package was built with,
+// or "(devel)" when it is built from a checkout.
+func SDKVersion() string {
+	info, ok := rtdebug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	const path = "github.com/robomotionio/robomotion-go"
+	if info.Main.Path == path {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil {
+				if dep.Replace.Version == "" {
+					return "(devel)" // replaced by a local checkout
+				}
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// introspect builds the report.
+func introspect() *IntrospectReport {
+	now := time.Now()
+	r := &IntrospectReport{
+		SDKVersion: SDKVersion(),
+		GoVersion:  goruntime.Version(),
+		PID:        os.Getpid(),
+		UptimeSec:  now.Sub(processStart).Seconds(),
+		Mode:       "robot",
+		Draining:   isDraining(),
+		Goroutines: goruntime.NumGoroutine(),
+		Capabilities: CapabilitiesInfo{
+			Package:    GetPackageCapabilities(),
+			Robot:      GetRobotCapabilities(),
+			Negotiated: GetCapabilities(),
+
+			PackageFeatures:    PackageFeatures(),
+			RobotFeatures:      RobotFeatures(),
+			NegotiatedFeatures: NegotiatedFeatures(),
+		},
+	}
+	if sessionMode {
+		r.Mode = "session"
+	}
+	if store := currentLMOStore(); store != nil {
+		r.LMOStorePath = store.RelPath()
+	}
+
+	hMux.Lock()
+	for guid, h := range handlers {
+		r.Handlers = append(r.Handlers, HandlerInfo{GUID: guid, Type: h.nodeType, Name: h.Name})
+	}
+	hMux.Unlock()
+	sort.Slice(r.Handlers, func(i, j int) bool { return r.Handlers[i].GUID < r.Handlers[j].GUID })
+
+	inflightMu.Lock()
+	for call := range inflight {
+		c := *call
+		c.DurationSec = now.Sub(c.Started).Seconds()
+		r.InFlight = append(r.InFlight, c)
+	}
+	inflightMu.Unlock()
+	sort.Slice(r.InFlight, func(i, j int) bool { return r.InFlight[i].Started.Before(r.InFlight[j].Started) })
+
+	var ms goruntime.MemStats
+	goruntime.ReadMemStats(&ms)
+	r.Memory = MemoryInfo{
+		Alloc:      ms.Alloc,
+		TotalAlloc: ms.TotalAlloc,
+		Sys:        ms.Sys,
+		HeapInuse:  ms.HeapInuse,
+		NumGC:      ms.NumGC,
+	}
+	return r
+}
+
+// healthInterceptor answers gRPC health checks with NOT_SERVING once the
+// package is draining, whichever health server is registered.
+func healthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if info.FullMethod == grpc_health_v1.Health_Check_FullMethodName && isDraining() {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return handler(ctx, req)
+}
+
+// grpcServerWithHealth is plugin.DefaultGRPCServer plus healthInterceptor;
+// go-plugin registers the health service itself.
+func grpcServerWithHealth(opts []grpc.ServerOption) *grpc.Server {
+	return plugin.DefaultGRPCServer(append(opts, grpc.ChainUnaryInterceptor(healthInterceptor)))
+}
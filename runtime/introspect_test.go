@@ -0,0 +1,108 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/robomotionio/robomotion-go/proto"
+)
+
+func TestIntrospect_ReportsHandlersAndInFlightCalls(t *testing.T) {
+	t.Parallel()
+	n, started, _ := fxBlocking()
+	addTestHandler(t, "introspect-busy", Node{Name: "Busy"}, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &GRPCServer{}
+	returned := make(chan struct{})
+	go func() {
+		s.OnMessage(ctx, onMessageRequest(t, "introspect-busy", `{}`))
+		close(returned)
+	}()
+	<-started
+
+	resp, err := s.Introspect(context.Background(), &proto.Empty{})
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	var r IntrospectReport
+	if err := json.Unmarshal(resp.Report, &r); err != nil {
+		t.Fatalf("report is not an IntrospectReport: %v", err)
+	}
+
+	var listed, busy bool
+	for _, h := range r.Handlers {
+		listed = listed || (h.GUID == "introspect-busy" && h.Name == "Busy" && h.Type == "runtime.fxNode")
+	}
+	for _, c := range r.InFlight {
+		busy = busy || (c.GUID == "introspect-busy" && c.DurationSec >= 0)
+	}
+	if !listed || !busy {
+		t.Fatalf("report misses the busy node (listed=%v, in flight=%v): %s", listed, busy, resp.Report)
+	}
+	if r.Goroutines == 0 || r.Memory.Sys == 0 || r.SDKVersion == "" || r.PID == 0 {
+		t.Fatalf("report lacks process data: %s", resp.Report)
+	}
+
+	cancel()
+	<-returned
+	for _, c := range introspect().InFlight {
+		if c.GUID == "introspect-busy" {
+			t.Fatal("finished call is still listed in flight")
+		}
+	}
+}
+
+func TestHealthInterceptor_NotServingWhileDraining(t *testing.T) {
+	resetDrain(t)
+	info := &grpc.UnaryServerInfo{FullMethod: grpc_health_v1.Health_Check_FullMethodName}
+	serving := func(context.Context, interface{}) (interface{}, error) {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+	}
+	check := func() grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, _ := healthInterceptor(context.Background(), &grpc_health_v1.HealthCheckRequest{}, info, serving)
+		return resp.(*grpc_health_v1.HealthCheckResponse).Status
+	}
+
+	if got := check(); got != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %v before draining, want SERVING", got)
+	}
+	startDrain()
+	if got := check(); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("status = %v while draining, want NOT_SERVING", got)
+	}
+}
+
+func TestSessionTimeoutInterceptor_ProbesAreNotActivity(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	intercept := sessionTimeoutInterceptor(timer, time.Hour, &mu)
+	call := func(method string) {
+		noop := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+		intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, noop)
+	}
+
+	for _, method := range []string{
+		grpc_health_v1.Health_Check_FullMethodName,
+		proto.Node_Introspect_FullMethodName,
+		proto.Node_GetMetrics_FullMethodName,
+	} {
+		call(method)
+		if timer.Stop() {
+			t.Fatalf("%s reset the inactivity timer", method)
+		}
+	}
+	call(proto.Node_OnMessage_FullMethodName)
+	if !timer.Stop() {
+		t.Fatal("OnMessage did not reset the inactivity timer")
+	}
+}
@@ -0,0 +1,29 @@
+package lmo
+
+import "sync/atomic"
+
+// Stats are process-wide counters of blob store activity, kept across
+// store instances so they only ever grow.
+type Stats struct {
+	BlobsWritten  int64 // blobs handed to PutBlob, deduplicated ones included
+	BlobsResolved int64 // blobs read back by GetBlob
+	BytesSaved    int64 // message bytes replaced by BlobRefs
+}
+
+var stats Stats
+
+// ReadStats returns a snapshot of the counters.
+func ReadStats() Stats {
+	return Stats{
+		BlobsWritten:  atomic.LoadInt64(&stats.BlobsWritten),
+		BlobsResolved: atomic.LoadInt64(&stats.BlobsResolved),
+		BytesSaved:    atomic.LoadInt64(&stats.BytesSaved),
+	}
+}
+
+// countExtracted records a field of size raw replaced by a ref of size ref.
+func countExtracted(raw, ref int) {
+	if raw > ref {
+		atomic.AddInt64(&stats.BytesSaved, int64(raw-ref))
+	}
+}
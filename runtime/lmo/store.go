@@ -1,15 +1,19 @@
 package lmo
 
 import (
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/klauspost/compress/zstd"
+	"github.com/robomotionio/robomotion-go/message"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"github.com/zeebo/xxh3"
@@ -18,6 +22,9 @@ import (
 const (
 	Magic     = 20260301
 	Threshold = 4096 // 4KB
+
+	// TypeBytes is the __type of a BlobRef to raw bytes rather than JSON.
+	TypeBytes = "bytes"
 )
 
 // BlobRef replaces a large field value in the message.
@@ -28,6 +35,7 @@ type BlobRef struct {
 	Path  string `json:"__path"`
 	Type  string `json:"__type"`
 	Len   int    `json:"__len,omitempty"`
+	Mime  string `json:"__mime,omitempty"` // of TypeBytes blobs
 }
 
 // Store manages content-addressed, zstd-compressed blobs on disk.
@@ -87,11 +95,10 @@ func (s *Store) Close() {
 
 // GetBlob reads and decompresses a blob given its ref and relPath.
 func (s *Store) GetBlob(ref, relPath string) ([]byte, error) {
-	h := strings.TrimPrefix(ref, "xxh3:")
-	if len(h) < 3 {
-		return nil, fmt.Errorf("lmo: invalid ref %q: hex too short", ref)
+	p, err := s.blobPath(ref, relPath)
+	if err != nil {
+		return nil, err
 	}
-	p := filepath.Join(s.configDir, "store", relPath, "blobs", h[:2], h[2:])
 
 	compressed, err := readFile(p)
 	if err != nil {
@@ -103,16 +110,56 @@ func (s *Store) GetBlob(ref, relPath string) ([]byte, error) {
 		return nil, fmt.Errorf("lmo: decompress blob %s: %w", ref, err)
 	}
 
+	atomic.AddInt64(&stats.BlobsResolved, 1)
 	return data, nil
 }
 
-// Resolve lazily resolves a BlobRef for a specific field path.
+// OpenBlob streams a blob given its ref and relPath, decompressing as it
+// is read, for blobs too large to hold in memory at once.
+func (s *Store) OpenBlob(ref, relPath string) (io.ReadCloser, error) {
+	p, err := s.blobPath(ref, relPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := openFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("lmo: open blob %s: %w", ref, err)
+	}
+	dec, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lmo: decompress blob %s: %w", ref, err)
+	}
+
+	atomic.AddInt64(&stats.BlobsResolved, 1)
+	return &blobReader{dec: dec, f: f}, nil
+}
+
+// blobReader reads a blob file through its own zstd decoder.
+type blobReader struct {
+	dec *zstd.Decoder
+	f   *os.File
+}
+
+func (r *blobReader) Read(p []byte) (int, error) { return r.dec.Read(p) }
+
+func (r *blobReader) Close() error {
+	r.dec.Close()
+	return r.f.Close()
+}
+
+// Resolve lazily resolves a BlobRef for a specific field path, in the
+// grammar of message paths (see message.ConvertPath).
 //
 // Each `resolveRef` call is wrapped in `fullyResolveRef`, which loops while
 // the result is itself a BlobRef envelope. This handles double-nested
 // envelopes — same pathology as ResolveAll's recursive fix, applied to the
 // singular path-based variant.
 func (s *Store) Resolve(data []byte, key string) (gjson.Result, error) {
+	key, err := message.ConvertPath(data, key)
+	if err != nil {
+		return gjson.Result{}, nil // no such path
+	}
 	value := gjson.GetBytes(data, key)
 	if value.Exists() {
 		if IsBlobRef(value) {
@@ -231,6 +278,7 @@ func (s *Store) ResolveAll(data []byte) ([]byte, error) {
 // an unrelated tool) is rewritten rather than honored.
 func (s *Store) PutBlob(data []byte) (string, error) {
 	ref := hashRef(data)
+	atomic.AddInt64(&stats.BlobsWritten, 1)
 
 	p := s.blobPathLocal(ref)
 	if info, err := os.Stat(p); err == nil && info.Size() > 0 {
@@ -325,6 +373,28 @@ func (s *Store) Pack(payload []byte) ([]byte, error) {
 	return out, nil
 }
 
+// PutBytes stores binary data as a blob and returns a TypeBytes BlobRef
+// marker for it. Resolve and ResolveAll turn the marker into the base64
+// JSON string of the data; OpenBlob streams the data itself. Requires
+// relPath to be set via SetRelPath.
+func (s *Store) PutBytes(data []byte, mime string) ([]byte, error) {
+	if s.relPath == "" {
+		return nil, fmt.Errorf("lmo: store path not set")
+	}
+	ref, err := s.PutBlob(data)
+	if err != nil {
+		return nil, err
+	}
+	return marshalBlobRef(BlobRef{
+		Ref:   ref,
+		Magic: Magic,
+		Size:  len(data),
+		Path:  s.relPath,
+		Type:  TypeBytes,
+		Mime:  mime,
+	}), nil
+}
+
 // --- Detection ---
 
 // IsBlobRef checks if a gjson.Result is a BlobRef marker.
@@ -376,6 +446,15 @@ func hashRef(data []byte) string {
 	return "xxh3:" + hex.EncodeToString(buf[:])
 }
 
+// blobPath returns the path of a blob under relPath.
+func (s *Store) blobPath(ref, relPath string) (string, error) {
+	h := strings.TrimPrefix(ref, "xxh3:")
+	if len(h) < 3 {
+		return "", fmt.Errorf("lmo: invalid ref %q: hex too short", ref)
+	}
+	return filepath.Join(s.configDir, "store", relPath, "blobs", h[:2], h[2:]), nil
+}
+
 // blobPathLocal returns the blob path under the store's own relPath.
 func (s *Store) blobPathLocal(ref string) string {
 	h := strings.TrimPrefix(ref, "xxh3:")
@@ -389,6 +468,11 @@ func (s *Store) resolveRef(value gjson.Result) (gjson.Result, error) {
 	if err != nil {
 		return gjson.Result{}, err
 	}
+	if gjson.Get(value.Raw, "__type").String() == TypeBytes {
+		// Binary content reads as a base64 string, the way encoding/json
+		// marshals a []byte.
+		return gjson.Parse(`"` + base64.StdEncoding.EncodeToString(data) + `"`), nil
+	}
 	return gjson.ParseBytes(data), nil
 }
 
@@ -514,6 +598,7 @@ func (s *Store) extractField(value gjson.Result) ([]byte, bool, error) {
 
 		blobRef := s.buildBlobRef(ref, raw, value)
 		refJSON := marshalBlobRef(blobRef)
+		countExtracted(len(raw), len(refJSON))
 		return refJSON, true, nil
 	}
 
@@ -561,6 +646,7 @@ func (s *Store) extractObject(value gjson.Result) ([]byte, bool, error) {
 		}
 		blobRef := s.buildBlobRef(ref, raw, value)
 		refJSON := marshalBlobRef(blobRef)
+		countExtracted(len(raw), len(refJSON))
 		return refJSON, true, nil
 	}
 
@@ -603,6 +689,9 @@ func marshalBlobRef(br BlobRef) []byte {
 	if br.Len > 0 {
 		out, _ = sjson.SetBytes(out, "__len", br.Len)
 	}
+	if br.Mime != "" {
+		out, _ = sjson.SetBytes(out, "__mime", br.Mime)
+	}
 	return out
 }
 
@@ -610,6 +699,27 @@ func escape(key string) string {
 	return strings.ReplaceAll(key, ".", `\.`)
 }
 
+// splitPath splits a converted path at the dots between its segments,
+// leaving escaped dots and the dots of gjson filters in place.
 func splitPath(path string) []string {
-	return strings.Split(path, ".")
+	var parts []string
+	start, depth := 0, 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case '.':
+			if depth == 0 {
+				parts = append(parts, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, path[start:])
 }
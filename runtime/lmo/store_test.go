@@ -1,7 +1,9 @@
 package lmo
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
 	"strconv"
 	"os"
 	"path/filepath"
@@ -253,6 +255,39 @@ func TestLazyResolve(t *testing.T) {
 	}
 }
 
+func TestResolvePathGrammar(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	items := make([]string, 600)
+	for i := range items {
+		items[i] = "item-" + strconv.Itoa(i)
+	}
+	raw, _ := json.Marshal(map[string]interface{}{"a.b": items})
+	packed, err := s.Pack(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsBlobRef(gjson.GetBytes(packed, `a\.b`)) {
+		t.Fatalf("expected a.b packed, got %.200s", packed)
+	}
+
+	// Paths into the blob, with the key escaped or quoted and the index
+	// counted from either end.
+	for path, want := range map[string]string{
+		`a\.b.0`:     "item-0",
+		`["a.b"][1]`:  "item-1",
+		`["a.b"][-1]`: "item-599",
+	} {
+		got, err := s.Resolve(packed, path)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", path, err)
+		}
+		if got.String() != want {
+			t.Errorf("Resolve(%q) = %q, want %q", path, got.String(), want)
+		}
+	}
+}
+
 func TestBlobRefPassthrough(t *testing.T) {
 	s, _ := newTestStore(t)
 
@@ -275,6 +310,49 @@ func TestBlobRefPassthrough(t *testing.T) {
 	}
 }
 
+func TestPutBytesRoundtrip(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	data := make([]byte, 3*Threshold)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	ref, err := s.PutBytes(data, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	marker := gjson.ParseBytes(ref)
+	if !IsBlobRef(marker) || marker.Get("__type").String() != TypeBytes ||
+		marker.Get("__mime").String() != "image/png" || marker.Get("__size").Int() != int64(len(data)) {
+		t.Fatalf("unexpected marker: %s", ref)
+	}
+
+	r, err := s.OpenBlob(marker.Get("__ref").String(), marker.Get("__path").String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("OpenBlob read %d bytes, err %v", len(got), err)
+	}
+
+	// Resolved, the blob is the base64 string encoding/json gives a []byte.
+	payload := []byte(`{"file":` + string(ref) + `}`)
+	want, _ := json.Marshal(map[string][]byte{"file": data})
+	all, err := s.ResolveAll(payload)
+	if err != nil || string(all) != string(want) {
+		t.Fatalf("ResolveAll = %.80s..., err %v", all, err)
+	}
+	one, err := s.Resolve(payload, "file")
+	if err != nil || one.Raw != gjson.GetBytes(want, "file").Raw {
+		t.Fatalf("Resolve = %.80s..., err %v", one.Raw, err)
+	}
+	if packed, _ := s.Pack(payload); string(packed) != string(payload) {
+		t.Fatal("packing a bytes BlobRef should be a no-op")
+	}
+}
+
 func TestRecursiveExtraction(t *testing.T) {
 	s, _ := newTestStore(t)
 
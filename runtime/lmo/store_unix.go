@@ -8,3 +8,5 @@ import "os"
 func renameAtomic(oldPath, newPath string) error { return os.Rename(oldPath, newPath) }
 
 func readFile(p string) ([]byte, error) { return os.ReadFile(p) }
+
+func openFile(p string) (*os.File, error) { return os.Open(p) }
@@ -63,6 +63,26 @@ func renameAtomic(oldPath, newPath string) error {
 	return lastErr
 }
 
+func openFile(p string) (*os.File, error) {
+	backoff := initialFileBackoff
+	var lastErr error
+	for i := 0; i < maxFileRetries; i++ {
+		f, err := os.Open(p)
+		if err == nil {
+			return f, nil
+		}
+		if !isTransientShareErr(err) {
+			return nil, err
+		}
+		lastErr = err
+		time.Sleep(backoff)
+		if backoff < maxFileBackoff {
+			backoff *= 2
+		}
+	}
+	return nil, lastErr
+}
+
 func readFile(p string) ([]byte, error) {
 	backoff := initialFileBackoff
 	var lastErr error
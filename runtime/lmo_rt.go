@@ -1,8 +1,10 @@
 package runtime
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/tidwall/gjson"
 
@@ -10,10 +12,22 @@ import (
 	"github.com/robomotionio/robomotion-go/utils"
 )
 
-var lmoStore *lmo.Store
+var (
+	lmoMu    sync.RWMutex
+	lmoStore *lmo.Store
+)
+
+// currentLMOStore returns the store, or nil before Init and after shutdown.
+func currentLMOStore() *lmo.Store {
+	lmoMu.RLock()
+	defer lmoMu.RUnlock()
+	return lmoStore
+}
 
 // InitLMOStore creates a Store using the platform config directory.
 func InitLMOStore() error {
+	lmoMu.Lock()
+	defer lmoMu.Unlock()
 	if lmoStore != nil {
 		lmoStore.Close()
 		lmoStore = nil
@@ -29,14 +43,17 @@ func InitLMOStore() error {
 // SetLMOStorePath sets the relative path for the blob store.
 // Must be called before Pack/PutBlob can work (e.g. "robots/{id}/flows/{flowID}").
 func SetLMOStorePath(relPath string) error {
-	if lmoStore == nil {
+	store := currentLMOStore()
+	if store == nil {
 		return fmt.Errorf("lmo store not initialised")
 	}
-	return lmoStore.SetRelPath(relPath)
+	return store.SetRelPath(relPath)
 }
 
 // CloseLMOStore releases zstd resources.
 func CloseLMOStore() {
+	lmoMu.Lock()
+	defer lmoMu.Unlock()
 	if lmoStore == nil {
 		return
 	}
@@ -47,33 +64,36 @@ func CloseLMOStore() {
 // LMOResolve lazily resolves a BlobRef for a specific field path.
 // Falls back to plain gjson if the store is nil.
 func LMOResolve(data []byte, key string) (gjson.Result, error) {
-	if lmoStore == nil {
+	store := currentLMOStore()
+	if store == nil {
 		return gjson.GetBytes(data, key), nil
 	}
-	return lmoStore.Resolve(data, key)
+	return store.Resolve(data, key)
 }
 
 // LMOResolveAll eagerly resolves all BlobRefs in the payload.
 // Returns data unchanged if the store is nil.
 func LMOResolveAll(data []byte) ([]byte, error) {
-	if lmoStore == nil {
+	store := currentLMOStore()
+	if store == nil {
 		return data, nil
 	}
-	return lmoStore.ResolveAll(data)
+	return store.ResolveAll(data)
 }
 
 // LMOResolveSubtree resolves a field by key and all nested BlobRefs within it.
 // Use this instead of LMOResolve when the result will be consumed as a whole
 // (e.g. an object whose children may individually be BlobRefs).
 func LMOResolveSubtree(data []byte, key string) (gjson.Result, error) {
+	store := currentLMOStore()
 	result, err := LMOResolve(data, key)
 	if err != nil {
 		return result, err
 	}
-	if lmoStore == nil || result.Type != gjson.JSON {
+	if store == nil || result.Type != gjson.JSON {
 		return result, nil
 	}
-	resolved, err := lmoStore.ResolveAll([]byte(result.Raw))
+	resolved, err := store.ResolveAll([]byte(result.Raw))
 	if err != nil {
 		return gjson.Result{}, err
 	}
@@ -83,16 +103,18 @@ func LMOResolveSubtree(data []byte, key string) (gjson.Result, error) {
 // LMOPack extracts large fields from the payload as blobs.
 // Returns payload unchanged if the store is nil or relPath is not set.
 func LMOPack(payload []byte) ([]byte, error) {
-	if lmoStore == nil {
+	store := currentLMOStore()
+	if store == nil {
 		return payload, nil
 	}
-	return lmoStore.Pack(payload)
+	return store.Pack(payload)
 }
 
 // ResolveBlobRefValue resolves a BlobRef from a map[string]interface{} value.
 // Extracts __ref and __path, reads the blob, and unmarshals the result.
 func ResolveBlobRefValue(m map[string]interface{}) (interface{}, error) {
-	if lmoStore == nil {
+	store := currentLMOStore()
+	if store == nil {
 		return nil, fmt.Errorf("lmo store not initialised")
 	}
 	ref, _ := m["__ref"].(string)
@@ -102,14 +124,17 @@ func ResolveBlobRefValue(m map[string]interface{}) (interface{}, error) {
 	}
 
 	// Learn the store relPath from the first BlobRef we encounter.
-	if lmoStore.RelPath() == "" && relPath != "" {
-		_ = lmoStore.SetRelPath(relPath)
+	if store.RelPath() == "" && relPath != "" {
+		_ = store.SetRelPath(relPath)
 	}
 
-	data, err := lmoStore.GetBlob(ref, relPath)
+	data, err := store.GetBlob(ref, relPath)
 	if err != nil {
 		return nil, err
 	}
+	if t, _ := m["__type"].(string); t == lmo.TypeBytes {
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
 	var result interface{}
 	if err := json.Unmarshal(data, &result); err != nil {
 		return nil, fmt.Errorf("lmo: unmarshal blob: %w", err)
@@ -121,7 +146,8 @@ func ResolveBlobRefValue(m map[string]interface{}) (interface{}, error) {
 // if it exceeds the threshold. Returns (blobRefMap, true) if packed,
 // or (nil, false) if the value is small enough to send inline.
 func PackValue(value interface{}) (interface{}, bool, error) {
-	if lmoStore == nil || lmoStore.RelPath() == "" {
+	store := currentLMOStore()
+	if store == nil || store.RelPath() == "" {
 		return nil, false, nil
 	}
 
@@ -134,7 +160,7 @@ func PackValue(value interface{}) (interface{}, bool, error) {
 		return nil, false, nil
 	}
 
-	packed, err := lmoStore.Pack(data)
+	packed, err := store.Pack(data)
 	if err != nil {
 		return nil, false, err
 	}
@@ -0,0 +1,137 @@
+package runtime
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	hclog "github.com/hashicorp/go-hclog"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// logOutput is the process's stderr as it was at start-up. go-plugin later
+// points os.Stderr and os.Stdout at pipes of its own; the robot reads the
+// original stderr and parses its JSON lines as log records.
+var logOutput io.Writer = os.Stderr
+
+// Logger returns a logger for node whose every line carries the node's
+// GUID and name and, given the message being handled, its ID:
+//
+//	func (n *Fetch) OnMessage(ctx message.Context) error {
+//	    log := runtime.Logger(n, ctx)
+//	    log.Info("fetching", "url", url)
+//	    ...
+//	}
+//
+// The level comes from the robomotion.log.level property (trace, debug,
+// info, warn, error; info by default). Output is JSON on stderr, never
+// stdout. With robomotion.log.mirror=true, lines are also sent to the
+// Designer's debug panel through the node's RuntimeHelper. ctx may be nil,
+// e.g. in OnCreate.
+func Logger(node MessageHandler, ctx message.Context) hclog.Logger {
+	n := NodeOf(node)
+	args := []interface{}{"guid", n.GUID, "node", n.Name}
+	if ctx != nil {
+		if id := ctx.GetID(); id != "" {
+			args = append(args, "msg_id", id)
+		}
+	}
+
+	opts := &hclog.LoggerOptions{
+		Name:       nodeTypeOf(node),
+		Level:      logLevel(),
+		Output:     logOutput,
+		JSONFormat: true,
+	}
+	if !Props.GetBool("robomotion.log.mirror", false) {
+		return hclog.New(opts).With(args...)
+	}
+
+	h := n.Helper()
+	if ctx != nil {
+		h = helperOf(ctx)
+	}
+	logger := hclog.NewInterceptLogger(opts)
+	if h != nil {
+		logger.RegisterSink(hclog.NewSinkAdapter(&hclog.LoggerOptions{
+			Level:      opts.Level,
+			Output:     &debugWriter{helper: h, guid: n.GUID, name: n.Name},
+			JSONFormat: true,
+		}))
+	}
+	return logger.With(args...)
+}
+
+func logLevel() hclog.Level {
+	level := hclog.LevelFromString(Props.GetString("robomotion.log.level", "info"))
+	if level == hclog.NoLevel {
+		return hclog.Info
+	}
+	return level
+}
+
+// debugWriter sends each JSON log line to the debug panel.
+type debugWriter struct {
+	helper     RuntimeHelper
+	guid, name string
+}
+
+func (w *debugWriter) Write(p []byte) (int, error) {
+	var record map[string]interface{}
+	if err := json.Unmarshal(p, &record); err != nil {
+		return len(p), nil
+	}
+	if err := w.helper.Debug(w.guid, w.name, record); err != nil {
+		hclog.Default().Info("runtime.logger.mirror", "err", err)
+	}
+	return len(p), nil
+}
+
+var stdoutOnce sync.Once
+
+// captureStdout logs what the package prints to stdout instead of letting
+// it reach go-plugin's stdout pipe, which the robot does not read. Called
+// once the plugin handshake is done, as only then is stdout free to take.
+func captureStdout() {
+	stdoutOnce.Do(func() {
+		r, w, err := os.Pipe()
+		if err != nil {
+			hclog.Default().Info("runtime.logger.stdout", "err", err)
+			return
+		}
+		os.Stdout = w
+
+		go logLinesOf(r, hclog.New(&hclog.LoggerOptions{
+			Name:       "stdout",
+			Output:     logOutput,
+			JSONFormat: true,
+		}))
+	})
+}
+
+// logLinesOf logs each non-empty line read from r at info until r ends.
+func logLinesOf(r io.Reader, logger hclog.Logger) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			logger.Info(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// LogWriter returns an io.Writer that logs each line written to it through
+// Logger(node, ctx), at the level a "[WARN]"-style prefix names and at info
+// otherwise. Hand it to code that prints, such as a library's log.Logger or
+// an exec.Cmd's Stdout, so its lines are logged as the node's rather than
+// as anonymous stdout lines.
+func LogWriter(node MessageHandler, ctx message.Context) io.Writer {
+	return Logger(node, ctx).StandardWriter(&hclog.StandardLoggerOptions{InferLevels: true})
+}
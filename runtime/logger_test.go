@@ -0,0 +1,133 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/magiconair/properties"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// The logger reads Props and writes to logOutput, so these tests do not
+// run in parallel.
+
+func useLogOutput(t *testing.T, props map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prevOut, prevProps := logOutput, Props
+	logOutput = &buf
+	Props = properties.LoadMap(props)
+	t.Cleanup(func() { logOutput, Props = prevOut, prevProps })
+	return &buf
+}
+
+// fxLogged is a node to log for.
+type fxLogged struct {
+	Node `spec:"id=Test.Log.Node"`
+	fxLifecycle
+}
+
+func (n *fxLogged) OnMessage(_ message.Context) error { return nil }
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if l == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", l, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestLogger_TagsNodeAndMessage(t *testing.T) {
+	buf := useLogOutput(t, nil)
+	n := &fxLogged{Node: Node{GUID: "log-1", Name: "Fetch"}}
+
+	log := Logger(n, newCtx(`{"id":"msg-42"}`))
+	log.Debug("hidden at the default level")
+	log.Info("fetched", "pages", 3)
+
+	lines := logLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %s", len(lines), buf)
+	}
+	l := lines[0]
+	if l["@message"] != "fetched" || l["@level"] != "info" || l["@module"] != "Test.Log.Node" {
+		t.Fatalf("unexpected record %v", l)
+	}
+	if l["guid"] != "log-1" || l["node"] != "Fetch" || l["msg_id"] != "msg-42" || l["pages"] != 3.0 {
+		t.Fatalf("record lacks node fields: %v", l)
+	}
+}
+
+func TestLogger_LevelFromProps(t *testing.T) {
+	buf := useLogOutput(t, map[string]string{"robomotion.log.level": "debug"})
+	Logger(&fxLogged{}, nil).Debug("shown")
+
+	if lines := logLines(t, buf); len(lines) != 1 || lines[0]["@level"] != "debug" {
+		t.Fatalf("debug line not logged: %s", buf)
+	}
+}
+
+func TestLogger_MirrorsToDebugPanel(t *testing.T) {
+	useLogOutput(t, map[string]string{"robomotion.log.mirror": "true"})
+	rec := &fxHelper{}
+	ctx := message.NewContextWith(WithRuntimeHelper(context.Background(), rec), []byte(`{}`))
+
+	Logger(&fxLogged{Node: Node{GUID: "log-2"}}, ctx).Warn("slow response")
+
+	calls := rec.debugCalls()
+	if len(calls) != 1 || calls[0].guid != "log-2" {
+		t.Fatalf("Debug calls = %v, want one for log-2", calls)
+	}
+	record, _ := calls[0].msg.(map[string]interface{})
+	if record["@message"] != "slow response" || record["@level"] != "warn" {
+		t.Fatalf("mirrored record = %v", calls[0].msg)
+	}
+}
+
+func TestLogWriter_LogsEachLine(t *testing.T) {
+	buf := useLogOutput(t, nil)
+	w := LogWriter(&fxLogged{Node: Node{GUID: "log-3"}}, nil)
+
+	fmt.Fprintln(w, "plain line")
+	fmt.Fprintln(w, "[WARN] disk almost full")
+
+	lines := logLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %s", len(lines), buf)
+	}
+	if lines[0]["@message"] != "plain line" || lines[0]["@level"] != "info" || lines[0]["guid"] != "log-3" {
+		t.Fatalf("first record = %v", lines[0])
+	}
+	if lines[1]["@message"] != "disk almost full" || lines[1]["@level"] != "warn" {
+		t.Fatalf("second record = %v", lines[1])
+	}
+}
+
+func TestLogLinesOf_LogsStrayPrints(t *testing.T) {
+	var buf bytes.Buffer
+	logger := hclog.New(&hclog.LoggerOptions{Name: "stdout", Output: &buf, JSONFormat: true})
+
+	logLinesOf(strings.NewReader("first\r\n\nsecond"), logger)
+
+	lines := logLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %s", len(lines), &buf)
+	}
+	if lines[0]["@message"] != "first" || lines[0]["@module"] != "stdout" || lines[1]["@message"] != "second" {
+		t.Fatalf("records = %v", lines)
+	}
+}
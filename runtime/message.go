@@ -2,6 +2,9 @@ package runtime
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/robomotionio/robomotion-go/message"
 	"github.com/tidwall/gjson"
@@ -13,6 +16,8 @@ func init() {
 	message.Resolve = func(data []byte, key string) (gjson.Result, error) {
 		return LMOResolveSubtree(data, key)
 	}
+	message.PutBytes = putBytes
+	message.OpenBlob = openBlob
 }
 
 // WithUnpack resolves all BlobRefs in the message payload.
@@ -29,6 +34,30 @@ func WithPack() message.SetOption {
 	}
 }
 
+// putBytes keeps binary data set on a message in an LMO blob, when the
+// robot resolves blobs and the store has a path.
+func putBytes(data []byte, mime string) (json.RawMessage, bool, error) {
+	store := currentLMOStore()
+	if store == nil || store.RelPath() == "" || !HasCapability(CapabilityLMO) {
+		return nil, false, nil
+	}
+	ref, err := store.PutBytes(data, mime)
+	if err != nil {
+		return nil, false, err
+	}
+	return ref, true, nil
+}
+
+// openBlob streams the blob a BlobRef marker points to.
+func openBlob(ref json.RawMessage) (io.ReadCloser, error) {
+	store := currentLMOStore()
+	if store == nil {
+		return nil, fmt.Errorf("lmo store not initialised")
+	}
+	r := gjson.ParseBytes(ref)
+	return store.OpenBlob(r.Get("__ref").String(), r.Get("__path").String())
+}
+
 func getRaw(raw json.RawMessage, options ...message.GetOption) (json.RawMessage, error) {
 	// Auto-resolve all BlobRefs before returning to the caller.
 	resolved, err := LMOResolveAll(raw)
@@ -55,3 +84,48 @@ func setRaw(raw json.RawMessage, options ...message.SetOption) (json.RawMessage,
 	}
 	return raw, nil
 }
+
+// stampMeta fills in the envelope of out as it leaves node guid, which
+// received a message with envelope in. A message the node made itself,
+// rather than the one it received, gets an ID and in as its parent.
+func stampMeta(out message.Context, in message.Meta, guid string, span *Span) {
+	if out == nil || out.IsEmpty() {
+		return
+	}
+	meta := out.Meta()
+	if meta.ID == "" || meta.ID != in.ID {
+		if meta.ID == "" {
+			meta.ID = newTraceID(16)
+		}
+		if meta.ParentID == "" {
+			meta.ParentID = in.ID
+		}
+	}
+	now := time.Now()
+	if meta.Created.IsZero() {
+		meta.Created = now
+	}
+	meta.Sent = now
+	meta.Origin = guid
+	if span != nil {
+		meta.TraceID, meta.SpanID = span.TraceID, span.SpanID
+	}
+	message.SetMeta(out, meta)
+}
+
+// debugChanges sends what the node did to its message to the debug log, as
+// a compact diff, when the robomotion.debug.changes property is on.
+func debugChanges(h RuntimeHelper, node Node, guid string, msgCtx message.Context) {
+	if h == nil || !Props.GetBool("robomotion.debug.changes", false) {
+		return
+	}
+	changes := msgCtx.Changes()
+	if len(changes) == 0 {
+		return
+	}
+	delta := 0
+	for _, c := range changes {
+		delta += c.Delta
+	}
+	h.Debug(guid, node.Name, map[string]interface{}{"changes": changes, "delta": delta})
+}
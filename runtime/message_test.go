@@ -0,0 +1,553 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties"
+
+	"github.com/robomotionio/robomotion-go/message"
+	"github.com/robomotionio/robomotion-go/runtime/lmo"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// useLMOStore installs a store in a temporary directory for t.
+func useLMOStore(t *testing.T) {
+	t.Helper()
+	s, err := lmo.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := s.SetRelPath("robots/test/flows/test"); err != nil {
+		t.Fatalf("SetRelPath: %v", err)
+	}
+	lmoMu.Lock()
+	old := lmoStore
+	lmoStore = s
+	lmoMu.Unlock()
+	t.Cleanup(func() {
+		lmoMu.Lock()
+		lmoStore = old
+		lmoMu.Unlock()
+		s.Close()
+	})
+}
+
+type fxItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestGetAs_DecodesSubtrees(t *testing.T) {
+	ctx := newCtx(`{"order":{"items":[{"name":"a","count":1},{"name":"b","count":2}]},"n":"x","nil":null}`)
+
+	items, err := message.GetAs[[]fxItem](ctx, "order.items")
+	if err != nil {
+		t.Fatalf("GetAs: %v", err)
+	}
+	if len(items) != 2 || items[1] != (fxItem{"b", 2}) {
+		t.Errorf("items = %+v", items)
+	}
+	if got := message.MustGet[fxItem](ctx, "order.items[0]"); got.Name != "a" {
+		t.Errorf("MustGet = %+v", got)
+	}
+	if v, err := message.GetAs[*fxItem](ctx, "nil"); err != nil || v != nil {
+		t.Errorf("null = %v, %v; want nil, nil", v, err)
+	}
+
+	_, err = message.GetAs[int](ctx, "missing")
+	if !errors.Is(err, message.ErrMissing) || errors.Is(err, message.ErrWrongType) {
+		t.Errorf("missing path: err = %v, want ErrMissing", err)
+	}
+	_, err = message.GetAs[int](ctx, "n")
+	var pe *message.PathError
+	if !errors.Is(err, message.ErrWrongType) || !errors.As(err, &pe) || pe.Got != "string" || pe.Want != "int" {
+		t.Errorf("wrong type: err = %v, want ErrWrongType string→int", err)
+	}
+}
+
+func TestGetAs_ResolvesLMO(t *testing.T) {
+	useLMOStore(t)
+	big := strings.Repeat("x", lmo.Threshold)
+	packed, err := LMOPack([]byte(`{"item":{"name":"` + big + `","count":7}}`))
+	if err != nil {
+		t.Fatalf("LMOPack: %v", err)
+	}
+	if !strings.Contains(string(packed), "__ref") {
+		t.Fatalf("payload was not packed: %.100s", packed)
+	}
+
+	item, err := message.GetAs[fxItem](message.NewContext(packed), "item")
+	if err != nil {
+		t.Fatalf("GetAs: %v", err)
+	}
+	if item.Count != 7 || item.Name != big {
+		t.Errorf("item = {%d chars, %d}", len(item.Name), item.Count)
+	}
+}
+
+func TestSetAs_HonorsJSONTags(t *testing.T) {
+	ctx := newCtx(`{}`)
+	if err := message.SetAs(ctx, "out", fxItem{Name: "a", Count: 3}); err != nil {
+		t.Fatalf("SetAs: %v", err)
+	}
+	if got := ctx.GetString("out.name"); got != "a" {
+		t.Errorf("out.name = %q", got)
+	}
+	if err := message.SetAs(ctx, "bad", func() {}); err == nil {
+		t.Error("SetAs of a func did not fail")
+	}
+}
+
+func TestContext_Mutations(t *testing.T) {
+	ctx := newCtx(`{"id":"m1","a":{"x":1,"y":null},"list":[1]}`)
+
+	if !ctx.Has("a.y") || ctx.Has("a.z") {
+		t.Errorf("Has(a.y)=%v Has(a.z)=%v, want true false", ctx.Has("a.y"), ctx.Has("a.z"))
+	}
+	if got := strings.Join(ctx.Keys(""), ","); got != "id,a,list" {
+		t.Errorf("Keys() = %s", got)
+	}
+	if ctx.Keys("list") != nil {
+		t.Error("Keys of an array is not nil")
+	}
+
+	clone := ctx.Clone()
+	if err := ctx.Delete("a.x"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := ctx.Delete("no.such.path"); err != nil {
+		t.Errorf("Delete of a missing path: %v", err)
+	}
+	if ctx.Has("a.x") || !clone.Has("a.x") || clone.GetID() != "m1" {
+		t.Error("Delete did not remove a.x from the message alone")
+	}
+
+	if err := ctx.Merge("a", map[string]interface{}{"z": 2, "k.dot": true}); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if err := ctx.Merge("b", fxItem{Name: "n"}); err != nil {
+		t.Fatalf("Merge into a missing path: %v", err)
+	}
+	if err := ctx.Merge("list", map[string]int{"x": 1}); !errors.Is(err, message.ErrWrongType) {
+		t.Errorf("Merge into an array: err = %v, want ErrWrongType", err)
+	}
+	if got := strings.Join(ctx.Keys("a"), ","); got != "y,k.dot,z" {
+		t.Errorf("Keys(a) after Merge = %s", got)
+	}
+	if ctx.GetString("b.name") != "n" {
+		t.Errorf("b = %v", ctx.Get("b"))
+	}
+
+	if err := ctx.Append("list", map[string]int{"v": 2}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := ctx.Append("fresh", "s"); err != nil {
+		t.Fatalf("Append to a missing path: %v", err)
+	}
+	if err := ctx.Append("a", 1); !errors.Is(err, message.ErrWrongType) {
+		t.Errorf("Append to an object: err = %v, want ErrWrongType", err)
+	}
+	if ctx.GetInt("list[1].v") != 2 || ctx.GetString("fresh[0]") != "s" {
+		t.Errorf("after Append: list=%v fresh=%v", ctx.Get("list"), ctx.Get("fresh"))
+	}
+}
+
+func TestContext_MergeIntoEmptyMessage(t *testing.T) {
+	for _, raw := range [][]byte{nil, []byte(""), []byte(" \n")} {
+		ctx := message.NewContext(raw)
+		if err := ctx.Merge("", map[string]int{"n": 1}); err != nil {
+			t.Fatalf("Merge into %q: %v", raw, err)
+		}
+		if ctx.GetInt("n") != 1 {
+			t.Errorf("after Merge into %q: n = %v", raw, ctx.Get("n"))
+		}
+	}
+	if _, err := message.MergeJSON([]byte(`[]`), "", map[string]int{"n": 1}); !errors.Is(err, message.ErrWrongType) {
+		t.Errorf("MergeJSON into an array: err = %v, want ErrWrongType", err)
+	}
+}
+
+func TestContext_MutationsKeepLMORefs(t *testing.T) {
+	useLMOStore(t)
+	big := strings.Repeat("x", lmo.Threshold)
+	packed, err := LMOPack([]byte(`{"blob":"` + big + `","obj":{"big":"` + big + `","n":1}}`))
+	if err != nil {
+		t.Fatalf("LMOPack: %v", err)
+	}
+	ctx := message.NewContext(packed)
+	if !lmo.IsBlobRef(gjson.GetBytes(packed, "blob")) {
+		t.Fatalf("blob was not packed: %.200s", packed)
+	}
+
+	if err := ctx.Merge("extra", map[string]int{"n": 1}); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if err := ctx.Set("obj.n", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	out := message.PackedBytes(ctx)
+	if !lmo.IsBlobRef(gjson.GetBytes(out, "blob")) {
+		t.Error("mutating other paths inlined the blob")
+	}
+	if !ctx.Has("obj.big") || ctx.GetString("obj.big") != big {
+		t.Error("obj.big is not readable after the writes")
+	}
+	if keys := strings.Join(ctx.Keys("obj"), ","); !strings.Contains(keys, "big") {
+		t.Errorf("Keys(obj) = %s", keys)
+	}
+}
+
+func TestContext_SetBelowBlobRef(t *testing.T) {
+	useLMOStore(t)
+	big := strings.Repeat("y", lmo.Threshold)
+	packed, err := LMOPack([]byte(`{"obj":{"a":"` + big[:lmo.Threshold/2] + `","b":"` + big[:lmo.Threshold/2] + `"}}`))
+	if err != nil {
+		t.Fatalf("LMOPack: %v", err)
+	}
+	if !lmo.IsBlobRef(gjson.GetBytes(packed, "obj")) {
+		t.Fatalf("obj was not packed whole: %.200s", packed)
+	}
+
+	ctx := message.NewContext(packed)
+	if err := ctx.Set("obj.c", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := ctx.Append("obj.list", 1); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if ctx.GetInt("obj.c") != 1 || ctx.GetString("obj.a") == "" || ctx.GetInt("obj.list[0]") != 1 {
+		t.Errorf("obj = %.80v", ctx.Get("obj"))
+	}
+}
+
+// TestContext_BatchedSetsMatchSjson checks that batched Sets give the same
+// payload, byte for byte, and the same errors as applying each with sjson.
+func TestContext_BatchedSetsMatchSjson(t *testing.T) {
+	type set struct {
+		path  string
+		value interface{}
+	}
+	cases := []struct {
+		name string
+		data string
+		sets []set
+	}{
+		{"new and existing keys", `{"a":1, "b" : {"x":[1,2]},"c":"s"}`, []set{
+			{"c", "t"}, {"d", 1.5}, {"b.x.-1", 3}, {"a", nil}, {"b.y", map[string]int{"k": 1}}, {"e.f.g", true},
+		}},
+		{"empty payload", ``, []set{{"a", 1}, {"b", "x"}, {"a", 2}}},
+		{"empty payload, index first", ``, []set{{"0", "v"}, {"1", "w"}}},
+		{"blank payload", ` `, []set{{"a", 1}, {"b", 2}}},
+		{"empty object", ` { } `, []set{{"x", []int{1}}, {"y.z", "w"}}},
+		{"escaped keys", `{"a.b":1,"c":{}}`, []set{{`a\.b`, 2}, {`c.d\.e`, 3}, {`new\.key`, "v"}}},
+		{"array errors", `{"a":[1],"b":"s"}`, []set{{"a.k", 1}, {"a.2", "x"}, {"b.c", 1}, {"a.k", 2}}},
+		{"invalid paths", `{"a":1}`, []set{{"", 1}, {"a*", 2}, {"b", 3}}},
+		{"top-level array", `[1,2]`, []set{{"0", 9}, {"x", 1}, {"-1", 3}}},
+		{"bytes and raw", `{}`, []set{{"b", []byte("raw")}, {"r", json.RawMessage(`{"n":1}`)}, {"r.m", 2}}},
+		{"duplicate keys", `{"a":1,"a":2}`, []set{{"a", 3}, {"b", 4}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			want := []byte(tc.data)
+			ctx := message.NewContext([]byte(tc.data))
+			for _, s := range tc.sets {
+				var wantErr error
+				if next, err := sjson.SetBytes(want, s.path, s.value); err != nil {
+					wantErr = err
+				} else {
+					want = next
+				}
+				if err := ctx.Set(s.path, s.value); (err == nil) != (wantErr == nil) {
+					t.Errorf("Set(%q) = %v, sjson: %v", s.path, err, wantErr)
+				}
+			}
+			if got := message.PackedBytes(ctx); string(got) != string(want) {
+				t.Errorf("payload\n got %q\nwant %q", got, want)
+			}
+		})
+	}
+}
+
+func TestContext_SetCapturesValue(t *testing.T) {
+	ctx := newCtx(`{}`)
+	m := map[string]int{"n": 1}
+	b := []byte("ab")
+	ctx.Set("m", m)
+	ctx.Set("b", b)
+	m["n"], b[0] = 2, 'x'
+	if got := ctx.GetInt("m.n"); got != 1 {
+		t.Errorf("m.n = %d, want the value at Set time", got)
+	}
+	if got := ctx.GetString("b"); got != "ab" {
+		t.Errorf("b = %q, want the value at Set time", got)
+	}
+}
+
+func TestContext_ReadsSeePendingSets(t *testing.T) {
+	ctx := newCtx(`{"a":{"x":1},"items":[1,2]}`)
+	ctx.Set("a.y", 2)
+	ctx.Set("items.-1", 3)
+	ctx.Set("new", "n")
+
+	if ctx.GetInt("a.y") != 2 || ctx.GetInt("a.x") != 1 || ctx.GetString("new") != "n" {
+		t.Errorf("a = %v, new = %v", ctx.Get("a"), ctx.Get("new"))
+	}
+	if got := ctx.GetInt("items.#"); got != 3 {
+		t.Errorf("items.# = %d, want 3", got)
+	}
+	ctx.Set("a.y", 5)
+	if got := ctx.GetInt("a.y"); got != 5 {
+		t.Errorf("a.y after a second Set = %d, want 5", got)
+	}
+	if got := strings.Join(ctx.Keys(""), ","); got != "a,items,new" {
+		t.Errorf("Keys() = %s", got)
+	}
+	raw, _ := ctx.GetRaw()
+	if string(raw) != `{"a":{"x":1,"y":5},"items":[1,2,3],"new":"n"}` {
+		t.Errorf("GetRaw = %s", raw)
+	}
+}
+
+func TestContext_SetBytes(t *testing.T) {
+	useLMOStore(t)
+	useFeatures(t)
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	read := func(ctx message.Context, path string) []byte {
+		t.Helper()
+		r, err := ctx.OpenReader(path)
+		if err != nil {
+			t.Fatalf("OpenReader(%q): %v", path, err)
+		}
+		defer r.Close()
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("read %q: %v", path, err)
+		}
+		return got
+	}
+
+	// Without LMO on the robot the data goes inline, as base64.
+	SetRobotCapabilities(0)
+	ctx := newCtx(`{}`)
+	if err := ctx.SetBytes("doc.pdf", data, "application/pdf"); err != nil {
+		t.Fatalf("SetBytes: %v", err)
+	}
+	if ctx.GetString("doc.pdf") != base64.StdEncoding.EncodeToString(data) {
+		t.Fatal("fallback is not the base64 string of the data")
+	}
+	if !bytes.Equal(read(ctx, "doc.pdf"), data) {
+		t.Fatal("OpenReader of base64 returned other data")
+	}
+
+	// With LMO the data goes to a blob, and a downstream node streams it.
+	SetRobotCapabilities(uint64(CapabilityLMO))
+	ctx = newCtx(`{}`)
+	if err := ctx.SetBytes("doc.pdf", data, "application/pdf"); err != nil {
+		t.Fatalf("SetBytes: %v", err)
+	}
+	wire := message.PackedBytes(ctx)
+	if ref := gjson.GetBytes(wire, "doc.pdf"); ref.Get("__type").String() != "bytes" ||
+		ref.Get("__mime").String() != "application/pdf" || len(wire) > 1000 {
+		t.Fatalf("wire form = %s", wire)
+	}
+	next := newCtx(string(wire))
+	if !bytes.Equal(read(next, "doc.pdf"), data) {
+		t.Fatal("OpenReader of the blob returned other data")
+	}
+	if got, err := message.GetAs[[]byte](next, "doc.pdf"); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("GetAs[[]byte] = %d bytes, %v", len(got), err)
+	}
+	if raw, _ := next.GetRaw(); gjson.GetBytes(raw, "doc.pdf").String() != base64.StdEncoding.EncodeToString(data) {
+		t.Fatal("GetRaw does not resolve the blob to base64")
+	}
+
+	next.Set("n", 1)
+	if _, err := next.OpenReader("n"); !errors.Is(err, message.ErrWrongType) {
+		t.Fatalf("OpenReader of a number: %v", err)
+	}
+	if _, err := next.OpenReader("none"); !errors.Is(err, message.ErrMissing) {
+		t.Fatalf("OpenReader of a missing path: %v", err)
+	}
+}
+
+func TestContext_PathGrammar(t *testing.T) {
+	ctx := newCtx(`{"items":[1,2,3],"a.b":{"x[0]":"q"}}`)
+
+	if ctx.GetString(`["a.b"]['x[0]']`) != "q" || ctx.GetString(`a\.b.x\[0\]`) != "q" {
+		t.Fatalf("quoted and escaped keys not found")
+	}
+	if err := ctx.Set(`["say \"hi\""].to`, "you"); err != nil {
+		t.Fatalf("Set quoted key: %v", err)
+	}
+	if ctx.GetString(`['say "hi"'].to`) != "you" {
+		t.Fatal("a key set with one quote style is not found with the other")
+	}
+
+	if ctx.GetInt("items[-1]") != 3 || ctx.GetInt("items[-3]") != 1 || ctx.Has("items[-4]") {
+		t.Fatalf("negative indexes: %v %v %v", ctx.Get("items[-1]"), ctx.Get("items[-3]"), ctx.Get("items[-4]"))
+	}
+	ctx.Set("items[-1]", 9)
+	ctx.Append("items", 10)
+	if got := ctx.Get("items[-2]"); got != float64(9) || ctx.GetInt("items.#") != 4 || ctx.GetInt("items.1") != 2 {
+		t.Fatalf("items = %v", ctx.Get("items"))
+	}
+	if err := ctx.Set("items[-9]", 0); !errors.Is(err, message.ErrMissing) {
+		t.Fatalf("Set past the start: %v", err)
+	}
+	if err := ctx.Delete("items[-1]"); err != nil || ctx.GetInt("items[-1]") != 9 {
+		t.Fatalf("Delete last: %v, items = %v", err, ctx.Get("items"))
+	}
+
+	for _, bad := range []string{`a["b`, `a[`, `a[]`, `a["b"c]`} {
+		if err := ctx.Set(bad, 1); !errors.Is(err, message.ErrBadPath) {
+			t.Errorf("Set(%q) = %v, want ErrBadPath", bad, err)
+		}
+		if ctx.Get(bad) != nil {
+			t.Errorf("Get(%q) found a value", bad)
+		}
+	}
+
+	// A converted path converts to itself.
+	for _, path := range []string{`["a.b"]['x[0]']`, `items[-1]`, `x["(y)"][0]`} {
+		once, err := message.ConvertPath([]byte(`{"items":[1]}`), path)
+		if err != nil {
+			t.Fatalf("ConvertPath(%q): %v", path, err)
+		}
+		if twice, _ := message.ConvertPath(nil, once); twice != once {
+			t.Errorf("ConvertPath(%q) = %q, then %q", path, once, twice)
+		}
+	}
+}
+
+func TestContext_Query(t *testing.T) {
+	useLMOStore(t)
+	useFeatures(t)
+	SetRobotCapabilities(uint64(CapabilityLMO))
+
+	items := make([]map[string]interface{}, 200)
+	for i := range items {
+		items[i] = map[string]interface{}{"sku": fmt.Sprintf("s%03d", i), "qty": i % 3}
+	}
+	raw, _ := json.Marshal(map[string]interface{}{"order": map[string]interface{}{"id": "o1", "items": items}})
+	packed, err := LMOPack(raw)
+	if err != nil || !strings.Contains(string(packed), "__ref") {
+		t.Fatalf("LMOPack: %v (%d bytes)", err, len(packed))
+	}
+	ctx := newCtx(string(packed))
+
+	skus, err := message.QueryAs[[]string](ctx, `order.items.#(qty==2)#.sku`)
+	if err != nil || len(skus) != 66 || skus[0] != "s002" {
+		t.Fatalf("filter: %d skus, %v", len(skus), err)
+	}
+	if r, err := ctx.Query(`order.items[-1].sku`); err != nil || r.String() != "s199" {
+		t.Fatalf("negative index into a blob: %q, %v", r.String(), err)
+	}
+	if ctx.GetString(`order.items[-1].sku`) != "s199" {
+		t.Fatal("Get with a negative index into a blob")
+	}
+	if r, _ := ctx.Query(`order.*`); !r.Exists() || r.String() != "o1" {
+		t.Fatalf("wildcard: %s", r.Raw)
+	}
+	if r, _ := ctx.Query(`order.items.#`); r.Int() != 200 {
+		t.Fatalf("count: %s", r.Raw)
+	}
+	var first fxItem
+	if r, _ := ctx.Query(`order.items[0]`); r.Decode(&first) != nil {
+		t.Fatalf("Decode: %s", r.Raw)
+	}
+	if _, err := message.QueryAs[int](ctx, `order.items.#(qty>5)`); !errors.Is(err, message.ErrMissing) {
+		t.Fatalf("no match: %v", err)
+	}
+	if _, err := ctx.Query(`order["id`); !errors.Is(err, message.ErrBadPath) {
+		t.Fatalf("bad expression: %v", err)
+	}
+}
+
+func TestContext_Changes(t *testing.T) {
+	ctx := newCtx(`{"a":1,"order":{"total":10,"items":[1,2]},"old":"x","a.b":true,"__meta__":{"id":"m1"}}`)
+	if changes := ctx.Changes(); len(changes) != 0 {
+		t.Fatalf("Changes before any write = %v", changes)
+	}
+
+	ctx.Set("a", 1) // the same value
+	ctx.Set("order.total", 1000)
+	ctx.Append("order.items", 3)
+	ctx.Delete("old")
+	ctx.Set(`["a.b"]`, false)
+	ctx.Merge("tags", map[string]string{"k": "v"})
+	want := []message.Change{
+		{Path: "order.total", Kind: message.Changed, Delta: 2},
+		{Path: "order.items.2", Kind: message.Added, Delta: 1},
+		{Path: "old", Kind: message.Removed, Delta: -3},
+		{Path: `a\.b`, Kind: message.Changed, Delta: 1},
+		{Path: "tags", Kind: message.Added, Delta: 9},
+	}
+	got := ctx.Changes()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("Changes = %v, want %v", got, want)
+	}
+	for _, c := range got[:2] {
+		if !ctx.Has(c.Path) {
+			t.Errorf("change path %q is not a path of the message", c.Path)
+		}
+	}
+	if fmt.Sprint(ctx.Clone().Changes()) != fmt.Sprint(want) {
+		t.Error("Clone lost the changes")
+	}
+
+	ctx.SetRaw([]byte(`{"a":1}`))
+	if got := ctx.Changes(); len(got) != 3 || got[0].Path != "order" || got[0].Kind != message.Removed {
+		t.Fatalf("Changes after SetRaw = %v", got)
+	}
+}
+
+// TestOnMessage_DebugChanges sets Props, so it does not run in parallel.
+func TestOnMessage_DebugChanges(t *testing.T) {
+	old := Props
+	t.Cleanup(func() { Props = old })
+	addTestHandler(t, "changes-on", Node{}, &fxNode{})
+	addTestHandler(t, "changes-set", Node{}, fxFunc(func(ctx message.Context) error {
+		return ctx.Set("b", "xyz")
+	}))
+	rec := &fxHelper{}
+	s := &GRPCServer{helper: rec}
+	run := func(guid string) {
+		t.Helper()
+		if _, err := s.OnMessage(context.Background(), onMessageRequest(t, guid, `{"a":1}`)); err != nil {
+			t.Fatalf("OnMessage: %v", err)
+		}
+	}
+
+	Props = properties.LoadMap(nil)
+	run("changes-set")
+	if calls := rec.debugCalls(); len(calls) != 0 {
+		t.Fatalf("Debug calls with robomotion.debug.changes off: %v", calls)
+	}
+
+	Props = properties.LoadMap(map[string]string{"robomotion.debug.changes": "true"})
+	run("changes-on")
+	if calls := rec.debugCalls(); len(calls) != 0 {
+		t.Fatalf("Debug calls for an untouched message: %v", calls)
+	}
+	run("changes-set")
+	calls := rec.debugCalls()
+	if len(calls) != 1 || calls[0].guid != "changes-set" {
+		t.Fatalf("Debug calls = %v", calls)
+	}
+	diff, _ := json.Marshal(calls[0].msg)
+	if string(diff) != `{"changes":[{"path":"b","kind":"added","delta":5}],"delta":5}` {
+		t.Fatalf("diff = %s", diff)
+	}
+}
@@ -0,0 +1,105 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+func TestMeta_HiddenFromPayload(t *testing.T) {
+	ctx := newCtx(`{"a":1,"__meta__":{"id":"m1","origin":"g0","traceId":"t1","spanId":"s1"}}`)
+
+	raw, _ := ctx.GetRaw()
+	if string(raw) != `{"a":1}` {
+		t.Fatalf("GetRaw = %s, want the payload alone", raw)
+	}
+	if ctx.Has(message.MetaField) || ctx.Get(message.MetaField) != nil {
+		t.Fatal("envelope visible through Get")
+	}
+	meta := ctx.Meta()
+	if meta.ID != "m1" || meta.Origin != "g0" || meta.TraceID != "t1" || ctx.GetID() != "m1" {
+		t.Fatalf("Meta = %+v, GetID = %q", meta, ctx.GetID())
+	}
+
+	// The envelope survives edits, Clone and SetRaw, and goes back on the wire.
+	ctx.Set("b", 2)
+	ctx.SetRaw([]byte(`{"c":3,"__meta__":{"id":"forged"}}`))
+	clone := ctx.Clone()
+	packed := message.PackedBytes(clone)
+	if payloadOf(packed) != `{"c":3}` {
+		t.Fatalf("PackedBytes payload = %s", packed)
+	}
+	if got := message.MetaOf(packed); got.ID != "m1" || got.Origin != "g0" {
+		t.Fatalf("PackedBytes envelope = %+v (%s)", got, packed)
+	}
+}
+
+func TestMeta_ReadsLegacyFields(t *testing.T) {
+	legacy := `{"id":"x","__trace__":{"traceId":"t","spanId":"s"},"__message_type__":"tool_request",` +
+		`"__tool_name__":"search","__tool_caller_id__":"c","__agent_node_id__":"agent","session_id":"sess"}`
+	meta := newCtx(legacy).Meta()
+	want := message.Meta{
+		ID: "x", TraceID: "t", SpanID: "s", Type: "tool_request",
+		Tool: &message.ToolCall{Name: "search", CallerID: "c", AgentNodeID: "agent", SessionID: "sess"},
+	}
+	if meta.Tool == nil || *meta.Tool != *want.Tool {
+		t.Fatalf("Meta.Tool = %+v, want %+v", meta.Tool, want.Tool)
+	}
+	meta.Tool, want.Tool = nil, nil
+	if meta != want {
+		t.Fatalf("Meta = %+v, want %+v", meta, want)
+	}
+
+	// The envelope wins over the legacy fields.
+	ctx := newCtx(`{"id":"x","__tool_name__":"search","__meta__":{"id":"y","tool":{"name":"fetch"}}}`)
+	if ctx.Meta().ID != "y" || ToolName(ctx) != "fetch" || ctx.Meta().Tool.CallerID != "" {
+		t.Fatalf("Meta = %+v", ctx.Meta())
+	}
+}
+
+func TestOnMessage_StampsMeta(t *testing.T) {
+	t.Parallel()
+	var seen message.Meta
+	addTestHandler(t, "meta-pass", Node{}, fxFunc(func(ctx message.Context) error {
+		seen = ctx.Meta()
+		return nil
+	}))
+	// meta-spawn sends a new message in place of the one it received.
+	addTestHandler(t, "meta-spawn", Node{}, fxFunc(func(ctx message.Context) error {
+		ctx.RouteTo()
+		ctx.SendTo(0, message.NewContext([]byte(`{"made":true}`)))
+		return nil
+	}))
+	in := `{"id":"m1","v":1,"__meta__":{"id":"m1","origin":"upstream","created":"2026-01-02T03:04:05Z"}}`
+
+	s := &GRPCServer{}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "meta-pass", in))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	if seen.Origin != "upstream" {
+		t.Fatalf("node saw %+v, want the upstream envelope", seen)
+	}
+	out := message.MetaOf(resp.OutMessage)
+	if out.ID != "m1" || out.ParentID != "" || out.Origin != "meta-pass" ||
+		out.Created.Year() != 2026 || out.Sent.Before(out.Created) || out.Sent.Equal(out.Created) {
+		t.Fatalf("passed-on envelope = %+v", out)
+	}
+	if payloadOf(resp.OutMessage) != `{"id":"m1","v":1}` {
+		t.Fatalf("payload = %s", resp.OutMessage)
+	}
+
+	resp, err = s.OnMessage(context.Background(), onMessageRequest(t, "meta-spawn", in))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	out = message.MetaOf(resp.OutMessage)
+	if out.ID == "" || out.ID == "m1" || out.ParentID != "m1" || out.Origin != "meta-spawn" || out.Created.IsZero() {
+		t.Fatalf("new message envelope = %+v", out)
+	}
+	if strings.Contains(payloadOf(resp.OutMessage), "m1") {
+		t.Fatalf("new message inherited the input's headers: %s", resp.OutMessage)
+	}
+}
@@ -0,0 +1,452 @@
+package runtime
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/robomotionio/robomotion-go/runtime/lmo"
+)
+
+// The runtime counts messages, latencies, bytes and LMO activity in
+// DefaultMetrics, and nodes can add their own metrics to it:
+//
+//	var fetched = runtime.NewCounter("mypkg_pages_fetched_total", "Pages fetched.", "site")
+//	...
+//	fetched.Inc("example.com")
+//
+// The registry is exposed in the Prometheus text format on
+// ROBOMOTION_METRICS_ADDR (or the robomotion.metrics.addr property), e.g.
+// 127.0.0.1:9464/metrics, and by the session daemon through
+// --session-metrics <id>.
+
+// DefBuckets are the default histogram buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// MetricsRegistry holds named metrics and renders them for Prometheus.
+type MetricsRegistry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// metric is one registered family.
+type metric interface {
+	kind() string
+	help() string
+	write(w io.Writer, name string)
+}
+
+// DefaultMetrics is the registry of the package process.
+var DefaultMetrics = NewMetricsRegistry()
+
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{metrics: make(map[string]metric)}
+}
+
+// register returns the metric already registered as name, or m. Asking for
+// an existing name with another kind or label set panics.
+func (r *MetricsRegistry) register(name string, m metric, labels []string) metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if prev, ok := r.metrics[name]; ok {
+		if prev.kind() != m.kind() || !sameLabels(prev, labels) {
+			panic(fmt.Sprintf("metric %q registered twice with different kinds or labels", name))
+		}
+		return prev
+	}
+	r.metrics[name] = m
+	return m
+}
+
+func sameLabels(m metric, labels []string) bool {
+	var have []string
+	switch m := m.(type) {
+	case *Counter:
+		have = m.labels
+	case *Gauge:
+		have = m.labels
+	case *Histogram:
+		have = m.labels
+	}
+	if len(have) != len(labels) {
+		return false
+	}
+	for i := range have {
+		if have[i] != labels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Counter registers (or returns) a counter with the given label names.
+func (r *MetricsRegistry) Counter(name, help string, labels ...string) *Counter {
+	return r.register(name, &Counter{series: newSeries(help, labels)}, labels).(*Counter)
+}
+
+// Gauge registers (or returns) a gauge with the given label names.
+func (r *MetricsRegistry) Gauge(name, help string, labels ...string) *Gauge {
+	return r.register(name, &Gauge{Counter{series: newSeries(help, labels)}}, labels).(*Gauge)
+}
+
+// Histogram registers (or returns) a histogram. Nil buckets means DefBuckets.
+func (r *MetricsRegistry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{series: newSeries(help, labels), buckets: buckets}
+	return r.register(name, h, labels).(*Histogram)
+}
+
+// CounterFunc registers a counter whose value is read from fn on each scrape.
+func (r *MetricsRegistry) CounterFunc(name, help string, fn func() float64) {
+	r.register(name, &funcMetric{typ: "counter", text: help, fn: fn}, nil)
+}
+
+// GaugeFunc registers a gauge whose value is read from fn on each scrape.
+func (r *MetricsRegistry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &funcMetric{typ: "gauge", text: help, fn: fn}, nil)
+}
+
+// WritePrometheus writes every metric in the Prometheus text format,
+// sorted by name.
+func (r *MetricsRegistry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make(map[string]metric, len(r.metrics))
+	for name, m := range r.metrics {
+		metrics[name] = m
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		m := metrics[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(m.help()), name, m.kind())
+		m.write(bw, name)
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the registry in the Prometheus text format.
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := r.WritePrometheus(w); err != nil {
+		hclog.Default().Info("runtime.metrics.serve", "err", err)
+	}
+}
+
+// NewCounter registers a counter in DefaultMetrics.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return DefaultMetrics.Counter(name, help, labels...)
+}
+
+// NewGauge registers a gauge in DefaultMetrics.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return DefaultMetrics.Gauge(name, help, labels...)
+}
+
+// NewHistogram registers a histogram in DefaultMetrics.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return DefaultMetrics.Histogram(name, help, buckets, labels...)
+}
+
+// series is the labelled state shared by every metric kind.
+type series struct {
+	text   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string][]string // key → label values
+}
+
+func newSeries(help string, labels []string) series {
+	return series{text: help, labels: append([]string(nil), labels...), values: make(map[string][]string)}
+}
+
+func (s *series) help() string { return s.text }
+
+// key checks the label values and returns their map key. s.mu must be held.
+func (s *series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metric with labels %v got %d values", s.labels, len(values)))
+	}
+	k := strings.Join(values, "\xff")
+	if _, ok := s.values[k]; !ok {
+		s.values[k] = append([]string(nil), values...)
+	}
+	return k
+}
+
+// sortedKeys returns the series keys in order. s.mu must be held.
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelString renders {a="x",b="y"} plus extra, e.g. le="0.5".
+func (s *series) labelString(values []string, extra ...string) string {
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range s.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, l, escapeLabel(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extra[i], escapeLabel(extra[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Counter is a value that only goes up, one per set of label values.
+type Counter struct {
+	series
+	counts map[string]float64
+}
+
+func (c *Counter) kind() string { return "counter" }
+
+// Inc adds 1 to the series with the given label values.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add adds v to the series with the given label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]float64)
+	}
+	c.counts[c.key(labelValues)] += v
+}
+
+// Value returns the current value of the series with the given label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[strings.Join(labelValues, "\xff")]
+}
+
+func (c *Counter) write(w io.Writer, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", name, c.labelString(c.values[k]), formatFloat(c.counts[k]))
+	}
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	Counter
+}
+
+func (g *Gauge) kind() string { return "gauge" }
+
+// Set sets the series with the given label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.counts == nil {
+		g.counts = make(map[string]float64)
+	}
+	g.counts[g.key(labelValues)] = v
+}
+
+// Histogram counts observations into buckets.
+type Histogram struct {
+	series
+	buckets []float64
+	data    map[string]*histogramData
+}
+
+type histogramData struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (h *Histogram) kind() string { return "histogram" }
+
+// Observe records v in the series with the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.data == nil {
+		h.data = make(map[string]*histogramData)
+	}
+	k := h.key(labelValues)
+	d, ok := h.data[k]
+	if !ok {
+		d = &histogramData{counts: make([]uint64, len(h.buckets))}
+		h.data[k] = d
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		d.counts[i]++
+	}
+	d.sum += v
+	d.count++
+}
+
+// Count returns the number of observations of the given series.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if d, ok := h.data[strings.Join(labelValues, "\xff")]; ok {
+		return d.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer, name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, k := range h.sortedKeys() {
+		values, d := h.values[k], h.data[k]
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += d.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labelString(values, "le", formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labelString(values, "le", "+Inf"), d.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, h.labelString(values), formatFloat(d.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, h.labelString(values), d.count)
+	}
+}
+
+// funcMetric is an unlabelled metric read from a function at scrape time.
+type funcMetric struct {
+	typ  string
+	text string
+	fn   func() float64
+}
+
+func (f *funcMetric) kind() string { return f.typ }
+func (f *funcMetric) help() string { return f.text }
+func (f *funcMetric) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(f.fn()))
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+// startMetricsServer serves DefaultMetrics at /metrics when an address is
+// configured. It is opt-in: nothing listens otherwise.
+func startMetricsServer() {
+	addr := os.Getenv("ROBOMOTION_METRICS_ADDR")
+	if addr == "" {
+		addr = Props.GetString("robomotion.metrics.addr", "")
+	}
+	if addr == "" {
+		return
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		hclog.Default().Info("runtime.metrics.listen", "err", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", DefaultMetrics)
+	go func() {
+		if err := http.Serve(lis, mux); err != nil {
+			hclog.Default().Info("runtime.metrics.serve", "err", err)
+		}
+	}()
+}
+
+// Metrics the runtime maintains.
+var (
+	messagesTotal = NewCounter("robomotion_messages_total",
+		"Messages handled by OnMessage.", "node_type")
+	messagesFailed = NewCounter("robomotion_messages_failed_total",
+		"OnMessage calls that returned an error.", "node_type")
+	onMessageSeconds = NewHistogram("robomotion_onmessage_duration_seconds",
+		"OnMessage latency, retries included.", nil, "node_type")
+	messageBytes = NewCounter("robomotion_message_bytes_total",
+		"Message bytes received and sent, before and after compression.", "direction", "stage")
+	rpcSeconds = NewHistogram("robomotion_rpc_duration_seconds",
+		"Latency of RuntimeHelper calls to the robot.", nil, "method")
+)
+
+func init() {
+	DefaultMetrics.CounterFunc("robomotion_lmo_blobs_written_total",
+		"Blobs written to the LMO store.", func() float64 { return float64(lmo.ReadStats().BlobsWritten) })
+	DefaultMetrics.CounterFunc("robomotion_lmo_blobs_resolved_total",
+		"Blobs read back from the LMO store.", func() float64 { return float64(lmo.ReadStats().BlobsResolved) })
+	DefaultMetrics.CounterFunc("robomotion_lmo_bytes_saved_total",
+		"Message bytes replaced by LMO blob references.", func() float64 { return float64(lmo.ReadStats().BytesSaved) })
+}
+
+// observeMessage records one OnMessage call of a node of nodeType.
+func observeMessage(nodeType string, start time.Time, err error) {
+	messagesTotal.Inc(nodeType)
+	if err != nil {
+		messagesFailed.Inc(nodeType)
+	}
+	onMessageSeconds.Observe(time.Since(start).Seconds(), nodeType)
+}
+
+// countBytes records n message bytes going in direction ("in" or "out") at
+// stage "raw" (before compression) or "wire" (as transmitted).
+func countBytes(direction, stage string, n int) {
+	messageBytes.Add(float64(n), direction, stage)
+}
+
+// timedConn times every RuntimeHelper call made on the robot connection.
+type timedConn struct {
+	grpc.ClientConnInterface
+}
+
+func (c timedConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := c.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+	rpcSeconds.Observe(time.Since(start).Seconds(), path.Base(method))
+	return err
+}
@@ -0,0 +1,120 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/robomotionio/robomotion-go/message"
+	"github.com/robomotionio/robomotion-go/proto"
+)
+
+func TestMetricsRegistry_PrometheusText(t *testing.T) {
+	t.Parallel()
+	r := NewMetricsRegistry()
+	c := r.Counter("test_requests_total", "Requests.", "code")
+	c.Inc("200")
+	c.Add(2, "500")
+	r.Gauge("test_queue", "Queue length.").Set(3)
+	h := r.Histogram("test_seconds", "Latency.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+	r.CounterFunc("test_func_total", "From a func.", func() float64 { return 7 })
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_func_total From a func.
+# TYPE test_func_total counter
+test_func_total 7
+# HELP test_queue Queue length.
+# TYPE test_queue gauge
+test_queue 3
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{code="200"} 1
+test_requests_total{code="500"} 2
+# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 5.55
+test_seconds_count 3
+`
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestMetricsRegistry_ReRegister(t *testing.T) {
+	t.Parallel()
+	r := NewMetricsRegistry()
+	a := r.Counter("x_total", "X.", "k")
+	if b := r.Counter("x_total", "X.", "k"); a != b {
+		t.Fatal("registering the same counter twice returned a new one")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering x_total as a gauge did not panic")
+		}
+	}()
+	r.Gauge("x_total", "X.", "k")
+}
+
+func TestMetricsRegistry_EscapesLabels(t *testing.T) {
+	t.Parallel()
+	r := NewMetricsRegistry()
+	r.Counter("esc_total", "Escapes.", "v").Inc("a\"b\\c\nd")
+
+	var b strings.Builder
+	r.WritePrometheus(&b)
+	if !strings.Contains(b.String(), `esc_total{v="a\"b\\c\nd"} 1`) {
+		t.Fatalf("label not escaped:\n%s", b.String())
+	}
+}
+
+// fxCounted fails when the message says so.
+type fxCounted struct {
+	Node `spec:"id=Test.Metrics.Counted"`
+	fxLifecycle
+}
+
+func (n *fxCounted) OnMessage(ctx message.Context) error {
+	if ctx.GetBool("fail") {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestOnMessage_CountsMessages(t *testing.T) {
+	t.Parallel()
+	addTestHandler(t, "metrics-counted", Node{}, &fxCounted{})
+
+	const nodeType = "Test.Metrics.Counted"
+	s := &GRPCServer{}
+	s.OnMessage(context.Background(), onMessageRequest(t, "metrics-counted", `{}`))
+	s.OnMessage(context.Background(), onMessageRequest(t, "metrics-counted", `{"fail":true}`))
+
+	if got := messagesTotal.Value(nodeType); got != 2 {
+		t.Fatalf("messages_total = %v, want 2", got)
+	}
+	if got := messagesFailed.Value(nodeType); got != 1 {
+		t.Fatalf("messages_failed_total = %v, want 1", got)
+	}
+	if got := onMessageSeconds.Count(nodeType); got != 2 {
+		t.Fatalf("duration observations = %d, want 2", got)
+	}
+
+	resp, err := s.GetMetrics(context.Background(), &proto.Empty{})
+	if err != nil {
+		t.Fatalf("GetMetrics: %v", err)
+	}
+	if !strings.Contains(resp.Text, `robomotion_messages_total{node_type="Test.Metrics.Counted"} 2`) {
+		t.Fatalf("GetMetrics text lacks the node's counter:\n%s", resp.Text)
+	}
+}
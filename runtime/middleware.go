@@ -0,0 +1,138 @@
+package runtime
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// Middleware wraps a node's handler to run code around its lifecycle calls
+// (logging, metrics, auth checks, input validation) for every node of a
+// package without editing each node.
+//
+// A middleware handler should implement Unwrap() MessageHandler returning
+// next, so the runtime can still find interfaces the node implements, such
+// as SetupHandler. Handlers built with OnMessageMiddleware do.
+type Middleware func(next MessageHandler) MessageHandler
+
+// Unwrapper is implemented by handlers that wrap another handler.
+type Unwrapper interface {
+	Unwrap() MessageHandler
+}
+
+var (
+	middlewares []Middleware
+	mwMux       sync.Mutex
+)
+
+// Use registers middleware for every node created afterwards, so call it
+// before Start. Middleware run in registration order: the first one
+// registered is the outermost and sees each call first.
+//
+// Example:
+//
+//	runtime.Use(runtime.OnMessageMiddleware(func(next runtime.MessageHandler, ctx message.Context) error {
+//		start := time.Now()
+//		err := next.OnMessage(ctx)
+//		log.Printf("%s took %s", runtime.NodeOf(next).Name, time.Since(start))
+//		return err
+//	}))
+func Use(mw ...Middleware) {
+	mwMux.Lock()
+	defer mwMux.Unlock()
+	middlewares = append(middlewares, mw...)
+}
+
+// applyMiddleware wraps handler in the registered middleware.
+func applyMiddleware(handler MessageHandler) MessageHandler {
+	mwMux.Lock()
+	mws := append([]Middleware(nil), middlewares...)
+	mwMux.Unlock()
+
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+// onMessageMiddleware is the handler OnMessageMiddleware builds.
+type onMessageMiddleware struct {
+	next MessageHandler
+	fn   func(next MessageHandler, ctx message.Context) error
+}
+
+// OnMessageMiddleware builds a Middleware that intercepts OnMessage only.
+// fn decides whether and when to call next.OnMessage; OnCreate and OnClose
+// pass straight through.
+func OnMessageMiddleware(fn func(next MessageHandler, ctx message.Context) error) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return &onMessageMiddleware{next: next, fn: fn}
+	}
+}
+
+func (m *onMessageMiddleware) OnCreate() error { return m.next.OnCreate() }
+func (m *onMessageMiddleware) OnClose() error  { return m.next.OnClose() }
+func (m *onMessageMiddleware) OnMessage(ctx message.Context) error {
+	return m.fn(m.next, ctx)
+}
+func (m *onMessageMiddleware) Unwrap() MessageHandler { return m.next }
+
+// UnwrapHandler returns the node behind any number of middleware layers and
+// the ToolInterceptor, stopping at the first layer without Unwrap.
+func UnwrapHandler(h MessageHandler) MessageHandler {
+	for {
+		u, ok := h.(Unwrapper)
+		if !ok {
+			return h
+		}
+		inner := u.Unwrap()
+		if inner == nil {
+			return h
+		}
+		h = inner
+	}
+}
+
+// findHandler returns the outermost layer of h that matches, or false.
+func findHandler(h MessageHandler, match func(MessageHandler) bool) (MessageHandler, bool) {
+	for h != nil {
+		if match(h) {
+			return h, true
+		}
+		u, ok := h.(Unwrapper)
+		if !ok {
+			break
+		}
+		h = u.Unwrap()
+	}
+	return nil, false
+}
+
+// NodeOf returns the runtime.Node embedded in the node behind h, which
+// gives middleware the GUID and name of the node it wraps. It returns the
+// zero Node when the node does not embed one.
+func NodeOf(h MessageHandler) Node {
+	if n := embeddedNode(h); n != nil {
+		return *n
+	}
+	return Node{}
+}
+
+// embeddedNode returns a pointer to the Node embedded in the node behind h,
+// or nil when the node is not a struct pointer embedding one.
+func embeddedNode(h MessageHandler) *Node {
+	v := reflect.ValueOf(UnwrapHandler(h))
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.FieldByName("Node")
+	if !f.IsValid() || f.Type() != reflect.TypeOf(Node{}) {
+		return nil
+	}
+	return f.Addr().Interface().(*Node)
+}
@@ -0,0 +1,85 @@
+package runtime
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// useMiddleware registers mw for the duration of the test.
+func useMiddleware(t *testing.T, mw ...Middleware) {
+	t.Helper()
+	mwMux.Lock()
+	prev := middlewares
+	mwMux.Unlock()
+	Use(mw...)
+	t.Cleanup(func() {
+		mwMux.Lock()
+		middlewares = prev
+		mwMux.Unlock()
+	})
+}
+
+// recordingMiddleware appends name to calls around every OnMessage.
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return OnMessageMiddleware(func(next MessageHandler, ctx message.Context) error {
+		*calls = append(*calls, name+":before")
+		err := next.OnMessage(ctx)
+		*calls = append(*calls, name+":after")
+		return err
+	})
+}
+
+// fxSetupNode supports setup.
+type fxSetupNode struct {
+	fxNode
+}
+
+func (n *fxSetupNode) OnSetup(ctx SetupContext) error { return nil }
+
+// recordingNode appends "node" to calls in OnMessage.
+func recordingNode(n Node, calls *[]string) *fxSetupNode {
+	return &fxSetupNode{fxNode{Node: n, onMessage: func(message.Context) error {
+		*calls = append(*calls, "node")
+		return nil
+	}}}
+}
+
+func TestUse_RunsInRegistrationOrder(t *testing.T) {
+	var calls []string
+	useMiddleware(t, recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+
+	node := recordingNode(Node{}, &calls)
+	addTestHandler(t, "mw-order", Node{}, node)
+
+	if err := GetNodeHandler("mw-order").Handler.OnMessage(newCtx(`{}`)); err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	want := []string{"outer:before", "inner:before", "node", "inner:after", "outer:after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMiddleware_UnwrapsThroughLayers(t *testing.T) {
+	var calls []string
+	useMiddleware(t, recordingMiddleware("a", &calls), recordingMiddleware("b", &calls), recordingMiddleware("c", &calls))
+
+	node := recordingNode(Node{Name: "Setup"}, &calls)
+	addTestHandler(t, "mw-unwrap", Node{}, node)
+	h := GetNodeHandler("mw-unwrap").Handler
+
+	if AsSetupHandler(h) == nil {
+		t.Fatal("AsSetupHandler lost the node behind three middleware layers")
+	}
+	if UnwrapHandler(h) != node {
+		t.Fatalf("UnwrapHandler = %T, want the node", UnwrapHandler(h))
+	}
+	if NodeOf(h).Name != "Setup" {
+		t.Fatalf("NodeOf(h).Name = %q, want Setup", NodeOf(h).Name)
+	}
+	if AsSetupHandler(NewToolInterceptor(&fxPlain{})) != nil {
+		t.Fatal("AsSetupHandler found setup on a node without OnSetup")
+	}
+}
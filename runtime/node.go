@@ -11,9 +11,24 @@ type Node struct {
 	DelayBefore     float32
 	DelayAfter      float32
 	ContinueOnError bool
+
+	// Per-instance overrides of the node's RetryPolicy, set from the Retry
+	// options of the Options group. Zero keeps the declared policy.
+	OptRetryAttempts int
+	OptRetryDelay    float32
+
+	// Per-instance OnMessage timeout in seconds, set from the Timeout option
+	// of nodes that declare a timeout. Zero keeps the declared timeout.
+	OptTimeout float32
+
+	// helper is the RuntimeHelper of the runtime that created the node.
+	helper RuntimeHelper
 }
 
+// Init is called by the plugin host with the robot connection. It becomes
+// this node's helper and the package-level fallback.
 func (n *Node) Init(e RuntimeHelper) error {
+	n.helper = e
 	client = e
 	return nil
 }
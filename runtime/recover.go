@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// safeCall runs one node lifecycle call and converts a panic inside it into
+// an *Error with code ErrPanic and the goroutine's stack, so a broken node
+// fails its own call instead of taking down the package process and every
+// flow sharing it.
+func safeCall(op string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := string(debug.Stack())
+			hclog.Default().Error("runtime.panic", "op", op, "panic", r, "stack", stack)
+			err = &Error{
+				Code:    ErrPanic,
+				Message: fmt.Sprintf("%s panicked: %v", op, r),
+				Stack:   stack,
+			}
+		}
+	}()
+	return fn()
+}
+
+// isPanic reports whether err came from a recovered panic.
+func isPanic(err error) bool {
+	var rerr *Error
+	return errors.As(err, &rerr) && rerr.Code == ErrPanic
+}
+
+// reportPanic forwards a recovered panic to the robot through EmitError so it
+// shows up against the node in the Designer. Other errors are left to the
+// normal return path.
+func reportPanic(h RuntimeHelper, guid, name string, err error) {
+	if !isPanic(err) || h == nil {
+		return
+	}
+	if emitErr := h.EmitError(guid, name, err.Error()); emitErr != nil {
+		hclog.Default().Info("runtime.panic.emit", "err", emitErr)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"reflect"
 	"strings"
+	"syscall"
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
@@ -23,7 +24,7 @@ type PluginNode struct {
 
 var (
 	nc          int32
-	done        = make(chan bool, 1)
+	done        = make(chan int, 1) // exit code; see requestShutdown
 	ns          = ""
 	attached    = false
 	sessionMode bool // true when running as session daemon
@@ -38,7 +39,7 @@ var (
 			JSONFormat: true,
 		}),
 		// A non-nil value here enables gRPC serving for this plugin...
-		GRPCServer: plugin.DefaultGRPCServer,
+		GRPCServer: grpcServerWithHealth,
 	}
 )
 
@@ -66,6 +67,28 @@ func Start() {
 			return
 		}
 
+		if strings.HasPrefix(arg, "--session-metrics") {
+			if idx := strings.IndexByte(arg, '='); idx >= 0 {
+				SessionMetrics(arg[idx+1:])
+			} else if len(os.Args) > 2 {
+				SessionMetrics(os.Args[2])
+			} else {
+				log.Fatal("--session-metrics requires a session ID")
+			}
+			return
+		}
+
+		if strings.HasPrefix(arg, "--session-introspect") {
+			if idx := strings.IndexByte(arg, '='); idx >= 0 {
+				SessionIntrospect(arg[idx+1:])
+			} else if len(os.Args) > 2 {
+				SessionIntrospect(os.Args[2])
+			} else {
+				log.Fatal("--session-introspect requires a session ID")
+			}
+			return
+		}
+
 		config = ReadConfigFile()
 
 		name := config.Get("name").String()
@@ -119,6 +142,7 @@ func Start() {
 
 	initLogger()
 	os.Setenv(serveCfg.MagicCookieKey, serveCfg.MagicCookieValue)
+	startMetricsServer()
 
 	go plugin.Serve(serveCfg)
 	if attached {
@@ -129,21 +153,23 @@ func Start() {
 	RegisterFactories()
 
 	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, os.Interrupt)
-	signal.Notify(sc, os.Kill)
+	signal.Notify(sc, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-sc
-		done <- true
+		requestShutdown(exitOK)
 	}()
 
-	<-done
+	code := drain(<-done)
 
 	if attached {
 		debug.Detach(ns)
 	}
 
-	time.Sleep(time.Second * 2)
+	// Give the robot a moment to read the response of the call that
+	// triggered the shutdown (usually the last OnClose).
+	time.Sleep(500 * time.Millisecond)
+	os.Exit(code)
 }
 
 func RegisterFactories() {
@@ -0,0 +1,189 @@
+package runtime
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// RetryPolicy tells the runtime to run a failed OnMessage again. Declare it
+// on the Node spec tag:
+//
+//	runtime.Node `spec:"id=Acme.Fetch,...,retry=3,retryDelay=1s,retryOn=ErrRateLimited|ErrTimeout"`
+//
+// or implement RetryPolicyProvider. Flow authors can override the attempts
+// and delay per node instance from the Options group.
+type RetryPolicy struct {
+	// MaxAttempts counts the first call too; 0 or 1 disables retries.
+	MaxAttempts int
+	// InitialDelay is the wait before the first retry; each later wait is
+	// Multiplier times longer, up to MaxDelay.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// Jitter spreads every wait by up to ±Jitter of its length (0.2 = 20%)
+	// so nodes that failed together do not retry in lockstep. Like the
+	// other fields, 0 means the default; NoJitter turns it off.
+	Jitter float64
+	// RetryOn lists the error codes worth retrying. When empty, errors
+	// marked retryable (see IsRetryable) are retried.
+	RetryOn []string
+}
+
+// RetryPolicyProvider is implemented by nodes that compute their retry
+// policy in code. It takes precedence over the spec tag.
+type RetryPolicyProvider interface {
+	RetryPolicy() RetryPolicy
+}
+
+// NoJitter is the RetryPolicy.Jitter that keeps waits exactly as computed.
+const NoJitter = -1
+
+// Defaults for policy fields left zero.
+const (
+	defaultRetryDelay      = time.Second
+	defaultRetryMaxDelay   = 30 * time.Second
+	defaultRetryMultiplier = 2
+	defaultRetryJitter     = 0.2
+)
+
+// Enabled reports whether the policy retries at all.
+func (p RetryPolicy) Enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+// ShouldRetry reports whether err is worth another attempt.
+func (p RetryPolicy) ShouldRetry(err error) bool {
+	if err == nil || isPanic(err) {
+		return false
+	}
+	if len(p.RetryOn) == 0 {
+		return AsError(err).Retryable
+	}
+	code := AsError(err).Code
+	for _, c := range p.RetryOn {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the wait before retry number n (1 for the first retry).
+func (p RetryPolicy) Backoff(n int) time.Duration {
+	delay, maxDelay, mult, jitter := p.InitialDelay, p.MaxDelay, p.Multiplier, p.Jitter
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	if mult < 1 {
+		mult = defaultRetryMultiplier
+	}
+	switch {
+	case jitter == 0 || jitter > 1:
+		jitter = defaultRetryJitter
+	case jitter < 0:
+		jitter = 0
+	}
+
+	d := float64(delay) * math.Pow(mult, float64(n-1))
+	if d > float64(maxDelay) {
+		d = float64(maxDelay)
+	}
+	d += (rand.Float64()*2 - 1) * jitter * d
+	return time.Duration(d)
+}
+
+// withOverrides applies a node instance's Retry options.
+func (p RetryPolicy) withOverrides(node Node) RetryPolicy {
+	if node.OptRetryAttempts > 0 {
+		p.MaxAttempts = node.OptRetryAttempts
+	}
+	if node.OptRetryDelay > 0 {
+		p.InitialDelay = delayDuration(node.OptRetryDelay)
+	}
+	return p
+}
+
+// retryPolicyOf returns the policy a node declares, from RetryPolicyProvider
+// or its Node spec tag. A node that declares none gets the zero policy.
+func retryPolicyOf(handler MessageHandler) RetryPolicy {
+	if rp, ok := handler.(RetryPolicyProvider); ok {
+		return rp.RetryPolicy()
+	}
+
+	t := reflect.TypeOf(handler)
+	if t == nil {
+		return RetryPolicy{}
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return RetryPolicy{}
+	}
+	field, ok := t.FieldByName("Node")
+	if !ok {
+		return RetryPolicy{}
+	}
+	return retryPolicyFromSpec(parseSpec(field.Tag.Get("spec")))
+}
+
+// retryPolicyFromSpec reads the retry, retryDelay, retryMaxDelay and
+// retryOn keys of a Node spec tag.
+func retryPolicyFromSpec(nsMap map[string]string) RetryPolicy {
+	var p RetryPolicy
+	p.MaxAttempts, _ = strconv.Atoi(nsMap["retry"])
+	p.InitialDelay, _ = time.ParseDuration(nsMap["retryDelay"])
+	p.MaxDelay, _ = time.ParseDuration(nsMap["retryMaxDelay"])
+	if on := nsMap["retryOn"]; on != "" {
+		p.RetryOn = strings.Split(on, "|")
+	}
+	return p
+}
+
+// retryMessage runs attempt under policy. Each attempt builds a fresh
+// message from the input, so writes made by a failed attempt never leak
+// into the next one; the message of the last attempt is returned with its
+// error. An attempt abandoned as stuck, whose message is nil, is not
+// retried.
+func retryMessage(ctx context.Context, policy RetryPolicy, guid string, attempt func() (message.Context, error)) (message.Context, error) {
+	for n := 1; ; n++ {
+		msgCtx, err := attempt()
+		if err == nil || msgCtx == nil || n >= policy.MaxAttempts || !policy.ShouldRetry(err) || ctx.Err() != nil {
+			return msgCtx, err
+		}
+
+		delay := policy.Backoff(n)
+		hclog.Default().Info("runtime.retry", "guid", guid, "attempt", n, "delay", delay, "err", err)
+		if sleepErr := Sleep(ctx, delay); sleepErr != nil {
+			return msgCtx, err
+		}
+	}
+}
+
+// addRetryOptions adds the Retry options to a node's Options group so flow
+// authors can tune its policy per instance. The form defaults are the
+// declared policy; the values land in Node.OptRetryAttempts/OptRetryDelay.
+func addRetryOptions(optProperty *Property, policy RetryPolicy) {
+	delay := policy.InitialDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	optProperty.Schema.Properties["optRetryAttempts"] = SProperty{Type: "integer", Title: "Retry Attempts"}
+	optProperty.Schema.Properties["optRetryDelay"] = SProperty{Type: "double", Title: "Retry Delay (sec)"}
+	optProperty.FormData["optRetryAttempts"] = policy.MaxAttempts
+	optProperty.FormData["optRetryDelay"] = delay.Seconds()
+	optProperty.UISchema["ui:order"] = append(optProperty.UISchema["ui:order"].([]string), "optRetryAttempts", "optRetryDelay")
+}
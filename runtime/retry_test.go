@@ -0,0 +1,132 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// fxFlaky fails with err until it has been called failures times. Each
+// failed attempt writes "dirty" into the message first.
+type fxFlaky struct {
+	Node `spec:"id=Test.Flaky,name=Flaky,icon=,color=#000,retry=3,retryDelay=1ms,retryOn=ErrRateLimited"`
+	fxLifecycle
+	failures int
+	calls    int
+	err      error
+}
+
+func (n *fxFlaky) OnMessage(ctx message.Context) error {
+	n.calls++
+	if n.calls <= n.failures {
+		ctx.Set("dirty", true)
+		return n.err
+	}
+	ctx.Set("attempt", n.calls)
+	return nil
+}
+
+func TestOnMessage_RetriesPerSpecTag(t *testing.T) {
+	n := &fxFlaky{failures: 2, err: NewError(ErrRateLimited, "slow down")}
+	addTestHandler(t, "retry-ok", Node{}, n)
+
+	s := &GRPCServer{}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "retry-ok", `{}`))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	if n.calls != 3 {
+		t.Fatalf("calls = %d, want 3", n.calls)
+	}
+	if payloadOf(resp.OutMessage) != `{"attempt":3}` {
+		t.Fatalf("OutMessage = %s, want only the last attempt's writes", resp.OutMessage)
+	}
+}
+
+func TestOnMessage_RetryStopsOnOtherCodesAndAtMaxAttempts(t *testing.T) {
+	n := &fxFlaky{failures: 5, err: NewError(ErrNotFound, "gone")}
+	addTestHandler(t, "retry-code", Node{}, n)
+	s := &GRPCServer{}
+	if _, err := s.OnMessage(context.Background(), onMessageRequest(t, "retry-code", `{}`)); err == nil || n.calls != 1 {
+		t.Fatalf("err = %v, calls = %d; want an error after 1 call", err, n.calls)
+	}
+
+	n = &fxFlaky{failures: 5, err: NewError(ErrRateLimited, "slow down")}
+	addTestHandler(t, "retry-max", Node{OptRetryAttempts: 2}, n)
+	if _, err := s.OnMessage(context.Background(), onMessageRequest(t, "retry-max", `{}`)); err == nil || n.calls != 2 {
+		t.Fatalf("err = %v, calls = %d; want an error after the overridden 2 attempts", err, n.calls)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2, Jitter: 0.1}
+	cases := []struct {
+		n    int
+		base time.Duration
+	}{{1, 100 * time.Millisecond}, {2, 200 * time.Millisecond}, {3, 400 * time.Millisecond}, {10, time.Second}}
+	for _, c := range cases {
+		for i := 0; i < 20; i++ {
+			d := p.Backoff(c.n)
+			if d < c.base*9/10 || d > c.base*11/10 {
+				t.Fatalf("Backoff(%d) = %s, want %s ±10%%", c.n, d, c.base)
+			}
+		}
+	}
+
+	p.Jitter = NoJitter
+	for i := 0; i < 20; i++ {
+		if d := p.Backoff(2); d != 200*time.Millisecond {
+			t.Fatalf("Backoff(2) with NoJitter = %s, want 200ms", d)
+		}
+	}
+	if got := retryPolicyOf(&fxRetryProvider{policy: p}).Jitter; got != NoJitter {
+		t.Fatalf("provider's NoJitter became %v", got)
+	}
+}
+
+// fxRetryProvider declares its policy in code.
+type fxRetryProvider struct {
+	fxNode
+	policy RetryPolicy
+}
+
+func (n *fxRetryProvider) RetryPolicy() RetryPolicy { return n.policy }
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	var p RetryPolicy
+	if !p.ShouldRetry(NewError(ErrTimeout, "")) || p.ShouldRetry(NewError(ErrNotFound, "")) {
+		t.Fatal("without RetryOn, only retryable errors should be retried")
+	}
+	if p.ShouldRetry(&Error{Code: ErrPanic, Retryable: true}) {
+		t.Fatal("panics must never be retried")
+	}
+	p.RetryOn = []string{ErrNotFound}
+	if !p.ShouldRetry(NewError(ErrNotFound, "")) || p.ShouldRetry(NewError(ErrTimeout, "")) {
+		t.Fatal("RetryOn did not replace the retryable flag")
+	}
+}
+
+func TestSpec_RetryOptionsInOptionsGroup(t *testing.T) {
+	pspec := captureSpec(t, &fxFlaky{}, &fxPlain{})
+
+	var opts map[string]interface{}
+	for _, p := range nodeByID(t, pspec, "Test.Flaky")["properties"].([]interface{}) {
+		prop := p.(map[string]interface{})
+		if prop["schema"].(map[string]interface{})["title"] == "Options" {
+			opts = prop
+		}
+	}
+	if opts == nil {
+		t.Fatal("Test.Flaky has no Options group")
+	}
+	formData := opts["formData"].(map[string]interface{})
+	if formData["optRetryAttempts"] != float64(3) || formData["optRetryDelay"] != 0.001 {
+		t.Fatalf("retry formData = %v", formData)
+	}
+
+	if props := nodeByID(t, pspec, "Test.Plain")["properties"]; props != nil {
+		t.Fatalf("Test.Plain without a retry policy got properties: %v", props)
+	}
+}
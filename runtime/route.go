@@ -0,0 +1,155 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/robomotionio/robomotion-go/message"
+	"github.com/robomotionio/robomotion-go/proto"
+)
+
+// routeOutputs delivers the messages a node sent with RouteTo/SendTo. The
+// first port-0 message rides in resp.OutMessage as before; every other one
+// goes to the robot through EmitOutput, or into resp.Outputs for the CLI
+// session daemon, whose client has no robot to emit to. Nothing is sent
+// when a port is not one of the node's outputs ports.
+func routeOutputs(h RuntimeHelper, guid string, outputs int, msgCtx message.Context, resp *proto.OnMessageResponse) error {
+	outs := routedOutputs(msgCtx, outputs)
+	if err := checkPorts(outs, outputs); err != nil {
+		return err
+	}
+	for _, out := range outs {
+		if out.Message == nil || out.Message.IsEmpty() {
+			continue
+		}
+
+		msg, err := outputBytes(out.Message)
+		if err != nil {
+			return err
+		}
+		// Responses and EmitOutput carry the message uncompressed.
+		countBytes("out", "raw", len(msg))
+		countBytes("out", "wire", len(msg))
+
+		switch {
+		case out.Port == 0 && resp.OutMessage == nil:
+			resp.OutMessage = msg
+		case sessionMode:
+			resp.Outputs = append(resp.Outputs, &proto.PortMessage{Port: int32(out.Port), Message: msg})
+		default:
+			if h == nil {
+				return errNotInitialized
+			}
+			if err := h.EmitOutput(guid, msg, int32(out.Port)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// routedOutputs returns the messages msgCtx goes out with. A node without
+// outputs drops the message it did not route; routing one is an error.
+func routedOutputs(msgCtx message.Context, outputs int) []message.Output {
+	if outputs == 0 && message.IsDefaultRoute(msgCtx) {
+		return nil
+	}
+	return msgCtx.Outputs()
+}
+
+// checkPorts returns an ErrInvalidArg error for the first message sent to a
+// port outside 0 to outputs-1.
+func checkPorts(outs []message.Output, outputs int) error {
+	for _, out := range outs {
+		switch {
+		case out.Port < 0:
+			return NewError(ErrInvalidArg, fmt.Sprintf("output port %d is negative", out.Port))
+		case out.Port >= outputs:
+			return NewError(ErrInvalidArg, fmt.Sprintf("output port %d does not exist; the node has %d outputs", out.Port, outputs))
+		}
+	}
+	return nil
+}
+
+// outputsOf returns the number of output ports handler's node declares with
+// the outputs key of its spec tag, 1 when it declares none or a value that
+// is not a count.
+func outputsOf(handler MessageHandler) int {
+	t := reflect.TypeOf(handler)
+	if t == nil {
+		return 1
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return 1
+	}
+	field, ok := t.FieldByName("Node")
+	if !ok {
+		return 1
+	}
+	v, ok := parseSpec(field.Tag.Get("spec"))["outputs"]
+	if !ok {
+		return 1
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 1
+	}
+	return n
+}
+
+// outputBytes returns the wire form of an outgoing message, packing large
+// values into LMO blobs when the robot supports them.
+func outputBytes(msgCtx message.Context) ([]byte, error) {
+	msg := []byte(message.PackedBytes(msgCtx))
+	if msg == nil {
+		// Not a runtime message (e.g. a test double passed to SendTo).
+		raw, err := msgCtx.GetRaw()
+		if err != nil {
+			return nil, err
+		}
+		msg = raw
+	}
+
+	if HasCapability(CapabilityLMO) {
+		_, span := StartSpan(msgCtx.Context(), "lmo.pack")
+		packed, packErr := LMOPack(msg)
+		if packErr == nil {
+			msg = packed
+		}
+		span.Finish(packErr)
+	}
+	return msg, nil
+}
+
+// cliOutputs describes where a routed message went, for the CLI result.
+// Each entry is {"port": n, "message": <decoded message>}.
+func cliOutputs(outs []message.Output) []map[string]interface{} {
+	var list []map[string]interface{}
+	for _, out := range outs {
+		if out.Message == nil || out.Message.IsEmpty() {
+			continue
+		}
+		raw, err := out.Message.GetRaw()
+		if err != nil {
+			raw = message.PackedBytes(out.Message)
+		}
+		list = append(list, map[string]interface{}{"port": out.Port, "message": decodeCLIMessage(raw)})
+	}
+	return list
+}
+
+// decodeCLIMessage decodes a message for printing, without its envelope,
+// falling back to its text when it is not JSON.
+func decodeCLIMessage(raw []byte) interface{} {
+	raw, _ = message.SplitMeta(raw)
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	return v
+}
@@ -0,0 +1,213 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// fxRouter routes by the "ok" field: true goes out of port 0, false out of
+// port 1, and every message also sends an audit copy out of port 2, or out
+// of the port in "audit" when set.
+type fxRouter struct {
+	Node `spec:"id=Test.Router,outputs=3"`
+	fxLifecycle
+}
+
+func (n *fxRouter) OnMessage(ctx message.Context) error {
+	if !ctx.GetBool("ok") {
+		ctx.RouteTo(1)
+	}
+	audit := 2
+	if ctx.Has("audit") {
+		audit = int(ctx.GetInt("audit"))
+	}
+	ctx.SendTo(audit, message.NewContext([]byte(`{"audit":true}`)))
+	return nil
+}
+
+// ports lists the ports of EmitOutput calls.
+func ports(calls []fxCall) []int32 {
+	var ports []int32
+	for _, c := range calls {
+		ports = append(ports, c.port)
+	}
+	return ports
+}
+
+func TestOnMessage_DefaultPortStaysInResponse(t *testing.T) {
+	t.Parallel()
+	e := &fxHelper{}
+	addTestHandler(t, "route-ok", Node{}, &fxRouter{})
+
+	s := &GRPCServer{helper: e}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "route-ok", `{"ok":true}`))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	if payloadOf(resp.OutMessage) != `{"ok":true}` {
+		t.Fatalf("OutMessage = %s", resp.OutMessage)
+	}
+	if calls := e.outputCalls(); len(calls) != 1 || calls[0].port != 2 || payloadOf(calls[0].out) != `{"audit":true}` {
+		t.Fatalf("EmitOutput calls = %v, want one audit message on port 2", calls)
+	}
+}
+
+func TestOnMessage_RouteToMovesMessageOffPortZero(t *testing.T) {
+	t.Parallel()
+	e := &fxHelper{}
+	addTestHandler(t, "route-fail", Node{}, &fxRouter{})
+
+	s := &GRPCServer{helper: e}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "route-fail", `{"ok":false}`))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	if resp.OutMessage != nil {
+		t.Fatalf("OutMessage = %s, want nil when routed away from port 0", resp.OutMessage)
+	}
+	if got := ports(e.outputCalls()); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("EmitOutput ports = %v, want [1 2]", got)
+	}
+}
+
+func TestOnMessage_SessionModeReturnsPorts(t *testing.T) {
+	e := &fxHelper{}
+	sessionMode = true
+	t.Cleanup(func() { sessionMode = false })
+	addTestHandler(t, "route-session", Node{}, &fxRouter{})
+
+	s := &GRPCServer{helper: e}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "route-session", `{"ok":false}`))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	if got := ports(e.outputCalls()); len(got) != 0 {
+		t.Fatalf("session mode called EmitOutput: %v", got)
+	}
+	if len(resp.Outputs) != 2 || resp.Outputs[0].Port != 1 || resp.Outputs[1].Port != 2 {
+		t.Fatalf("resp.Outputs = %v, want ports 1 and 2", resp.Outputs)
+	}
+}
+
+func TestOnMessage_UndeclaredPortIsAnError(t *testing.T) {
+	t.Parallel()
+	e := &fxHelper{}
+	addTestHandler(t, "route-bad", Node{}, &fxRouter{})
+
+	s := &GRPCServer{helper: e}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "route-bad", `{"ok":false,"audit":3}`))
+	if ErrorCode(errorFromStatus(err)) != ErrInvalidArg {
+		t.Fatalf("OnMessage err = %v, want ErrInvalidArg", err)
+	}
+	if calls := e.outputCalls(); len(calls) != 0 {
+		t.Fatalf("EmitOutput calls = %v, want none", calls)
+	}
+	if outputsOf(&fxNode{}) != 1 || outputsOf(&fxRouter{}) != 3 {
+		t.Fatal("outputsOf does not follow the spec tag")
+	}
+}
+
+func TestOnMessage_FailedCallSendsNothing(t *testing.T) {
+	t.Parallel()
+	e := &fxHelper{}
+	addTestHandler(t, "route-err", Node{}, fxFunc(func(ctx message.Context) error {
+		ctx.SendTo(0, message.NewContext([]byte(`{"partial":true}`)))
+		return NewError(ErrUnknown, "failed after sending")
+	}))
+
+	s := &GRPCServer{helper: e}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "route-err", `{}`))
+	if ErrorCode(errorFromStatus(err)) != ErrUnknown {
+		t.Fatalf("OnMessage err = %v, want ErrUnknown", err)
+	}
+	if resp.OutMessage != nil || len(e.outputCalls()) != 0 {
+		t.Fatalf("failed call sent %s and %v, want nothing", resp.OutMessage, e.outputCalls())
+	}
+}
+
+func TestOnMessage_ContinueOnErrorStillRoutes(t *testing.T) {
+	t.Parallel()
+	e := &fxHelper{}
+	addTestHandler(t, "route-continue", Node{ContinueOnError: true}, fxFunc(func(ctx message.Context) error {
+		ctx.SendTo(0, message.NewContext([]byte(`{"partial":true}`)))
+		return NewError(ErrUnknown, "failed after sending")
+	}))
+
+	s := &GRPCServer{helper: e}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "route-continue", `{}`))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	if resp.OutMessage == nil || len(e.outputCalls()) != 1 {
+		t.Fatalf("ContinueOnError sent %s and %v, want the message and its copy", resp.OutMessage, e.outputCalls())
+	}
+}
+
+// fxSink is a node without outputs; it sends a copy out of the port in
+// "send" when set.
+type fxSink struct {
+	Node `spec:"id=Test.Sink,inputs=1,outputs=0"`
+	fxLifecycle
+}
+
+func (n *fxSink) OnMessage(ctx message.Context) error {
+	if ctx.Has("send") {
+		ctx.SendTo(int(ctx.GetInt("send")), message.NewContext([]byte(`{}`)))
+	}
+	return nil
+}
+
+func TestOnMessage_SinkDropsItsMessage(t *testing.T) {
+	t.Parallel()
+	e := &fxHelper{}
+	addTestHandler(t, "route-sink", Node{}, &fxSink{})
+
+	s := &GRPCServer{helper: e}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "route-sink", `{"a":1}`))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+	if resp.OutMessage != nil || len(e.outputCalls()) != 0 {
+		t.Fatalf("sink sent %s and %v, want nothing", resp.OutMessage, e.outputCalls())
+	}
+
+	_, err = s.OnMessage(context.Background(), onMessageRequest(t, "route-sink", `{"send":0}`))
+	if ErrorCode(errorFromStatus(err)) != ErrInvalidArg {
+		t.Fatalf("SendTo(0) on a sink: err = %v, want ErrInvalidArg", err)
+	}
+	if outputsOf(&fxSink{}) != 0 {
+		t.Fatal("outputsOf(outputs=0) is not 0")
+	}
+}
+
+// fxOddOutputs declares an outputs value that is not a count.
+type fxOddOutputs struct {
+	Node `spec:"id=Test.OddOutputs,outputs=two"`
+	fxLifecycle
+}
+
+func (n *fxOddOutputs) OnMessage(ctx message.Context) error { return nil }
+
+func TestOutputsOf_UnparsableIsOne(t *testing.T) {
+	t.Parallel()
+	if n := outputsOf(&fxOddOutputs{}); n != 1 {
+		t.Fatalf("outputsOf(outputs=two) = %d, want 1", n)
+	}
+}
+
+func TestIsDefaultRoute(t *testing.T) {
+	ctx := newCtx(`{}`)
+	if !message.IsDefaultRoute(ctx) {
+		t.Fatal("fresh context is not on the default route")
+	}
+	ctx.RouteTo(0)
+	if !message.IsDefaultRoute(ctx) {
+		t.Fatal("RouteTo(0) is not the default route")
+	}
+	ctx.RouteTo(1)
+	if message.IsDefaultRoute(ctx) {
+		t.Fatal("RouteTo(1) reported as the default route")
+	}
+}
@@ -0,0 +1,452 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// InputSchemaProvider is implemented by nodes that declare what they expect
+// in the incoming message, as a JSON Schema. The runtime checks every
+// message against it before OnMessage, in flows and in CLI mode, and fails
+// the call with ErrInvalidInput listing each violated path, so bad upstream
+// data is reported where it enters instead of as a nil error deep in the
+// node. The schema is also emitted into the node spec for the Designer.
+//
+//	func (n *CreateOrder) InputSchema() map[string]interface{} {
+//	    return runtime.SchemaOf(Order{}) // or a JSON Schema written by hand
+//	}
+//
+// The checks cover type, properties, required, additionalProperties, items,
+// enum, const, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, minItems and maxItems; other keywords are
+// ignored. LMO blobs are resolved before the check.
+type InputSchemaProvider interface {
+	InputSchema() map[string]interface{}
+}
+
+// AsInputSchemaProvider returns the InputSchemaProvider for a stored
+// handler, unwrapping the ToolInterceptor and middleware, or nil.
+func AsInputSchemaProvider(h MessageHandler) InputSchemaProvider {
+	found, ok := findHandler(h, func(h MessageHandler) bool {
+		_, ok := h.(InputSchemaProvider)
+		return ok
+	})
+	if !ok {
+		return nil
+	}
+	return found.(InputSchemaProvider)
+}
+
+// inputSchema is a node's input schema with its patterns compiled, once,
+// when the handler is created.
+type inputSchema struct {
+	schema   map[string]interface{}
+	patterns map[string]*regexp.Regexp
+	// err reports patterns that do not compile; every message fails with it.
+	err error
+}
+
+// inputSchemaOf returns the compiled input schema of handler, or nil.
+func inputSchemaOf(handler MessageHandler) *inputSchema {
+	p := AsInputSchemaProvider(handler)
+	if p == nil {
+		return nil
+	}
+	compiled := compileSchema(p.InputSchema())
+	if compiled != nil && compiled.err != nil {
+		hclog.Default().Error("runtime.schema", "node", nodeTypeOf(handler), "err", compiled.err)
+	}
+	return compiled
+}
+
+// compileSchema compiles every pattern in schema, at the keywords
+// validateValue follows, or returns nil for no schema.
+func compileSchema(schema map[string]interface{}) *inputSchema {
+	if schema == nil {
+		return nil
+	}
+	s := &inputSchema{schema: schema, patterns: map[string]*regexp.Regexp{}}
+	var bad []string
+	var walk func(schema map[string]interface{}, path string)
+	walk = func(schema map[string]interface{}, path string) {
+		if pattern, ok := schema["pattern"].(string); ok {
+			if _, done := s.patterns[pattern]; !done {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					bad = append(bad, SchemaViolation{Path: path, Message: fmt.Sprintf("pattern %q: %v", pattern, err)}.String())
+				}
+				s.patterns[pattern] = re
+			}
+		}
+		if items := schemaMap(schema["items"]); items != nil {
+			walk(items, path+"[]")
+		}
+		for name, prop := range schemaMap(schema["properties"]) {
+			if prop := schemaMap(prop); prop != nil {
+				walk(prop, joinPath(path, name))
+			}
+		}
+		if extra := schemaMap(schema["additionalProperties"]); extra != nil {
+			walk(extra, joinPath(path, "*"))
+		}
+	}
+	walk(schema, "")
+	if len(bad) > 0 {
+		sort.Strings(bad)
+		s.err = fmt.Errorf("invalid input schema: %s", strings.Join(bad, "; "))
+	}
+	return s
+}
+
+// SchemaViolation is one place where a message breaks its schema. Path is
+// in message path syntax, e.g. "order.items[2].sku"; "" is the message.
+type SchemaViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// validateInput checks the message data against schema. It returns an
+// ErrInvalidInput *Error with the violations in its "violations" detail.
+func validateInput(schema *inputSchema, data []byte) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.err != nil {
+		return schema.err
+	}
+	if resolved, err := LMOResolveAll(data); err == nil {
+		data = resolved
+	}
+	var msg interface{} = map[string]interface{}{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return WrapError(ErrInvalidInput, err)
+		}
+	}
+
+	violations := schema.validate(msg)
+	if len(violations) == 0 {
+		return nil
+	}
+	parts := make([]string, len(violations))
+	for i, v := range violations {
+		parts[i] = v.String()
+	}
+	return NewError(ErrInvalidInput, "input message does not match the schema: "+strings.Join(parts, "; ")).
+		WithDetail("violations", violations)
+}
+
+func (v SchemaViolation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// ValidateSchema checks value, as decoded by encoding/json, against a JSON
+// Schema and returns every violation, sorted by path. Keys starting with
+// "__" at the top level, which the runtime and robot add to messages, are
+// never reported as additional properties. A pattern that does not compile
+// is reported at each value it applies to.
+func ValidateSchema(schema map[string]interface{}, value interface{}) []SchemaViolation {
+	if schema == nil {
+		return nil
+	}
+	return compileSchema(schema).validate(value)
+}
+
+func (s *inputSchema) validate(value interface{}) []SchemaViolation {
+	var out []SchemaViolation
+	validateValue(s.schema, s.patterns, value, "", &out)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func validateValue(schema map[string]interface{}, patterns map[string]*regexp.Regexp, value interface{}, path string, out *[]SchemaViolation) {
+	fail := func(format string, args ...interface{}) {
+		*out = append(*out, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		got := jsonTypeOf(value)
+		ok := false
+		for _, t := range types {
+			if t == got || (t == "number" && got == "integer") {
+				ok = true
+			}
+		}
+		if !ok {
+			fail("is %s, want %s", got, strings.Join(types, " or "))
+			return
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsJSON(enum, value) {
+		fail("is %s, want one of %s", jsonText(value), jsonText(enum))
+	}
+	if c, ok := schema["const"]; ok && !equalJSON(c, value) {
+		fail("is %s, want %s", jsonText(value), jsonText(c))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if min, ok := schemaNumber(schema["minimum"]); ok && v < min {
+			fail("is %v, want at least %v", v, min)
+		}
+		if max, ok := schemaNumber(schema["maximum"]); ok && v > max {
+			fail("is %v, want at most %v", v, max)
+		}
+		if min, ok := schemaNumber(schema["exclusiveMinimum"]); ok && v <= min {
+			fail("is %v, want more than %v", v, min)
+		}
+		if max, ok := schemaNumber(schema["exclusiveMaximum"]); ok && v >= max {
+			fail("is %v, want less than %v", v, max)
+		}
+
+	case string:
+		n := float64(len([]rune(v)))
+		if min, ok := schemaNumber(schema["minLength"]); ok && n < min {
+			fail("is %d characters, want at least %v", int(n), min)
+		}
+		if max, ok := schemaNumber(schema["maxLength"]); ok && n > max {
+			fail("is %d characters, want at most %v", int(n), max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re := patterns[pattern]; re == nil {
+				fail("cannot be checked against %q, which does not compile", pattern)
+			} else if !re.MatchString(v) {
+				fail("does not match %q", pattern)
+			}
+		}
+
+	case []interface{}:
+		n := float64(len(v))
+		if min, ok := schemaNumber(schema["minItems"]); ok && n < min {
+			fail("has %d items, want at least %v", len(v), min)
+		}
+		if max, ok := schemaNumber(schema["maxItems"]); ok && n > max {
+			fail("has %d items, want at most %v", len(v), max)
+		}
+		if items := schemaMap(schema["items"]); items != nil {
+			for i, item := range v {
+				validateValue(items, patterns, item, path+"["+strconv.Itoa(i)+"]", out)
+			}
+		}
+
+	case map[string]interface{}:
+		props := schemaMap(schema["properties"])
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				*out = append(*out, SchemaViolation{Path: joinPath(path, name), Message: "is required"})
+			}
+		}
+		for name, child := range v {
+			if prop := schemaMap(props[name]); prop != nil {
+				validateValue(prop, patterns, child, joinPath(path, name), out)
+				continue
+			}
+			if path == "" && strings.HasPrefix(name, "__") {
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					*out = append(*out, SchemaViolation{Path: joinPath(path, name), Message: "is not allowed"})
+				}
+			case map[string]interface{}:
+				validateValue(extra, patterns, child, joinPath(path, name), out)
+			}
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// jsonTypeOf names the JSON Schema type of a decoded value.
+func jsonTypeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func schemaTypes(v interface{}) []string {
+	if s, ok := v.(string); ok {
+		return []string{s}
+	}
+	return schemaStrings(v)
+}
+
+func schemaStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// schemaNumber reads a number from a schema built in Go or decoded JSON.
+func schemaNumber(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func containsJSON(list []interface{}, v interface{}) bool {
+	for _, item := range list {
+		if equalJSON(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalJSON compares a schema value, possibly built in Go, with a decoded
+// message value.
+func equalJSON(a, b interface{}) bool {
+	return jsonText(a) == jsonText(b)
+}
+
+func jsonText(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage(nil))
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf derives a JSON Schema from the Go type of v, the way
+// encoding/json would encode it: struct fields by their json names, and a
+// field is required unless it is a pointer or tagged omitempty.
+func SchemaOf(v interface{}) map[string]interface{} {
+	return schemaOfType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaOfType(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawType, t.Implements(marshalerType), reflect.PtrTo(t).Implements(marshalerType):
+		return map[string]interface{}{} // anything
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"} // base64
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOfType(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOfType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"} // recursive type
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := map[string]interface{}{}
+		var required []interface{}
+		addStructFields(t, props, &required, seen)
+		schema := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+func addStructFields(t reflect.Type, props map[string]interface{}, required *[]interface{}, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, props, required, seen)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = schemaOfType(f.Type, seen)
+		if f.Type.Kind() != reflect.Ptr && !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
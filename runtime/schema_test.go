@@ -0,0 +1,256 @@
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+type fxOrderLine struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+type fxOrder struct {
+	ID      string        `json:"id"`
+	Lines   []fxOrderLine `json:"lines"`
+	Note    string        `json:"note,omitempty"`
+	Due     *time.Time    `json:"due"`
+	private int
+}
+
+// fxSchema declares its input with SchemaOf and counts its calls.
+type fxSchema struct {
+	Node `spec:"id=Test.Schema,name=Test Schema,icon=,color=#000"`
+	fxLifecycle
+	calls int32
+}
+
+func (n *fxSchema) OnMessage(ctx message.Context) error {
+	atomic.AddInt32(&n.calls, 1)
+	return nil
+}
+
+func (n *fxSchema) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"order": SchemaOf(fxOrder{})},
+		"required":   []string{"order"},
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	t.Parallel()
+	got := SchemaOf(fxOrder{})
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"type": "string"},
+			"lines": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"sku": map[string]interface{}{"type": "string"},
+						"qty": map[string]interface{}{"type": "integer"},
+					},
+					"required": []interface{}{"sku", "qty"},
+				},
+			},
+			"note": map[string]interface{}{"type": "string"},
+			"due":  map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+		"required": []interface{}{"id", "lines"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SchemaOf = %s\nwant %s", jsonText(got), jsonText(want))
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+			"age":   map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 150},
+			"kind":  map[string]interface{}{"enum": []interface{}{"a", "b"}},
+			"tags":  map[string]interface{}{"type": "array", "maxItems": 2, "items": map[string]interface{}{"type": "string"}},
+			"score": map[string]interface{}{"type": []interface{}{"number", "null"}},
+		},
+		"required":             []interface{}{"name"},
+		"additionalProperties": false,
+	}
+	cases := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"valid", `{"name":"bob","age":40,"kind":"a","tags":["x"],"score":null,"__trace__":{}}`, nil},
+		{"missing", `{}`, []string{"name: is required"}},
+		{"null", `{"name":null}`, []string{"name: is null, want string"}},
+		{"types", `{"name":1,"age":1.5,"score":"x"}`, []string{
+			"age: is number, want integer",
+			"name: is integer, want string",
+			"score: is string, want number or null",
+		}},
+		{"bounds", `{"name":"B","age":200,"kind":"c","tags":["x",2,"z"],"extra":1}`, []string{
+			"age: is 200, want at most 150",
+			"extra: is not allowed",
+			"kind: is \"c\", want one of [\"a\",\"b\"]",
+			"name: is 1 characters, want at least 2",
+			"name: does not match \"^[a-z]+$\"",
+			"tags: has 3 items, want at most 2",
+			"tags[1]: is integer, want string",
+		}},
+	}
+	for _, tc := range cases {
+		err := validateInput(compileSchema(schema), []byte(tc.raw))
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("%s: err = %v, want nil", tc.name, err)
+			}
+			continue
+		}
+		rerr := AsError(err)
+		if rerr == nil || rerr.Code != ErrInvalidInput {
+			t.Fatalf("%s: err = %v, want %s", tc.name, err, ErrInvalidInput)
+		}
+		violations, _ := rerr.Details["violations"].([]SchemaViolation)
+		var got []string
+		for _, v := range violations {
+			got = append(got, v.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%s: violations\n%s\nwant\n%s", tc.name, strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(rerr.Message, w) {
+				t.Errorf("%s: message %q does not list %q", tc.name, rerr.Message, w)
+			}
+		}
+	}
+}
+
+// fxBadPattern declares a pattern that does not compile.
+type fxBadPattern struct {
+	Node `spec:"id=Test.BadPattern"`
+	fxLifecycle
+}
+
+func (n *fxBadPattern) OnMessage(message.Context) error { return nil }
+func (n *fxBadPattern) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "pattern": "[a-"}},
+		},
+	}
+}
+
+func TestInputSchema_BadPatternIsReported(t *testing.T) {
+	t.Parallel()
+	addTestHandler(t, "schema-bad-pattern", Node{}, &fxBadPattern{})
+	compiled := GetNodeHandler("schema-bad-pattern").inputSchema
+	if compiled == nil || compiled.err == nil || !strings.Contains(compiled.err.Error(), `tags[]: pattern "[a-"`) {
+		t.Fatalf("compiled schema err = %v, want the bad pattern named", compiled.err)
+	}
+
+	s := &GRPCServer{}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "schema-bad-pattern", `{"tags":[]}`))
+	if err == nil || !strings.Contains(err.Error(), "invalid input schema") {
+		t.Fatalf("OnMessage err = %v, want the schema error", err)
+	}
+	got := ValidateSchema((&fxBadPattern{}).InputSchema(), map[string]interface{}{"tags": []interface{}{"x"}})
+	if len(got) != 1 || !strings.Contains(got[0].String(), "tags[0]: cannot be checked") {
+		t.Fatalf("ValidateSchema = %v, want the value the pattern applies to", got)
+	}
+}
+
+func TestOnMessage_RejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+	n := &fxSchema{}
+	addTestHandler(t, "schema", Node{}, n)
+
+	s := &GRPCServer{}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "schema",
+		`{"order":{"id":7,"lines":[{"sku":"a","qty":1},{"qty":"2"}]}}`))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("OnMessage err = %v, want InvalidArgument", err)
+	}
+	for _, path := range []string{"order.id", "order.lines[1].sku", "order.lines[1].qty"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("error %q does not name %s", err, path)
+		}
+	}
+	if c := atomic.LoadInt32(&n.calls); c != 0 {
+		t.Fatalf("OnMessage ran %d times on invalid input", c)
+	}
+
+	_, err = s.OnMessage(context.Background(), onMessageRequest(t, "schema",
+		`{"order":{"id":"7","lines":[]}}`))
+	if err != nil {
+		t.Fatalf("OnMessage valid input: %v", err)
+	}
+	if c := atomic.LoadInt32(&n.calls); c != 1 {
+		t.Fatalf("OnMessage ran %d times, want 1", c)
+	}
+}
+
+func TestOnMessage_InvalidInputContinueOnError(t *testing.T) {
+	t.Parallel()
+	addTestHandler(t, "schema-continue", Node{ContinueOnError: true}, &fxSchema{})
+
+	s := &GRPCServer{}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "schema-continue", `{"a":1}`))
+	if err != nil {
+		t.Fatalf("OnMessage err = %v, want nil with ContinueOnError", err)
+	}
+	out := message.NewContext(resp.OutMessage)
+	if out.GetInt("a") != 1 || out.GetString("__error__.code") != ErrInvalidInput {
+		t.Fatalf("unexpected output: %s", resp.OutMessage)
+	}
+	if !strings.Contains(out.GetString("__error__.message"), "order: is required") {
+		t.Fatalf("__error__ does not name the path: %s", resp.OutMessage)
+	}
+}
+
+func TestSpec_InputSchema(t *testing.T) {
+	pspec := captureSpec(t, &fxSchema{})
+	node := nodeByID(t, pspec, "Test.Schema")
+	schema, ok := node["inputSchema"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("node.inputSchema missing or wrong type: %#v", node["inputSchema"])
+	}
+	order, _ := schema["properties"].(map[string]interface{})["order"].(map[string]interface{})
+	if order["type"] != "object" {
+		t.Fatalf("node.inputSchema = %s", jsonText(schema))
+	}
+	if _, has := nodeByID(t, captureSpec(t, &fxPlain{}), "Test.Plain")["inputSchema"]; has {
+		t.Fatal("plain node unexpectedly carries inputSchema")
+	}
+}
+
+func TestAsInputSchemaProvider(t *testing.T) {
+	t.Parallel()
+	if AsInputSchemaProvider(NewToolInterceptor(&fxSchema{})) == nil {
+		t.Fatal("provider hidden by the tool interceptor")
+	}
+	if AsInputSchemaProvider(&fxPlain{}) != nil {
+		t.Fatal("plain node reported as a provider")
+	}
+	if err := validateInput(nil, []byte(`not json`)); err != nil {
+		t.Fatalf("no schema: err = %v", err)
+	}
+	if rerr := AsError(validateInput(compileSchema(map[string]interface{}{}), []byte(`not json`))); rerr == nil || rerr.Code != ErrInvalidInput {
+		t.Fatalf("bad JSON: err = %v, want %s", rerr, ErrInvalidInput)
+	}
+}
@@ -58,6 +58,7 @@ type SetupContext interface {
 }
 
 type setupContext struct {
+	helper    RuntimeHelper
 	guid      string
 	sessionID string
 	config    []byte
@@ -70,26 +71,26 @@ func (s *setupContext) Config() []byte            { return s.config }
 func (s *setupContext) Context() context.Context { return s.ctx }
 
 func (s *setupContext) Emit(ev SetupEvent) error {
-	if client == nil {
+	if s.helper == nil {
 		return fmt.Errorf("runtime not initialized")
 	}
 	b, err := json.Marshal(ev)
 	if err != nil {
 		return err
 	}
-	return client.SetupEmit(s.guid, s.sessionID, b)
+	return s.helper.SetupEmit(s.guid, s.sessionID, b)
 }
 
 func (s *setupContext) Await(spec SetupInputSpec, timeoutSec int32) (SetupInput, error) {
 	var in SetupInput
-	if client == nil {
+	if s.helper == nil {
 		return in, fmt.Errorf("runtime not initialized")
 	}
 	b, err := json.Marshal(spec)
 	if err != nil {
 		return in, err
 	}
-	raw, timedOut, err := client.SetupAwait(s.guid, s.sessionID, b, timeoutSec)
+	raw, timedOut, err := s.helper.SetupAwait(s.guid, s.sessionID, b, timeoutSec)
 	if err != nil {
 		return in, err
 	}
@@ -105,16 +106,15 @@ func (s *setupContext) Await(spec SetupInputSpec, timeoutSec int32) (SetupInput,
 }
 
 // AsSetupHandler returns the SetupHandler for a stored handler, unwrapping the
-// ToolInterceptor that AddNodeHandler always applies. Returns nil when the
-// node doesn't support setup.
+// ToolInterceptor that AddNodeHandler always applies and any middleware
+// registered with Use. Returns nil when the node doesn't support setup.
 func AsSetupHandler(h MessageHandler) SetupHandler {
-	if sh, ok := h.(SetupHandler); ok {
-		return sh
+	found, ok := findHandler(h, func(h MessageHandler) bool {
+		_, ok := h.(SetupHandler)
+		return ok
+	})
+	if !ok {
+		return nil
 	}
-	if ti, ok := h.(*ToolInterceptor); ok {
-		if sh, ok := ti.Unwrap().(SetupHandler); ok {
-			return sh
-		}
-	}
-	return nil
+	return found.(SetupHandler)
 }
@@ -0,0 +1,129 @@
+package runtime
+
+import (
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// Process exit codes used by Start once the package has drained. 1 is left
+// to log.Fatal and 2 to the Go runtime's unrecovered panic; CLI mode's
+// codes, from cliExitCode, start at 10.
+const (
+	exitOK           = 0 // stopped on request, every node closed cleanly
+	exitDrainTimeout = 3 // in-flight OnMessage calls outlived the shutdown deadline
+	exitConnLost     = 4 // the robot connection died
+)
+
+// ShutdownTimeout bounds how long a shutdown waits for in-flight OnMessage
+// calls before cancelling their contexts and closing the nodes anyway. The
+// "robomotion.shutdown_timeout" property (e.g. "30s") overrides it.
+var ShutdownTimeout = 10 * time.Second
+
+var (
+	callMu      sync.Mutex
+	draining    bool
+	activeCalls int
+	drained     chan struct{} // closed when draining and activeCalls drops to 0
+)
+
+// requestShutdown asks Start to drain and exit with code. Only the first
+// request counts; later ones are dropped.
+func requestShutdown(code int) {
+	select {
+	case done <- code:
+	default:
+	}
+}
+
+// beginCall registers an in-flight OnMessage. It returns false once the
+// package is draining, in which case the call must be refused.
+func beginCall() bool {
+	callMu.Lock()
+	defer callMu.Unlock()
+	if draining {
+		return false
+	}
+	activeCalls++
+	return true
+}
+
+// endCall marks an OnMessage registered by beginCall as finished.
+func endCall() {
+	callMu.Lock()
+	defer callMu.Unlock()
+	activeCalls--
+	if draining && activeCalls == 0 && drained != nil {
+		close(drained)
+		drained = nil
+	}
+}
+
+// startDrain stops beginCall from admitting new calls and returns a channel
+// that is closed once the calls already running have finished.
+func startDrain() <-chan struct{} {
+	callMu.Lock()
+	defer callMu.Unlock()
+	draining = true
+	ch := make(chan struct{})
+	if activeCalls == 0 {
+		close(ch)
+	} else {
+		drained = ch
+	}
+	return ch
+}
+
+// isDraining reports whether a shutdown is in progress.
+func isDraining() bool {
+	callMu.Lock()
+	defer callMu.Unlock()
+	return draining
+}
+
+// shutdownTimeout returns ShutdownTimeout, overridden by Props.
+func shutdownTimeout() time.Duration {
+	return Props.GetParsedDuration("robomotion.shutdown_timeout", ShutdownTimeout)
+}
+
+// drain runs the shutdown sequence shared by every stop path — a signal, the
+// last node closing, the robot connection dying, or the robot terminating
+// the package on flow stop (CapabilityTerminateOnStop), which may happen
+// without an OnClose per node:
+//
+//  1. refuse new OnMessage calls,
+//  2. wait up to shutdownTimeout for the ones in flight, then cancel them,
+//  3. call OnClose on every handler still registered,
+//  4. flush and close the LMO store and the span exporter.
+//
+// It returns the exit code to use, which is code unless the deadline passed.
+func drain(code int) int {
+	timeout := shutdownTimeout()
+	idle := startDrain()
+
+	select {
+	case <-idle:
+	case <-time.After(timeout):
+		hclog.Default().Info("runtime.shutdown", "err", "in-flight calls outlived the deadline", "timeout", timeout)
+		if code == exitOK {
+			code = exitDrainTimeout
+		}
+	}
+
+	for _, guid := range listNodeHandlerGUIDs() {
+		node := GetNodeHandler(guid)
+		if node == nil {
+			continue
+		}
+		node.stop()
+		if err := safeCall("OnClose", node.Handler.OnClose); err != nil {
+			hclog.Default().Info("runtime.shutdown.onclose", "guid", guid, "err", err)
+		}
+		RemoveNodeHandler(guid)
+	}
+
+	CloseLMOStore()
+	closeTracing()
+	return code
+}
@@ -0,0 +1,66 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// resetDrain undoes a drain so later tests see an accepting server.
+func resetDrain(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		callMu.Lock()
+		draining = false
+		drained = nil
+		callMu.Unlock()
+	})
+}
+
+func TestDrain_ClosesHandlersAndRefusesCalls(t *testing.T) {
+	resetDrain(t)
+	n := &fxNode{}
+	addTestHandler(t, "drain-idle", Node{}, n)
+
+	if code := drain(exitOK); code != exitOK {
+		t.Fatalf("drain = %d, want %d", code, exitOK)
+	}
+	if !n.closed.Load() {
+		t.Fatal("drain did not call OnClose on a registered handler")
+	}
+	if GetNodeHandler("drain-idle") != nil {
+		t.Fatal("drain left the handler registered")
+	}
+
+	s := &GRPCServer{}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "drain-idle", `{}`))
+	if st, _ := status.FromError(err); st.Code() != codes.Unavailable {
+		t.Fatalf("OnMessage while draining = %v, want codes.Unavailable", err)
+	}
+}
+
+func TestDrain_TimesOutAndCancelsInFlight(t *testing.T) {
+	resetDrain(t)
+	prev := ShutdownTimeout
+	ShutdownTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { ShutdownTimeout = prev })
+
+	n, started, seen := fxBlocking()
+	addTestHandler(t, "drain-busy", Node{}, n)
+
+	s := &GRPCServer{}
+	go s.OnMessage(context.Background(), onMessageRequest(t, "drain-busy", `{}`))
+	<-started
+
+	if code := drain(exitOK); code != exitDrainTimeout {
+		t.Fatalf("drain = %d, want %d", code, exitDrainTimeout)
+	}
+	select {
+	case <-seen:
+	case <-time.After(time.Second):
+		t.Fatal("in-flight OnMessage was not cancelled after the deadline")
+	}
+}
@@ -46,6 +46,9 @@ type NodeSpec struct {
 	// MetadataProvider.Metadata(). Never interpreted by the framework;
 	// custom editors read the keys they know about.
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// InputSchema is the JSON Schema incoming messages are checked
+	// against, populated from InputSchemaProvider.InputSchema().
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
 }
 
 type Property struct {
@@ -159,6 +162,12 @@ func generateSpecFile(pluginName, version string) {
 		if instance, _ := reflect.New(t).Interface().(MetadataProvider); instance != nil {
 			spec.Metadata = instance.Metadata()
 		}
+		if instance, _ := reflect.New(t).Interface().(InputSchemaProvider); instance != nil {
+			spec.InputSchema = instance.InputSchema()
+			if compiled := compileSchema(spec.InputSchema); compiled != nil && compiled.err != nil {
+				log.Fatalf("%s: %v", id, compiled.err)
+			}
+		}
 
 		// Look for custom port fields (fields of type Port)
 		for i := 0; i < t.NumField(); i++ {
@@ -472,6 +481,15 @@ func generateSpecFile(pluginName, version string) {
 			}
 		}
 
+		if instance, _ := reflect.New(t).Interface().(MessageHandler); instance != nil {
+			if policy := retryPolicyOf(instance); policy.Enabled() {
+				addRetryOptions(&optProperty, policy)
+			}
+			if timeout, ok := declaredTimeout(instance); ok {
+				addTimeoutOption(&optProperty, timeout)
+			}
+		}
+
 		if len(inProperty.Schema.Properties) > 0 {
 			spec.Properties = append(spec.Properties, inProperty)
 		}
@@ -49,9 +49,11 @@ func convert(obj *st.Value) interface{} {
 	}
 }
 
-// ToValue converts an interface{} to a ptypes.Value
+// ToValue converts an interface{} to a ptypes.Value, with times, durations,
+// []byte and marshalers in the encodings InVariable reads back; see
+// convert.go.
 func ToValue(v interface{}) *st.Value {
-	switch v := v.(type) {
+	switch v := encodeValue(v).(type) {
 	case nil:
 		return nil
 	case bool:
@@ -139,6 +141,18 @@ func ToValue(v interface{}) *st.Value {
 }
 
 func toValue(v reflect.Value) *st.Value {
+	return toValueIn(v, false)
+}
+
+// toValueIn is toValue for a value that is, when inStruct, inside a struct,
+// where durations are in nanoseconds; see encodeIn.
+func toValueIn(v reflect.Value, inStruct bool) *st.Value {
+	if v.IsValid() && v.CanInterface() && needsEncoding(v.Type(), nil) {
+		// Unless encoding failed and left the value as it was.
+		if enc := encodeIn(v.Interface(), inStruct); enc == nil || reflect.TypeOf(enc) != v.Type() {
+			return ToValue(enc)
+		}
+	}
 	switch v.Kind() {
 	case reflect.Bool:
 		return &st.Value{
@@ -164,11 +178,11 @@ func toValue(v reflect.Value) *st.Value {
 				NumberValue: v.Float(),
 			},
 		}
-	case reflect.Ptr:
+	case reflect.Ptr, reflect.Interface:
 		if v.IsNil() {
 			return nil
 		}
-		return toValue(reflect.Indirect(v))
+		return toValueIn(v.Elem(), inStruct)
 	case reflect.Array, reflect.Slice:
 		size := v.Len()
 		if size == 0 {
@@ -176,7 +190,7 @@ func toValue(v reflect.Value) *st.Value {
 		}
 		values := make([]*st.Value, size)
 		for i := 0; i < size; i++ {
-			values[i] = toValue(v.Index(i))
+			values[i] = toValueIn(v.Index(i), inStruct)
 		}
 		return &st.Value{
 			Kind: &st.Value_ListValue{
@@ -196,7 +210,7 @@ func toValue(v reflect.Value) *st.Value {
 			name := t.Field(i).Name
 			// Better way?
 			if len(name) > 0 && 'A' <= name[0] && name[0] <= 'Z' {
-				fields[name] = toValue(v.Field(i))
+				fields[name] = toValueIn(v.Field(i), true)
 			}
 		}
 		if len(fields) == 0 {
@@ -217,7 +231,7 @@ func toValue(v reflect.Value) *st.Value {
 		fields := make(map[string]*st.Value, len(keys))
 		for _, k := range keys {
 			if k.Kind() == reflect.String {
-				fields[k.String()] = toValue(v.MapIndex(k))
+				fields[k.String()] = toValueIn(v.MapIndex(k), inStruct)
 			}
 		}
 		if len(fields) == 0 {
@@ -32,6 +32,19 @@ func SetTestClient(helper TestRuntimeHelper) {
 	client = &testClient{helper: helper}
 }
 
+// NewTestHelper returns a RuntimeHelper backed by helper without touching
+// the package-level one, so parallel tests can each run nodes against their
+// own mock. Hand it to a node with Node.SetHelper, or to the messages it
+// handles with WithRuntimeHelper.
+//
+// Example:
+//
+//	h := runtime.NewTestHelper(&MockHelper{})
+//	ctx := message.NewContextWith(runtime.WithRuntimeHelper(context.Background(), h), data)
+func NewTestHelper(helper TestRuntimeHelper) RuntimeHelper {
+	return &testClient{helper: helper}
+}
+
 // ClearTestClient clears the test client.
 func ClearTestClient() {
 	client = nil
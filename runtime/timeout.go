@@ -0,0 +1,135 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/pprof"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// TimeoutGrace is how long a node whose timeout expired gets to return
+// after its context was cancelled. A node still running after that is
+// considered stuck: the runtime dumps every goroutine's stack and fails the
+// call without waiting for it.
+var TimeoutGrace = 2 * time.Second
+
+// errNodeTimeout is the cancellation cause of a node's timeout, telling it
+// apart from the flow stopping.
+var errNodeTimeout = errors.New("node timeout")
+
+// declaredTimeout returns the timeout a node opts into with the "timeout"
+// key of its Node spec tag, e.g. timeout=30s. A bare "timeout" opts in
+// without a default. ok is false when the node does not opt in.
+func declaredTimeout(handler MessageHandler) (d time.Duration, ok bool) {
+	t := reflect.TypeOf(handler)
+	if t == nil {
+		return 0, false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return 0, false
+	}
+	field, hasNode := t.FieldByName("Node")
+	if !hasNode {
+		return 0, false
+	}
+	v, ok := parseSpec(field.Tag.Get("spec"))["timeout"]
+	if !ok {
+		return 0, false
+	}
+	d, _ = time.ParseDuration(v)
+	return d, true
+}
+
+// timeoutOf returns the timeout to enforce for a node instance: its Timeout
+// option, else the declared default. Zero means no timeout.
+func timeoutOf(handler MessageHandler, node Node) time.Duration {
+	if node.OptTimeout > 0 {
+		return delayDuration(node.OptTimeout)
+	}
+	d, _ := declaredTimeout(handler)
+	return d
+}
+
+// runWithTimeout runs fn, one OnMessage attempt, on a fresh message built
+// from data whose context is ctx bounded by timeout. When the timeout
+// expires, the message's context is cancelled and the attempt fails with an
+// ErrTimeout error whether fn then fails or returns nil; if fn has not
+// returned TimeoutGrace later, the goroutine stacks are reported and the
+// message is nil because fn may still be writing to it.
+func runWithTimeout(ctx context.Context, timeout time.Duration, guid, name string, data []byte, fn func(message.Context) error) (message.Context, error) {
+	if timeout <= 0 {
+		msgCtx := message.NewContextWith(ctx, append([]byte(nil), data...))
+		return msgCtx, fn(msgCtx)
+	}
+
+	tctx, cancel := context.WithTimeoutCause(ctx, timeout, errNodeTimeout)
+	defer cancel()
+	msgCtx := message.NewContextWith(tctx, append([]byte(nil), data...))
+
+	done := make(chan error, 1)
+	go func() { done <- fn(msgCtx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-tctx.Done():
+		if context.Cause(tctx) != errNodeTimeout {
+			// The flow stopped; the node sees the same cancellation it
+			// always did.
+			err = <-done
+			break
+		}
+		grace := time.NewTimer(TimeoutGrace)
+		defer grace.Stop()
+		select {
+		case err = <-done:
+		case <-grace.C:
+			reportStuck(HelperFrom(ctx), guid, name, timeout)
+			return nil, NewError(ErrTimeout, fmt.Sprintf("OnMessage did not return within %s", timeout))
+		}
+	}
+
+	if context.Cause(tctx) != errNodeTimeout {
+		return msgCtx, err
+	}
+	if err == nil {
+		return msgCtx, NewError(ErrTimeout, fmt.Sprintf("OnMessage returned after its %s timeout", timeout))
+	}
+	terr := WrapError(ErrTimeout, err)
+	terr.Message = fmt.Sprintf("OnMessage timed out after %s: %s", timeout, err)
+	return msgCtx, terr
+}
+
+// reportStuck dumps every goroutine's stack for a node that ignored its
+// timeout, to the package log and to the robot through EmitError and
+// EmitDebug, so the stuck call can be found.
+func reportStuck(h RuntimeHelper, guid, name string, timeout time.Duration) {
+	var stacks bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&stacks, 2)
+
+	msg := fmt.Sprintf("%s is still running %s after its %s timeout; goroutine stacks were sent to the debug log", name, TimeoutGrace, timeout)
+	hclog.Default().Error("runtime.timeout", "guid", guid, "name", name, "timeout", timeout, "stacks", stacks.String())
+	if h == nil {
+		return
+	}
+	h.EmitError(guid, name, msg)
+	h.Debug(guid, name, map[string]interface{}{"error": msg, "goroutines": stacks.String()})
+}
+
+// addTimeoutOption adds the Timeout option to a node's Options group. The
+// value lands in Node.OptTimeout.
+func addTimeoutOption(optProperty *Property, timeout time.Duration) {
+	optProperty.Schema.Properties["optTimeout"] = SProperty{Type: "double", Title: "Timeout (sec)"}
+	optProperty.FormData["optTimeout"] = timeout.Seconds()
+	optProperty.UISchema["ui:order"] = append(optProperty.UISchema["ui:order"].([]string), "optTimeout")
+}
@@ -0,0 +1,135 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// fxSlow opts into a timeout and waits for its context, or for release when
+// stuck is set.
+type fxSlow struct {
+	Node `spec:"id=Test.Slow,name=Slow,icon=,color=#000,timeout=1h"`
+	fxLifecycle
+	stuck   bool
+	release chan struct{}
+}
+
+func (n *fxSlow) OnMessage(ctx message.Context) error {
+	if n.stuck {
+		<-n.release
+		return nil
+	}
+	<-ctx.Context().Done()
+	return ctx.Context().Err()
+}
+
+// timeoutStatus asserts err is a DeadlineExceeded status carrying ErrTimeout.
+func timeoutStatus(t *testing.T, err error) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.DeadlineExceeded {
+		t.Fatalf("err = %v, want codes.DeadlineExceeded", err)
+	}
+	var rerr Error
+	if jerr := json.Unmarshal([]byte(st.Message()), &rerr); jerr != nil || rerr.Code != ErrTimeout {
+		t.Fatalf("status message = %q, want an ErrTimeout runtime.Error", st.Message())
+	}
+}
+
+func TestOnMessage_TimeoutOptionCancelsNode(t *testing.T) {
+	addTestHandler(t, "timeout", Node{OptTimeout: 0.02}, &fxSlow{})
+
+	start := time.Now()
+	s := &GRPCServer{}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "timeout", `{}`))
+	timeoutStatus(t, err)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout not enforced (took %s)", elapsed)
+	}
+}
+
+func TestOnMessage_StuckNodeIsAbandoned(t *testing.T) {
+	prev := TimeoutGrace
+	TimeoutGrace = 10 * time.Millisecond
+	t.Cleanup(func() { TimeoutGrace = prev })
+
+	n := &fxSlow{stuck: true, release: make(chan struct{})}
+	defer close(n.release)
+	addTestHandler(t, "stuck", Node{OptTimeout: 0.02, ContinueOnError: true}, n)
+
+	s := &GRPCServer{}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "stuck", `{"a":1}`))
+	if err != nil {
+		t.Fatalf("OnMessage err = %v, want nil with ContinueOnError", err)
+	}
+	out := message.NewContext(resp.OutMessage)
+	if out.GetInt("a") != 1 || out.GetString("__error__.code") != ErrTimeout {
+		t.Fatalf("OutMessage = %s, want the input plus an ErrTimeout __error__", resp.OutMessage)
+	}
+}
+
+func TestOnMessage_TimeoutBoundsEachAttempt(t *testing.T) {
+	t.Parallel()
+	// The first attempt runs into the timeout; the retry needs most of a
+	// timeout of its own.
+	n := &fxNode{}
+	n.onMessage = func(ctx message.Context) error {
+		if n.calls.Load() == 1 {
+			<-ctx.Context().Done()
+			return ctx.Context().Err()
+		}
+		time.Sleep(30 * time.Millisecond)
+		return ctx.Set("done", true)
+	}
+	addTestHandler(t, "timeout-attempts", Node{OptTimeout: 0.05, OptRetryAttempts: 2, OptRetryDelay: 0.001}, n)
+
+	s := &GRPCServer{}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "timeout-attempts", `{}`))
+	if err != nil || n.calls.Load() != 2 {
+		t.Fatalf("OnMessage = %v after %d calls, want the retry to succeed", err, n.calls.Load())
+	}
+	if payloadOf(resp.OutMessage) != `{"done":true}` {
+		t.Fatalf("OutMessage = %s", resp.OutMessage)
+	}
+}
+
+func TestOnMessage_ReturnAfterTimeoutFails(t *testing.T) {
+	t.Parallel()
+	addTestHandler(t, "timeout-late", Node{OptTimeout: 0.02}, fxFunc(func(ctx message.Context) error {
+		<-ctx.Context().Done()
+		return nil // ignores the cancellation, but returns within the grace
+	}))
+
+	s := &GRPCServer{}
+	_, err := s.OnMessage(context.Background(), onMessageRequest(t, "timeout-late", `{}`))
+	timeoutStatus(t, err)
+}
+
+func TestTimeoutOf(t *testing.T) {
+	if d := timeoutOf(&fxSlow{}, Node{}); d != time.Hour {
+		t.Fatalf("declared timeout = %s, want 1h", d)
+	}
+	if d := timeoutOf(&fxSlow{}, Node{OptTimeout: 5}); d != 5*time.Second {
+		t.Fatalf("overridden timeout = %s, want 5s", d)
+	}
+	if d := timeoutOf(&fxPlain{}, Node{}); d != 0 {
+		t.Fatalf("node without timeout = %s, want 0", d)
+	}
+}
+
+func TestSpec_TimeoutOptionWhenDeclared(t *testing.T) {
+	pspec := captureSpec(t, &fxSlow{})
+
+	props := nodeByID(t, pspec, "Test.Slow")["properties"].([]interface{})
+	opts := props[len(props)-1].(map[string]interface{})
+	if opts["formData"].(map[string]interface{})["optTimeout"] != float64(3600) {
+		t.Fatalf("Options formData = %v, want optTimeout 3600", opts["formData"])
+	}
+}
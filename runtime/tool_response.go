@@ -1,13 +1,14 @@
 package runtime
 
 import (
+	"time"
+
 	"github.com/robomotionio/robomotion-go/message"
 )
 
 // IsToolRequest checks if the current message is a tool request
 func IsToolRequest(ctx message.Context) bool {
-	msgType := ctx.Get("__message_type__")
-	return msgType == "tool_request"
+	return ctx.Meta().Type == "tool_request"
 }
 
 // ToolName returns the name of the tool the agent invoked for this
@@ -15,10 +16,15 @@ func IsToolRequest(ctx message.Context) bool {
 // can be ignored. For Toolkit nodes this is the discriminator used to
 // dispatch inside OnMessage. Empty string when ctx is not a tool request.
 func ToolName(ctx message.Context) string {
-	if v, ok := ctx.Get("__tool_name__").(string); ok {
-		return v
+	return toolCall(ctx.Meta()).Name
+}
+
+// toolCall returns the tool-call routing of meta, empty when there is none.
+func toolCall(meta message.Meta) message.ToolCall {
+	if meta.Tool == nil {
+		return message.ToolCall{}
 	}
-	return ""
+	return *meta.Tool
 }
 
 // ToolParameters returns the parsed parameters object the agent passed
@@ -39,21 +45,21 @@ func ToolResponse(ctx message.Context, status string, data map[string]interface{
 		return nil // Not a tool request
 	}
 
-	callerID := ctx.Get("__tool_caller_id__")
-	agentNodeID := ctx.Get("__agent_node_id__")
+	meta := ctx.Meta()
+	call := toolCall(meta)
 	
 	// Create response context with required fields
 	responseCtx := message.NewContext([]byte("{}"))
 	
 	// Copy all essential fields from the original message
 	// This ensures the LLM Agent has all necessary context to continue
-	if msgID := ctx.Get("id"); msgID != nil {
-		responseCtx.Set("id", msgID)
+	if meta.ID != "" {
+		responseCtx.Set("id", meta.ID)
 	}
 	
 	// Copy session information if present
-	if sessionID := ctx.Get("session_id"); sessionID != nil {
-		responseCtx.Set("session_id", sessionID)
+	if call.SessionID != "" {
+		responseCtx.Set("session_id", call.SessionID)
 	}
 	
 	// Copy any query information
@@ -63,7 +69,7 @@ func ToolResponse(ctx message.Context, status string, data map[string]interface{
 	
 	// Set tool response specific fields
 	responseCtx.Set("__message_type__", "tool_response")
-	responseCtx.Set("__tool_caller_id__", callerID)
+	responseCtx.Set("__tool_caller_id__", call.CallerID)
 	responseCtx.Set("__tool_status__", status)
 	
 	if errorMsg != "" {
@@ -73,10 +79,20 @@ func ToolResponse(ctx message.Context, status string, data map[string]interface{
 		responseCtx.Set("__tool_data__", data)
 	}
 	
+	// The legacy fields above are for agents that predate the envelope.
+	message.SetMeta(responseCtx, message.Meta{
+		ID:       meta.ID,
+		Created:  time.Now(),
+		Sent:     time.Now(),
+		TraceID:  meta.TraceID,
+		SpanID:   meta.SpanID,
+		Type:     "tool_response",
+		Tool:     &message.ToolCall{Name: call.Name, CallerID: call.CallerID, SessionID: call.SessionID},
+	})
+	
 	// Send response back to LLM Agent
-	if agentID, ok := agentNodeID.(string); ok && agentID != "" {
-		data, _ := responseCtx.GetRaw()
-		EmitInput(agentID, data)
+	if call.AgentNodeID != "" {
+		EmitInputWith(ctx, call.AgentNodeID, message.PackedBytes(responseCtx))
 	}
 	
 	// Prevent message flow to next node by clearing context
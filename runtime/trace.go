@@ -0,0 +1,236 @@
+package runtime
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// Tracing is off unless an exporter is configured, either in code with
+// SetSpanExporter or through the environment (or Props):
+//
+//	ROBOMOTION_TRACE_FILE=/tmp/spans.jsonl   robomotion.trace.file
+//	ROBOMOTION_TRACE_OTLP=http://localhost:4318   robomotion.trace.otlp
+//
+// Spans cover Init, OnCreate, OnMessage (with LMO pack/resolve and every
+// RuntimeHelper call made while handling the message) and OnClose. The
+// trace travels with the message in its envelope, so the next node — in this
+// package or another — continues it; a __trace__ field in the payload, from
+// older senders, is still read.
+
+// Span is one timed operation of a trace.
+type Span struct {
+	TraceID    string                 `json:"traceId"`
+	SpanID     string                 `json:"spanId"`
+	ParentID   string                 `json:"parentSpanId,omitempty"`
+	Name       string                 `json:"name"`
+	Start      time.Time              `json:"start"`
+	End        time.Time              `json:"end"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// SpanExporter receives every finished span.
+type SpanExporter interface {
+	ExportSpan(span *Span) error
+	Close() error
+}
+
+var (
+	traceMu       sync.RWMutex
+	spanExporter  SpanExporter
+	traceConfOnce sync.Once
+)
+
+// SetSpanExporter turns tracing on with e, or off with nil. The exporter
+// it replaces is closed.
+func SetSpanExporter(e SpanExporter) {
+	traceConfOnce.Do(func() {}) // an explicit choice wins over the environment
+	traceMu.Lock()
+	prev := spanExporter
+	spanExporter = e
+	traceMu.Unlock()
+	if prev != nil && prev != e {
+		prev.Close()
+	}
+}
+
+// TracingEnabled reports whether spans are being exported.
+func TracingEnabled() bool {
+	return currentExporter() != nil
+}
+
+func currentExporter() SpanExporter {
+	traceConfOnce.Do(configureTracing)
+	traceMu.RLock()
+	defer traceMu.RUnlock()
+	return spanExporter
+}
+
+// configureTracing sets the exporter from the environment or Props.
+func configureTracing() {
+	var exporters []SpanExporter
+	if path := traceSetting("robomotion.trace.file", "ROBOMOTION_TRACE_FILE"); path != "" {
+		e, err := NewJSONLinesExporter(path)
+		if err != nil {
+			hclog.Default().Info("runtime.trace", "err", err)
+		} else {
+			exporters = append(exporters, e)
+		}
+	}
+	if endpoint := traceSetting("robomotion.trace.otlp", "ROBOMOTION_TRACE_OTLP"); endpoint != "" {
+		exporters = append(exporters, NewOTLPExporter(endpoint))
+	}
+
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	switch len(exporters) {
+	case 0:
+	case 1:
+		spanExporter = exporters[0]
+	default:
+		spanExporter = multiExporter(exporters)
+	}
+}
+
+func traceSetting(prop, env string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return Props.GetString(prop, "")
+}
+
+// closeTracing flushes and closes the exporter on shutdown.
+func closeTracing() {
+	traceMu.Lock()
+	e := spanExporter
+	spanExporter = nil
+	traceMu.Unlock()
+	if e != nil {
+		if err := e.Close(); err != nil {
+			hclog.Default().Info("runtime.trace.close", "err", err)
+		}
+	}
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span ctx is running under, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// StartSpan starts a span named name, a child of the span in ctx if any,
+// and returns a context carrying it. With tracing off it returns ctx and a
+// nil span; every *Span method is safe to call on nil.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	if !TracingEnabled() {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	span := &Span{SpanID: newTraceID(8), Name: name, Start: time.Now()}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.TraceID, span.ParentID = parent.TraceID, parent.SpanID
+	} else {
+		span.TraceID = newTraceID(16)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttribute records a key/value on the span.
+func (s *Span) SetAttribute(key string, value interface{}) *Span {
+	if s == nil {
+		return s
+	}
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+	s.Attributes[key] = value
+	return s
+}
+
+// Finish ends the span, records err if non-nil, and exports it.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.End = time.Now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	if e := currentExporter(); e != nil {
+		if exportErr := e.ExportSpan(s); exportErr != nil {
+			hclog.Default().Info("runtime.trace.export", "err", exportErr)
+		}
+	}
+}
+
+// withMessageTrace returns ctx with the upstream span from the message's
+// envelope, or its __trace__ field, as the parent of the spans started
+// under it.
+func withMessageTrace(ctx context.Context, data []byte) context.Context {
+	meta := message.MetaOf(data)
+	traceID, spanID := meta.TraceID, meta.SpanID
+	if traceID == "" || spanID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, &Span{TraceID: traceID, SpanID: spanID})
+}
+
+func newTraceID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// multiExporter sends every span to each of its exporters.
+type multiExporter []SpanExporter
+
+func (m multiExporter) ExportSpan(span *Span) error {
+	var first error
+	for _, e := range m {
+		if err := e.ExportSpan(span); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (m multiExporter) Close() error {
+	var first error
+	for _, e := range m {
+		if err := e.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// packValue is PackValue recorded as an "lmo.pack" span of the message's call.
+func packValue(ctx message.Context, value interface{}) (interface{}, bool, error) {
+	_, span := StartSpan(ctx.Context(), "lmo.pack")
+	packed, ok, err := PackValue(value)
+	span.SetAttribute("packed", ok).Finish(err)
+	return packed, ok, err
+}
+
+// resolveBlobRef is ResolveBlobRefValue recorded as an "lmo.resolve" span.
+func resolveBlobRef(ctx message.Context, ref map[string]interface{}) (interface{}, error) {
+	_, span := StartSpan(ctx.Context(), "lmo.resolve")
+	value, err := ResolveBlobRefValue(ref)
+	span.Finish(err)
+	return value, err
+}
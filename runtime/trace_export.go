@@ -0,0 +1,213 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// JSONLinesExporter appends each span as one JSON object per line.
+type JSONLinesExporter struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewJSONLinesExporter opens (or creates) path for appending spans.
+func NewJSONLinesExporter(path string) (*JSONLinesExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesExporter{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (e *JSONLinesExporter) ExportSpan(span *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.f == nil {
+		return fmt.Errorf("exporter closed")
+	}
+	return e.enc.Encode(span)
+}
+
+func (e *JSONLinesExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.f == nil {
+		return nil
+	}
+	err := e.f.Close()
+	e.f = nil
+	return err
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP/HTTP in
+// its JSON encoding. Spans are batched and posted every OTLPFlushInterval,
+// when OTLPBatchSize are waiting, and on Close.
+type OTLPExporter struct {
+	url     string
+	service string
+	client  *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+	flushC  chan struct{}
+	closed  chan struct{}
+	done    chan struct{}
+}
+
+var (
+	OTLPFlushInterval = 2 * time.Second
+	OTLPBatchSize     = 256
+)
+
+// NewOTLPExporter posts to endpoint, the collector's base URL (e.g.
+// http://localhost:4318) or its full /v1/traces URL.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	e := &OTLPExporter{
+		url:     url,
+		service: filepath.Base(os.Args[0]),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		flushC:  make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go e.loop()
+	return e
+}
+
+func (e *OTLPExporter) ExportSpan(span *Span) error {
+	e.mu.Lock()
+	e.pending = append(e.pending, span)
+	full := len(e.pending) >= OTLPBatchSize
+	e.mu.Unlock()
+	if full {
+		select {
+		case e.flushC <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close sends the spans still waiting and stops the exporter.
+func (e *OTLPExporter) Close() error {
+	select {
+	case <-e.closed:
+	default:
+		close(e.closed)
+	}
+	<-e.done
+	return nil
+}
+
+func (e *OTLPExporter) loop() {
+	defer close(e.done)
+	ticker := time.NewTicker(OTLPFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.flushC:
+		case <-e.closed:
+			e.flush()
+			return
+		}
+		e.flush()
+	}
+}
+
+func (e *OTLPExporter) flush() {
+	e.mu.Lock()
+	spans := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(e.payload(spans))
+	if err != nil {
+		hclog.Default().Info("runtime.trace.otlp", "err", err)
+		return
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		hclog.Default().Info("runtime.trace.otlp", "err", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		hclog.Default().Info("runtime.trace.otlp", "status", resp.Status)
+	}
+}
+
+// payload builds an OTLP ExportTraceServiceRequest in its JSON form.
+func (e *OTLPExporter) payload(spans []*Span) map[string]interface{} {
+	otlpSpans := make([]map[string]interface{}, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]interface{}{
+			"traceId":           s.TraceID,
+			"spanId":            s.SpanID,
+			"name":              s.Name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.Attributes),
+		}
+		if s.ParentID != "" {
+			span["parentSpanId"] = s.ParentID
+		}
+		if s.Error != "" {
+			span["status"] = map[string]interface{}{"code": 2, "message": s.Error} // STATUS_CODE_ERROR
+		}
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes(map[string]interface{}{"service.name": e.service}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "robomotion-go"},
+				"spans": otlpSpans,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs map[string]interface{}) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]interface{}
+		switch v := v.(type) {
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		list = append(list, map[string]interface{}{"key": k, "value": value})
+	}
+	return list
+}
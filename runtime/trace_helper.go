@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"context"
+
+	"github.com/robomotionio/robomotion-go/proto"
+)
+
+// tracedHelper wraps a RuntimeHelper so each call back into the robot is
+// recorded as an "rpc.<Method>" span, a child of the span in ctx.
+type tracedHelper struct {
+	RuntimeHelper
+	ctx context.Context
+}
+
+// traceHelper returns h traced under ctx, or h itself with tracing off.
+func traceHelper(ctx context.Context, h RuntimeHelper) RuntimeHelper {
+	if h == nil || SpanFromContext(ctx) == nil {
+		return h
+	}
+	return &tracedHelper{RuntimeHelper: h, ctx: ctx}
+}
+
+func (t *tracedHelper) start(method string) *Span {
+	_, span := StartSpan(t.ctx, "rpc."+method)
+	return span
+}
+
+func (t *tracedHelper) Debug(guid, name string, message interface{}) (err error) {
+	span := t.start("Debug").SetAttribute("guid", guid)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.Debug(guid, name, message)
+}
+
+func (t *tracedHelper) EmitFlowEvent(guid, name string) (err error) {
+	span := t.start("EmitFlowEvent").SetAttribute("guid", guid)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.EmitFlowEvent(guid, name)
+}
+
+func (t *tracedHelper) EmitInput(guid string, input []byte) (err error) {
+	span := t.start("EmitInput").SetAttribute("guid", guid)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.EmitInput(guid, input)
+}
+
+func (t *tracedHelper) EmitOutput(guid string, output []byte, port int32) (err error) {
+	span := t.start("EmitOutput").SetAttribute("guid", guid).SetAttribute("port", int(port))
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.EmitOutput(guid, output, port)
+}
+
+func (t *tracedHelper) EmitError(guid, name, message string) (err error) {
+	span := t.start("EmitError").SetAttribute("guid", guid)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.EmitError(guid, name, message)
+}
+
+func (t *tracedHelper) GetVaultItem(vaultID, itemID string) (item map[string]interface{}, err error) {
+	span := t.start("GetVaultItem")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.GetVaultItem(vaultID, itemID)
+}
+
+func (t *tracedHelper) SetVaultItem(vaultID, itemID string, data []byte) (item map[string]interface{}, err error) {
+	span := t.start("SetVaultItem")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.SetVaultItem(vaultID, itemID, data)
+}
+
+func (t *tracedHelper) GetVariable(v *variable) (value interface{}, err error) {
+	span := t.start("GetVariable").SetAttribute("scope", v.Scope)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.GetVariable(v)
+}
+
+func (t *tracedHelper) SetVariable(v *variable, value interface{}) (err error) {
+	span := t.start("SetVariable").SetAttribute("scope", v.Scope)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.SetVariable(v, value)
+}
+
+func (t *tracedHelper) GetRobotInfo() (info map[string]interface{}, err error) {
+	span := t.start("GetRobotInfo")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.GetRobotInfo()
+}
+
+func (t *tracedHelper) AppRequest(request []byte, timeout int32) (resp []byte, err error) {
+	span := t.start("AppRequest")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.AppRequest(request, timeout)
+}
+
+func (t *tracedHelper) AppRequestV2(request []byte) (resp []byte, err error) {
+	span := t.start("AppRequestV2")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.AppRequestV2(request)
+}
+
+func (t *tracedHelper) AppPublish(request []byte) (err error) {
+	span := t.start("AppPublish")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.AppPublish(request)
+}
+
+func (t *tracedHelper) AppDownload(id, dir, file string) (path string, err error) {
+	span := t.start("AppDownload")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.AppDownload(id, dir, file)
+}
+
+func (t *tracedHelper) AppUpload(id, path string) (url string, err error) {
+	span := t.start("AppUpload")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.AppUpload(id, path)
+}
+
+func (t *tracedHelper) GatewayRequest(method, endpoint, body string, headers map[string]string) (resp *proto.GatewayRequestResponse, err error) {
+	span := t.start("GatewayRequest").SetAttribute("method", method)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.GatewayRequest(method, endpoint, body, headers)
+}
+
+func (t *tracedHelper) ProxyRequest(req *proto.HttpRequest) (resp *proto.HttpResponse, err error) {
+	span := t.start("ProxyRequest")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.ProxyRequest(req)
+}
+
+func (t *tracedHelper) IsRunning() (running bool, err error) {
+	span := t.start("IsRunning")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.IsRunning()
+}
+
+func (t *tracedHelper) GetPortConnections(guid string, port int) (nodes []NodeInfo, err error) {
+	span := t.start("GetPortConnections").SetAttribute("guid", guid).SetAttribute("port", port)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.GetPortConnections(guid, port)
+}
+
+func (t *tracedHelper) GetInstanceAccess() (access *InstanceAccess, err error) {
+	span := t.start("GetInstanceAccess")
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.GetInstanceAccess()
+}
+
+func (t *tracedHelper) SetupEmit(guid, sessionID string, event []byte) (err error) {
+	span := t.start("SetupEmit").SetAttribute("guid", guid)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.SetupEmit(guid, sessionID, event)
+}
+
+func (t *tracedHelper) SetupAwait(guid, sessionID string, spec []byte, timeoutSec int32) (input []byte, timedOut bool, err error) {
+	span := t.start("SetupAwait").SetAttribute("guid", guid)
+	defer func() { span.Finish(err) }()
+	return t.RuntimeHelper.SetupAwait(guid, sessionID, spec, timeoutSec)
+}
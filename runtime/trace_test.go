@@ -0,0 +1,140 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/robomotionio/robomotion-go/message"
+)
+
+// Tracing is package-wide, so these tests do not run in parallel.
+
+// memExporter keeps finished spans in memory.
+type memExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *memExporter) ExportSpan(s *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, s)
+	return nil
+}
+
+func (e *memExporter) Close() error { return nil }
+
+func (e *memExporter) byName(name string) *Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, s := range e.spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func useExporter(t *testing.T, e SpanExporter) {
+	t.Helper()
+	SetSpanExporter(e)
+	t.Cleanup(func() { SetSpanExporter(nil) })
+}
+
+func TestTrace_OnMessageContinuesUpstreamTrace(t *testing.T) {
+	exp := &memExporter{}
+	useExporter(t, exp)
+
+	e := &fxHelper{}
+	addTestHandler(t, "trace-route", Node{Name: "Router"}, &fxRouter{})
+
+	const traceID, parentID = "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"
+	in := `{"ok":true,"__trace__":{"traceId":"` + traceID + `","spanId":"` + parentID + `"}}`
+	s := &GRPCServer{helper: e}
+	resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "trace-route", in))
+	if err != nil {
+		t.Fatalf("OnMessage: %v", err)
+	}
+
+	span := exp.byName("OnMessage")
+	if span == nil {
+		t.Fatal("no OnMessage span exported")
+	}
+	if span.TraceID != traceID || span.ParentID != parentID {
+		t.Fatalf("OnMessage span = %s/%s, want child of %s/%s", span.TraceID, span.ParentID, traceID, parentID)
+	}
+	if span.Attributes["guid"] != "trace-route" || span.Attributes["node.name"] != "Router" {
+		t.Fatalf("OnMessage attributes = %v", span.Attributes)
+	}
+
+	rpc := exp.byName("rpc.EmitOutput")
+	if rpc == nil || rpc.TraceID != traceID || rpc.ParentID != span.SpanID {
+		t.Fatalf("rpc.EmitOutput span = %+v, want child of OnMessage", rpc)
+	}
+
+	for _, out := range [][]byte{resp.OutMessage, e.outputCalls()[0].out} {
+		if got := message.MetaOf(out).SpanID; got != span.SpanID {
+			t.Fatalf("output %s carries span %q, want %q", out, got, span.SpanID)
+		}
+		// The legacy field is read, not written.
+		if got := gjson.GetBytes(out, "__trace__.spanId").String(); got == span.SpanID {
+			t.Fatalf("output %s rewrote __trace__", out)
+		}
+	}
+}
+
+func TestTrace_DisabledLeavesMessageAlone(t *testing.T) {
+	SetSpanExporter(nil)
+	if TracingEnabled() {
+		t.Fatal("tracing enabled without an exporter")
+	}
+	ctx, span := StartSpan(context.Background(), "x")
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Fatal("StartSpan made a span with tracing off")
+	}
+	span.SetAttribute("k", 1).Finish(nil) // nil-safe
+}
+
+func TestTrace_JSONLinesExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	exp, err := NewJSONLinesExporter(path)
+	if err != nil {
+		t.Fatalf("NewJSONLinesExporter: %v", err)
+	}
+	useExporter(t, exp)
+
+	ctx, parent := StartSpan(context.Background(), "parent")
+	_, child := StartSpan(ctx, "child")
+	child.Finish(nil)
+	parent.Finish(nil)
+	SetSpanExporter(nil) // closes the file
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var spans []Span
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var s Span
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		spans = append(spans, s)
+	}
+	if len(spans) != 2 || spans[0].Name != "child" || spans[1].Name != "parent" {
+		t.Fatalf("spans = %+v", spans)
+	}
+	if spans[0].ParentID != spans[1].SpanID || spans[0].TraceID != spans[1].TraceID {
+		t.Fatalf("child %+v is not linked to parent %+v", spans[0], spans[1])
+	}
+}
@@ -0,0 +1,126 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"golang.org/x/net/context"
+)
+
+// UpdateHandler is implemented by nodes that can take a new configuration
+// while running, keeping their connections and state. On an update the
+// runtime waits for in-flight messages, copies the node into before,
+// unmarshals the new config into the node itself and calls OnUpdate on it:
+//
+//	func (n *Listener) OnUpdate(before runtime.MessageHandler) (bool, error) {
+//	    old := before.(*Listener)
+//	    if n.OptPort != old.OptPort {
+//	        return true, nil // needs a new socket: close and create again
+//	    }
+//	    n.setRate(n.OptRate)
+//	    return false, nil
+//	}
+//
+// Fields the new config leaves out keep their values, as do unexported
+// fields; before is a shallow copy. Return restart=true to have the runtime
+// close the node and create it again from the config, or an error to reject
+// the update. Either way the node gets its old values back first.
+//
+// Nodes without OnUpdate are always closed and created again.
+type UpdateHandler interface {
+	OnUpdate(before MessageHandler) (restart bool, err error)
+}
+
+// AsUpdateHandler returns the UpdateHandler for a stored handler, unwrapping
+// the ToolInterceptor and middleware, or nil when the node has none.
+func AsUpdateHandler(h MessageHandler) UpdateHandler {
+	found, ok := findHandler(h, func(h MessageHandler) bool {
+		_, ok := h.(UpdateHandler)
+		return ok
+	})
+	if !ok {
+		return nil
+	}
+	return found.(UpdateHandler)
+}
+
+// updateInPlace unmarshals config into the running node and lets it decide.
+// node.mu must be held for writing. A node it cannot copy is restarted.
+func updateInPlace(node *NodeHandler, uh UpdateHandler, config []byte) (restart bool, err error) {
+	target := UnwrapHandler(node.Handler)
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return true, nil
+	}
+
+	saved := reflect.New(v.Elem().Type())
+	saved.Elem().Set(v.Elem())
+	before := saved.Interface().(MessageHandler)
+
+	err = json.Unmarshal(config, target)
+	if err == nil {
+		err = safeCall("OnUpdate", func() error {
+			var callErr error
+			restart, callErr = uh.OnUpdate(before)
+			return callErr
+		})
+	}
+	if err != nil || restart {
+		v.Elem().Set(saved.Elem())
+		return restart && err == nil, err
+	}
+
+	n := NodeOf(target)
+	node.Node = n
+	node.retry = retryPolicyOf(target).withOverrides(n)
+	node.timeout = timeoutOf(target, n)
+	node.inputSchema = inputSchemaOf(target)
+	return false, nil
+}
+
+// createLocked calls OnCreate on a node created in place of another, with
+// its mu held so that calls queued during the update do not reach it first.
+func createLocked(node *NodeHandler) error {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return safeCall("OnCreate", node.Handler.OnCreate)
+}
+
+// recreateNode closes node and creates it again from config through the
+// factory of its type, as OnClose followed by OnCreate would.
+func (m *GRPCServer) recreateNode(ctx context.Context, node *NodeHandler, config []byte) error {
+	guid := node.GUID
+	f := GetNodeFactory(node.nodeType)
+	if f == nil {
+		return fmt.Errorf("%s factory not found", node.nodeType)
+	}
+
+	node.stop()
+	if err := safeCall("OnClose", node.Handler.OnClose); err != nil {
+		hclog.Default().Info("grpc.server.onupdate.close", "guid", guid, "err", err)
+		reportPanic(m.helperFor(node), guid, node.Name, err)
+	}
+	RemoveNodeHandler(guid)
+
+	err := safeCall("factory.OnCreate", func() error {
+		return f.OnCreate(m.withHelper(ctx), config)
+	})
+	if err == nil {
+		if created := GetNodeHandler(guid); created == nil {
+			err = fmt.Errorf("node handler not found")
+		} else if err = createLocked(created); err != nil {
+			reportPanic(m.helperFor(created), guid, created.Name, err)
+			RemoveNodeHandler(guid)
+		}
+	}
+	if err != nil {
+		// The node is gone, as if the robot had closed it.
+		if atomic.AddInt32(&nc, -1) == 0 && !sessionMode {
+			requestShutdown(exitOK)
+		}
+	}
+	return err
+}
@@ -0,0 +1,246 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/robomotionio/robomotion-go/message"
+	"github.com/robomotionio/robomotion-go/proto"
+)
+
+// fxTunable applies OptLimit in place, restarts for a negative one and
+// rejects zero. conn stands for state that must survive an update.
+type fxTunable struct {
+	Node `spec:"id=Test.Update.Tunable"`
+	fxLifecycle
+	OptLimit int `json:"optLimit"`
+
+	conn   string
+	before int
+}
+
+func (n *fxTunable) OnMessage(_ message.Context) error { return nil }
+func (n *fxTunable) OnUpdate(before MessageHandler) (bool, error) {
+	n.before = before.(*fxTunable).OptLimit
+	switch {
+	case n.OptLimit == 0:
+		return false, errors.New("limit must not be zero")
+	case n.OptLimit < 0:
+		return true, nil
+	}
+	return false, nil
+}
+
+// fxFixed has no OnUpdate; closed records that it was closed.
+type fxFixed struct {
+	Node `spec:"id=Test.Update.Fixed"`
+	fxLifecycle
+	OptLimit int `json:"optLimit"`
+	closed   bool
+}
+
+func (n *fxFixed) OnClose() error                    { n.closed = true; return nil }
+func (n *fxFixed) OnMessage(_ message.Context) error { return nil }
+
+func TestOnUpdate_AppliesInPlace(t *testing.T) {
+	t.Parallel()
+	n := &fxTunable{OptLimit: 1, conn: "open"}
+	addTestHandler(t, "update-tunable", Node{}, n)
+
+	s := &GRPCServer{}
+	resp, err := s.OnUpdate(context.Background(), &proto.OnUpdateRequest{
+		Guid:   "update-tunable",
+		Config: []byte(`{"guid":"update-tunable","optLimit":5,"continueOnError":true}`),
+	})
+	if err != nil || resp.Restarted {
+		t.Fatalf("OnUpdate = %+v, %v; want applied in place", resp, err)
+	}
+	if n.OptLimit != 5 || n.before != 1 || n.conn != "open" {
+		t.Fatalf("node after update = %+v", n)
+	}
+	if h := GetNodeHandler("update-tunable"); h.Handler == nil || !h.ContinueOnError {
+		t.Fatal("runtime kept the old Node options")
+	}
+}
+
+func TestOnUpdate_RejectedKeepsOldValues(t *testing.T) {
+	t.Parallel()
+	n := &fxTunable{OptLimit: 1}
+	addTestHandler(t, "update-reject", Node{}, n)
+
+	s := &GRPCServer{}
+	_, err := s.OnUpdate(context.Background(), &proto.OnUpdateRequest{
+		Guid:   "update-reject",
+		Config: []byte(`{"guid":"update-reject","optLimit":0}`),
+	})
+	if err == nil {
+		t.Fatal("OnUpdate accepted a rejected config")
+	}
+	if n.OptLimit != 1 {
+		t.Fatalf("OptLimit = %d after a rejected update, want 1", n.OptLimit)
+	}
+}
+
+func TestOnUpdate_RestartAndFallbackRecreate(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name, guid, nodeType string
+		node                 MessageHandler
+	}{
+		{"restart", "update-restart", "Test.Update.Tunable", &fxTunable{OptLimit: 1}},
+		{"fallback", "update-fixed", "Test.Update.Fixed", &fxFixed{OptLimit: 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			RegisterNodeFactory(tc.nodeType, &NodeFactory{Type: reflect.TypeOf(tc.node).Elem()})
+			addTestHandler(t, tc.guid, Node{}, tc.node)
+
+			s := &GRPCServer{}
+			resp, err := s.OnUpdate(context.Background(), &proto.OnUpdateRequest{
+				Guid:   tc.guid,
+				Config: []byte(`{"guid":"` + tc.guid + `","optLimit":-1}`),
+			})
+			if err != nil || !resp.Restarted {
+				t.Fatalf("OnUpdate = %+v, %v; want restarted", resp, err)
+			}
+			created := UnwrapHandler(GetNodeHandler(tc.guid).Handler)
+			if created == tc.node {
+				t.Fatal("node was not created again")
+			}
+			if got := NodeOf(created).GUID; got != tc.guid {
+				t.Fatalf("recreated node GUID = %q", got)
+			}
+			if f, ok := tc.node.(*fxFixed); ok && !f.closed {
+				t.Fatal("old node was not closed")
+			}
+		})
+	}
+}
+
+// fxRestarting restarts on every update. OnClose tells closing it started
+// and waits for release; OnMessage writes the instance's OptLimit.
+type fxRestarting struct {
+	Node `spec:"id=Test.Update.Restarting"`
+	fxLifecycle
+	OptLimit int `json:"optLimit"`
+
+	closing, release chan struct{}
+}
+
+func (n *fxRestarting) OnMessage(ctx message.Context) error   { return ctx.Set("limit", n.OptLimit) }
+func (n *fxRestarting) OnUpdate(MessageHandler) (bool, error) { return true, nil }
+func (n *fxRestarting) OnClose() error {
+	if n.closing != nil {
+		close(n.closing)
+		<-n.release
+	}
+	return nil
+}
+
+func TestOnUpdate_QueuedMessageReachesRecreatedNode(t *testing.T) {
+	t.Parallel()
+	RegisterNodeFactory("Test.Update.Restarting", &NodeFactory{Type: reflect.TypeOf(fxRestarting{})})
+	closing, release := make(chan struct{}), make(chan struct{})
+	addTestHandler(t, "update-queued", Node{}, &fxRestarting{OptLimit: 1, closing: closing, release: release})
+
+	s := &GRPCServer{}
+	updated := make(chan error, 1)
+	go func() {
+		_, err := s.OnUpdate(context.Background(), &proto.OnUpdateRequest{
+			Guid:   "update-queued",
+			Config: []byte(`{"guid":"update-queued","optLimit":2}`),
+		})
+		updated <- err
+	}()
+
+	// The message arrives while the old node is closing, and waits.
+	<-closing
+	type result struct {
+		resp *proto.OnMessageResponse
+		err  error
+	}
+	handled := make(chan result, 1)
+	go func() {
+		resp, err := s.OnMessage(context.Background(), onMessageRequest(t, "update-queued", `{}`))
+		handled <- result{resp, err}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if err := <-updated; err != nil {
+		t.Fatalf("OnUpdate: %v", err)
+	}
+	r := <-handled
+	if r.err != nil {
+		t.Fatalf("queued OnMessage: %v", r.err)
+	}
+	if got := payloadOf(r.resp.OutMessage); got != `{"limit":2}` {
+		t.Fatalf("queued message ran on %s, want the recreated node", got)
+	}
+}
+
+func TestOnUpdate_GivesUpWithItsContext(t *testing.T) {
+	t.Parallel()
+	n, started, seen := fxBlocking()
+	addTestHandler(t, "update-busy", Node{}, n)
+
+	s := &GRPCServer{}
+	msgCtx, stop := context.WithCancel(context.Background())
+	go s.OnMessage(msgCtx, onMessageRequest(t, "update-busy", `{}`))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := s.OnUpdate(ctx, &proto.OnUpdateRequest{Guid: "update-busy", Config: []byte(`{"guid":"update-busy"}`)})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("OnUpdate on a busy node = %v, want DeadlineExceeded", err)
+	}
+
+	stop()
+	<-seen
+	_, unlock, err := lockNodeHandler(context.Background(), "update-busy", true)
+	if err != nil {
+		t.Fatalf("lock after the message: %v", err)
+	}
+	unlock()
+}
+
+// fxPaced closes handled when its OnMessage returns and applies updates in
+// place.
+type fxPaced struct {
+	Node `spec:"id=Test.Update.Paced"`
+	fxLifecycle
+	handled chan struct{}
+}
+
+func (n *fxPaced) OnMessage(_ message.Context) error            { close(n.handled); return nil }
+func (n *fxPaced) OnUpdate(before MessageHandler) (bool, error) { return false, nil }
+
+func TestOnUpdate_DoesNotWaitOutDelayAfter(t *testing.T) {
+	t.Parallel()
+	n := &fxPaced{handled: make(chan struct{})}
+	addTestHandler(t, "update-paced", Node{DelayAfter: 60}, n)
+
+	s := &GRPCServer{}
+	msgCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.OnMessage(msgCtx, onMessageRequest(t, "update-paced", `{}`))
+		close(done)
+	}()
+	<-n.handled
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := s.OnUpdate(ctx, &proto.OnUpdateRequest{Guid: "update-paced", Config: []byte(`{"guid":"update-paced"}`)})
+	if err != nil {
+		t.Fatalf("OnUpdate during DelayAfter: %v", err)
+	}
+	stop()
+	<-done
+}
@@ -1,11 +1,8 @@
 package runtime
 
 import (
-	"encoding/json"
 	"fmt"
 	"reflect"
-	"strconv"
-	"strings"
 
 	"github.com/robomotionio/robomotion-go/message"
 	"github.com/robomotionio/robomotion-go/runtime/lmo"
@@ -38,227 +35,31 @@ func (v *Variable[T]) IsNil() bool {
 	return v.Name == nil
 }
 
-func (v *InVariable[T]) getInt(val interface{}) (t T, err error) {
-
-	switch v := val.(type) {
-	case int64:
-		reflect.ValueOf(&t).Elem().SetInt(v)
-	case float64:
-		reflect.ValueOf(&t).Elem().SetInt(int64(v))
-	case string:
-		var d int64
-		d, err = strconv.ParseInt(v, 10, 64)
-		if err == nil {
-			reflect.ValueOf(&t).Elem().SetInt(d)
-		}
-	case map[string]interface{}:
-		for _, val := range v {
-			dval, ok := val.(int64)
-			if !ok {
-				continue
-			}
-
-			reflect.ValueOf(&t).Elem().SetInt(dval)
-		}
-	}
-
-	return t, err
-}
-
-func (v *InVariable[T]) getIntPtr(val interface{}) (t T, err error) {
-	switch v := val.(type) {
-	case int64:
-		reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&v))
-	case float64:
-		var d int64
-		d = int64(v)
-		reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&d))
-	case string:
-		if v == "" {
-			return
-		}
-		var d int64
-		d, err = strconv.ParseInt(v, 10, 64)
-		if err == nil {
-			reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&d))
-		}
-	case map[string]interface{}:
-		for _, val := range v {
-			dval, ok := val.(int64)
-			if !ok {
-				continue
-			}
-
-			reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&dval))
-		}
-	}
-	return t, err
-}
-
-func (v *InVariable[T]) getFloat(val interface{}) (t T, err error) {
-	switch v := val.(type) {
-	case int64:
-		reflect.ValueOf(&t).Elem().SetFloat(float64(v))
-	case float64:
-		reflect.ValueOf(&t).Elem().SetFloat(v)
-	case string:
-		var d float64
-		d, err = strconv.ParseFloat(v, 64)
-		if err == nil {
-			reflect.ValueOf(&t).Elem().SetFloat(d)
-		}
-	case map[string]interface{}:
-		for _, val := range v {
-			fval, ok := val.(float64)
-			if !ok {
-				continue
-			}
-
-			reflect.ValueOf(&t).Elem().SetFloat(fval)
-		}
-	}
-
-	return t, err
-}
-
-func (v *InVariable[T]) getBool(val interface{}) (t T, err error) {
-	switch v := val.(type) {
-	case int64:
-		reflect.ValueOf(&t).Elem().SetBool(v > 0)
-	case float64:
-		reflect.ValueOf(&t).Elem().SetBool(v > 0)
-	case bool:
-		reflect.ValueOf(&t).Elem().SetBool(v)
-	case string:
-		var d bool
-		d, err = strconv.ParseBool(v)
-		if err == nil {
-			reflect.ValueOf(&t).Elem().SetBool(d)
-		}
-	case map[string]interface{}:
-		for _, val := range v {
-			bval, ok := val.(bool)
-			if !ok {
-				continue
-			}
-
-			reflect.ValueOf(&t).Elem().SetBool(bval)
-		}
-	}
-
-	return t, err
-}
-
-func (v *InVariable[T]) getBoolPtr(val interface{}) (t T, err error) {
-	switch v := val.(type) {
-	case int64:
-		reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(v > 0))
-	case float64:
-		reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(v > 0))
-	case bool:
-		reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&v))
-	case string:
-		if v == "" {
-			return
-		}
-		var d bool
-		d, err = strconv.ParseBool(v)
-		if err == nil {
-			reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&d))
-		}
-	case map[string]interface{}:
-		for _, val := range v {
-			bval, ok := val.(bool)
-			if !ok {
-				continue
-			}
-
-			reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&bval))
-		}
-	}
-	return t, err
-}
-
-func (v *InVariable[T]) getString(val interface{}) (t T, err error) {
-	switch v := val.(type) {
-	case string:
-		reflect.ValueOf(&t).Elem().SetString(v)
-	case map[string]interface{}:
-		for _, val := range v {
-			sval, ok := val.(string)
-			if !ok {
-				continue
-			}
-
-			reflect.ValueOf(&t).Elem().SetString(sval)
-		}
+// name returns Name as a message path or variable name. Only Custom scope
+// keeps a literal value of another type in Name; for every other scope a
+// non-string Name is a broken node config and must not panic.
+func (v *Variable[T]) name() (string, error) {
+	name, ok := v.Name.(string)
+	if !ok {
+		return "", NewError("ErrInvalidArg", fmt.Sprintf("%s scope variable needs a string name, got %T", v.Scope, v.Name))
 	}
-
-	return t, err
+	return name, nil
 }
 
-// getSlice handles conversion of various input types to slice types (e.g. []string).
-// Supports: JSON array strings ("[\"a\",\"b\"]"), comma-separated strings ("a,b,c"),
-// and values already in slice form.
-func (v *InVariable[T]) getSlice(val interface{}) (t T, err error) {
-	s, isStr := val.(string)
-	if isStr {
-		s = strings.TrimSpace(s)
-		// Try JSON array first
-		if strings.HasPrefix(s, "[") {
-			if err := json.Unmarshal([]byte(s), &t); err == nil {
-				return t, nil
-			}
-		}
-		// Comma-separated: split and set
-		parts := strings.Split(s, ",")
-		elemType := reflect.TypeOf(t).Elem()
-		slice := reflect.MakeSlice(reflect.TypeOf(t), 0, len(parts))
-		for _, p := range parts {
-			p = strings.TrimSpace(p)
-			elem := reflect.New(elemType).Elem()
-			switch elemType.Kind() {
-			case reflect.String:
-				elem.SetString(p)
-			default:
-				// For non-string element types, try JSON unmarshal
-				tmp := reflect.New(elemType).Interface()
-				if err := json.Unmarshal([]byte(p), tmp); err != nil {
-					return t, fmt.Errorf("cannot convert %q to %s", p, elemType)
-				}
-				elem.Set(reflect.ValueOf(tmp).Elem())
-			}
-			slice = reflect.Append(slice, elem)
-		}
-		reflect.ValueOf(&t).Elem().Set(slice)
-		return t, nil
-	}
-
-	// Already a slice or other type: use JSON roundtrip
-	d, err := json.Marshal(val)
-	if err != nil {
-		return t, err
+// describe names the variable in conversion errors.
+func (v *Variable[T]) describe() string {
+	if v.Scope == "Custom" {
+		return "custom value"
 	}
-	err = json.Unmarshal(d, &t)
-	return t, err
+	return fmt.Sprintf("%s.%v", v.Scope, v.Name)
 }
 
-func (v *InVariable[T]) getStringPtr(val interface{}) (t T, err error) {
-	switch v := val.(type) {
-	case string:
-		reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&v))
-	case map[string]interface{}:
-		for _, val := range v {
-			sval, ok := val.(string)
-			if !ok {
-				continue
-			}
-
-			reflect.ValueOf(&t).Elem().Set(reflect.ValueOf(&sval))
-		}
+// typeName is reflect.TypeOf(val).String() that tolerates nil.
+func typeName(val interface{}) string {
+	if val == nil {
+		return "nil"
 	}
-
-	return t, err
+	return reflect.TypeOf(val).String()
 }
 
 func (v *InVariable[T]) Get(ctx message.Context) (T, error) {
@@ -271,163 +72,108 @@ func (v *InVariable[T]) Get(ctx message.Context) (T, error) {
 		return t, nil
 	}
 
-	if v.Scope == "Custom" {
+	switch v.Scope {
+	case "Custom":
 		val = v.Name
-	}
 
-	if v.Scope == "Message" || v.Scope == "AI" {
+	case "Message", "AI":
+		name, err := v.name()
+		if err != nil {
+			return t, err
+		}
+
 		// AI scope works like Message scope for retrieving values
 		// When AI tools call nodes, parameters are passed in the message context
-		val = ctx.Get(v.Name.(string))
-		
+		val = ctx.Get(name)
+
 		// For AI scope, if not found at top level, check __parameters__ object
 		if val == nil && v.Scope == "AI" {
 			// Check if this is a tool request
 			if msgType := ctx.Get("__message_type__"); msgType == "tool_request" {
 				if params := ctx.Get("__parameters__"); params != nil {
 					if paramsMap, ok := params.(map[string]interface{}); ok {
-						val = paramsMap[v.Name.(string)]
+						val = paramsMap[name]
 					}
 				}
 			}
 		}
-		
+
 		if val == nil {
 			return t, nil
 		}
 
-		if lmo.IsBlobRefMap(val) {
-			resolved, err := ResolveBlobRefValue(val.(map[string]interface{}))
-			if err != nil {
-				return t, err
-			}
-			d, err := json.Marshal(resolved)
-			if err != nil {
-				return t, err
-			}
-			if err := json.Unmarshal(d, &t); err != nil {
-				return t, err
-			}
-			return t, nil
+	default:
+		h := helperOf(ctx)
+		if h == nil {
+			return t, errNotInitialized
 		}
-
-	}
-
-	kind := reflect.Invalid
-	typ := reflect.TypeOf(t)
-	if typ == nil {
-		// For nil-able types like slices/maps/pointers, TypeOf(zero) returns nil.
-		// Use the type parameter's concrete type instead.
-		typ = reflect.TypeOf((*T)(nil)).Elem()
-	}
-	kind = typ.Kind()
-
-	if val != nil {
-		switch kind {
-		case reflect.Ptr:
-			switch typ.Elem().Kind() {
-			case reflect.Bool:
-				return v.getBoolPtr(val)
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				return v.getIntPtr(val)
-			case reflect.String:
-				return v.getStringPtr(val)
-			}
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			return v.getInt(val)
-
-		case reflect.Float32, reflect.Float64:
-			return v.getFloat(val)
-
-		case reflect.Bool:
-			return v.getBool(val)
-
-		case reflect.String:
-			return v.getString(val)
-
-		case reflect.Slice:
-			return v.getSlice(val)
-
-		default:
-
-			d, err := json.Marshal(val)
-			if err != nil {
-				return t, err
-			}
-
-			err = json.Unmarshal(d, &t)
-			if err != nil {
-				return t, err
-			}
-			return t, nil
+		name, err := v.name()
+		if err != nil {
+			return t, err
 		}
-	}
-	if client == nil {
-		return t, fmt.Errorf("Runtime was not initialized")
-	}
-	payload, err := ctx.GetRaw()
-	if err != nil {
-		return t, err
-	}
-	val, err = client.GetVariable(&variable{Scope: v.Scope, Name: v.Name.(string), Payload: payload})
-	if err != nil {
-		return t, err
-	}
-
-	if lmo.IsBlobRefMap(val) {
-		resolved, err := ResolveBlobRefValue(val.(map[string]interface{}))
+		payload, err := ctx.GetRaw()
 		if err != nil {
 			return t, err
 		}
-		d, err := json.Marshal(resolved)
+		val, err = h.GetVariable(&variable{Scope: v.Scope, Name: name, Payload: payload})
 		if err != nil {
 			return t, err
 		}
-		if err := json.Unmarshal(d, &t); err != nil {
+	}
+
+	if lmo.IsBlobRefMap(val) {
+		resolved, err := resolveBlobRef(ctx, val.(map[string]interface{}))
+		if err != nil {
 			return t, err
 		}
-		return t, nil
+		val = resolved
 	}
 
-	t, ok := val.(T)
-	if !ok {
-		return t, fmt.Errorf("expected %s but got %s",
-			reflect.TypeOf(t).String(),
-			reflect.TypeOf(val).String(),
-		)
+	// See convert.go for the conversions.
+	out, err := convertTo(val, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return t, WrapError(ErrInvalidInput, fmt.Errorf("%s: %w", v.describe(), err))
 	}
+	reflect.ValueOf(&t).Elem().Set(out)
 	return t, nil
 }
 
 func (v *OutVariable[T]) Set(ctx message.Context, value T) error {
 
+	name, err := v.name()
+	if err != nil {
+		return err
+	}
+
+	// In the encodings InVariable.Get reads back; see convert.go.
+	encoded := encodeValue(value)
+
 	if v.Scope == "Message" || v.Scope == "AI" {
 		// AI scope works like Message scope for setting values
-		if v.Name == "" {
+		if name == "" {
 			return fmt.Errorf("Empty message object")
 		}
 		if HasCapability(CapabilityLMO) {
-			if packed, ok, err := PackValue(value); err != nil {
+			if packed, ok, err := packValue(ctx, encoded); err != nil {
 				return err
 			} else if ok {
-				return ctx.Set(v.Name.(string), packed)
+				return ctx.Set(name, packed)
 			}
 		}
-		return ctx.Set(v.Name.(string), value)
+		return ctx.Set(name, encoded)
 	}
 
-	if client == nil {
-		return fmt.Errorf("Runtime was not initialized")
+	h := helperOf(ctx)
+	if h == nil {
+		return errNotInitialized
 	}
 
 	if HasCapability(CapabilityLMO) {
-		if packed, ok, err := PackValue(value); err != nil {
+		if packed, ok, err := packValue(ctx, encoded); err != nil {
 			return err
 		} else if ok {
-			return client.SetVariable(&variable{Scope: v.Scope, Name: v.Name.(string)}, packed)
+			return h.SetVariable(&variable{Scope: v.Scope, Name: name}, packed)
 		}
 	}
-	return client.SetVariable(&variable{Scope: v.Scope, Name: v.Name.(string)}, value)
+	return h.SetVariable(&variable{Scope: v.Scope, Name: name}, encoded)
 }
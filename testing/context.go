@@ -21,7 +21,9 @@
 package testing
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"sync"
 
 	"github.com/robomotionio/robomotion-go/message"
@@ -35,6 +37,13 @@ type MockContext struct {
 	mu   sync.RWMutex
 	data []byte
 	id   string
+	ctx  context.Context
+	meta message.Meta
+	orig []byte // the data Changes compares against
+
+	routed bool
+	ports  []int
+	extra  []message.Output
 }
 
 // NewMockContext creates a new MockContext with optional initial data.
@@ -47,8 +56,9 @@ func NewMockContext(initialData ...map[string]interface{}) *MockContext {
 	if len(initialData) > 0 && initialData[0] != nil {
 		data, err := json.Marshal(initialData[0])
 		if err == nil {
-			ctx.data = data
+			ctx.data, ctx.meta = message.SplitMeta(data)
 		}
+		ctx.orig = ctx.data
 		// Extract ID if present
 		if id, ok := initialData[0]["id"].(string); ok {
 			ctx.id = id
@@ -60,9 +70,15 @@ func NewMockContext(initialData ...map[string]interface{}) *MockContext {
 
 // NewMockContextFromJSON creates a MockContext from raw JSON bytes.
 func NewMockContextFromJSON(jsonData []byte) *MockContext {
+	data, meta := message.SplitMeta(jsonData)
 	ctx := &MockContext{
-		data: jsonData,
-		id:   gjson.GetBytes(jsonData, "id").String(),
+		data: data,
+		id:   gjson.GetBytes(data, "id").String(),
+		meta: meta,
+		orig: data,
+	}
+	if ctx.id == "" {
+		ctx.id = meta.ID
 	}
 	return ctx
 }
@@ -77,8 +93,10 @@ func (m *MockContext) Set(path string, value interface{}) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	path = convertPath(path)
-	var err error
+	path, err := message.ConvertPath(m.data, path)
+	if err != nil {
+		return err
+	}
 	m.data, err = sjson.SetBytes(m.data, path, value)
 	return err
 }
@@ -88,8 +106,7 @@ func (m *MockContext) Get(path string) interface{} {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	path = convertPath(path)
-	return gjson.GetBytes(m.data, path).Value()
+	return m.get(path).Value()
 }
 
 // GetString retrieves a string value from the given path.
@@ -97,8 +114,7 @@ func (m *MockContext) GetString(path string) string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	path = convertPath(path)
-	return gjson.GetBytes(m.data, path).String()
+	return m.get(path).String()
 }
 
 // GetBool retrieves a boolean value from the given path.
@@ -106,8 +122,7 @@ func (m *MockContext) GetBool(path string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	path = convertPath(path)
-	return gjson.GetBytes(m.data, path).Bool()
+	return m.get(path).Bool()
 }
 
 // GetInt retrieves an integer value from the given path.
@@ -115,8 +130,7 @@ func (m *MockContext) GetInt(path string) int64 {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	path = convertPath(path)
-	return gjson.GetBytes(m.data, path).Int()
+	return m.get(path).Int()
 }
 
 // GetFloat retrieves a float value from the given path.
@@ -124,8 +138,20 @@ func (m *MockContext) GetFloat(path string) float64 {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	path = convertPath(path)
-	return gjson.GetBytes(m.data, path).Float()
+	return m.get(path).Float()
+}
+
+// Lookup returns the JSON at path and whether the path exists; it makes
+// message.GetAs tell a missing path from a null value.
+func (m *MockContext) Lookup(path string) (json.RawMessage, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := m.get(path)
+	if !result.Exists() {
+		return nil, false
+	}
+	return json.RawMessage(result.Raw), true
 }
 
 // GetRaw returns the raw JSON bytes.
@@ -160,7 +186,7 @@ func (m *MockContext) SetRaw(data json.RawMessage, options ...message.SetOption)
 		}
 	}
 
-	m.data = data
+	m.data, _ = message.SplitMeta(data)
 	return nil
 }
 
@@ -172,6 +198,212 @@ func (m *MockContext) IsEmpty() bool {
 	return m.data == nil || len(m.data) == 0
 }
 
+// Has reports whether path exists, even if it holds null.
+func (m *MockContext) Has(path string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if path == "" {
+		return len(m.data) > 0
+	}
+	return m.get(path).Exists()
+}
+
+// Delete removes path; a missing path is not an error.
+func (m *MockContext) Delete(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path, err := message.ConvertPath(m.data, path)
+	if err != nil {
+		return err
+	}
+	m.data, err = sjson.DeleteBytes(m.data, path)
+	return err
+}
+
+// Keys lists the keys of the object at path, "" for the top level.
+func (m *MockContext) Keys(path string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	path, err := message.ConvertPath(m.data, path)
+	if err != nil {
+		return nil
+	}
+	return message.KeysJSON(m.data, path)
+}
+
+// Clone returns an independent copy with the same ID and context, and no
+// routing.
+func (m *MockContext) Clone() message.Context {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data := make([]byte, len(m.data))
+	copy(data, m.data)
+	return &MockContext{data: data, id: m.id, ctx: m.ctx, meta: m.meta, orig: m.orig}
+}
+
+// Merge sets each top-level key of object under the object at path.
+func (m *MockContext) Merge(path string, object interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path, err := message.ConvertPath(m.data, path)
+	if err != nil {
+		return err
+	}
+	data, err := message.MergeJSON(m.data, path, object)
+	if err != nil {
+		return err
+	}
+	m.data = data
+	return nil
+}
+
+// Append adds value to the end of the array at path.
+func (m *MockContext) Append(path string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path, err := message.ConvertPath(m.data, path)
+	if err != nil {
+		return err
+	}
+	data, err := message.AppendJSON(m.data, path, value)
+	if err != nil {
+		return err
+	}
+	m.data = data
+	return nil
+}
+
+// SetBytes stores binary data at path, in an LMO blob when the runtime
+// package is linked in and has a store, as base64 otherwise.
+func (m *MockContext) SetBytes(path string, data []byte, mime string) error {
+	value, err := message.BytesValue(data, mime)
+	if err != nil {
+		return err
+	}
+	return m.Set(path, value)
+}
+
+// OpenReader streams the binary data at path.
+func (m *MockContext) OpenReader(path string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	value := m.get(path)
+	m.mu.RUnlock()
+
+	return message.OpenBytes(path, value)
+}
+
+// Query runs a gjson query over the data; see message.Context.Query.
+func (m *MockContext) Query(expr string) (message.Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	path, err := message.ConvertPath(m.data, expr)
+	if err != nil {
+		return message.Result{}, err
+	}
+	return message.QueryJSON(m.data, path), nil
+}
+
+// Context returns the cancellation context set with SetContext, or
+// context.Background() when none was set.
+func (m *MockContext) Context() context.Context {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// SetContext sets the context returned by Context. Use it to test how a node
+// reacts to a stopped flow or an expired deadline.
+func (m *MockContext) SetContext(ctx context.Context) *MockContext {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ctx = ctx
+	return m
+}
+
+// Meta returns the envelope set with SetMeta or received in the initial
+// JSON, its missing headers read from the legacy payload fields.
+func (m *MockContext) Meta() message.Meta {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return message.FillMeta(m.meta, m.data)
+}
+
+// SetMeta sets the envelope, as the runtime does for incoming messages. Use
+// it to test nodes that read the message's origin, trace or tool routing.
+func (m *MockContext) SetMeta(meta message.Meta) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.meta = meta
+	if m.id == "" {
+		m.id = meta.ID
+	}
+}
+
+// Changes lists the paths added, changed or removed since the context was
+// made or last passed to ResetChanges; see message.Context.Changes.
+func (m *MockContext) Changes() []message.Change {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return message.DiffJSON(m.orig, m.data)
+}
+
+// ResetChanges takes the current data as the message the node received, so
+// Changes lists only what is done from here on. Harness.Run calls it after
+// the inputs are set.
+func (m *MockContext) ResetChanges() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.orig = m.data
+}
+
+// RouteTo sends this message out of ports instead of port 0.
+func (m *MockContext) RouteTo(ports ...int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.routed = true
+	m.ports = append(m.ports[:0], ports...)
+}
+
+// SendTo sends msg out of port in addition to this message.
+func (m *MockContext) SendTo(port int, msg message.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.extra = append(m.extra, message.Output{Port: port, Message: msg})
+}
+
+// Outputs lists every message the node sent, with the port it went to.
+func (m *MockContext) Outputs() []message.Output {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var outs []message.Output
+	if !m.routed {
+		outs = append(outs, message.Output{Port: 0, Message: m})
+	}
+	for _, port := range m.ports {
+		outs = append(outs, message.Output{Port: port, Message: m})
+	}
+	return append(outs, m.extra...)
+}
+
 // GetAll returns all data as a map.
 func (m *MockContext) GetAll() map[string]interface{} {
 	m.mu.RLock()
@@ -192,18 +424,12 @@ func (m *MockContext) GetJSON() []byte {
 	return data
 }
 
-// convertPath converts bracket notation to dot notation for gjson/sjson.
-func convertPath(path string) string {
-	result := make([]byte, 0, len(path))
-	for i := 0; i < len(path); i++ {
-		switch path[i] {
-		case '[':
-			result = append(result, '.')
-		case ']':
-			// skip
-		default:
-			result = append(result, path[i])
-		}
+// get returns the value at path, m.mu held. Paths follow the grammar of
+// message.ConvertPath; one it rejects has no value.
+func (m *MockContext) get(path string) gjson.Result {
+	path, err := message.ConvertPath(m.data, path)
+	if err != nil {
+		return gjson.Result{}
 	}
-	return string(result)
+	return gjson.GetBytes(m.data, path)
 }
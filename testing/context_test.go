@@ -0,0 +1,19 @@
+package testing_test
+
+import (
+	"testing"
+
+	rtesting "github.com/robomotionio/robomotion-go/testing"
+)
+
+func TestMockContext_MergeIntoEmptyMessage(t *testing.T) {
+	for _, raw := range [][]byte{nil, []byte("")} {
+		ctx := rtesting.NewMockContextFromJSON(raw)
+		if err := ctx.Merge("", map[string]int{"n": 1}); err != nil {
+			t.Fatalf("Merge into %q: %v", raw, err)
+		}
+		if ctx.GetInt("n") != 1 {
+			t.Errorf("after Merge into %q: n = %v", raw, ctx.Get("n"))
+		}
+	}
+}
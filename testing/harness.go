@@ -1,9 +1,13 @@
 package testing
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/robomotionio/robomotion-go/message"
+	"github.com/robomotionio/robomotion-go/runtime"
 )
 
 // MessageHandler is the interface that all Robomotion nodes implement.
@@ -21,6 +25,10 @@ type Harness struct {
 	ctx     *MockContext
 	inputs  map[string]interface{}
 	created bool
+	helper  runtime.RuntimeHelper
+	// helperCtx is the message context's context as prepare wrapped it
+	// with helper, so repeated runs wrap it only once.
+	helperCtx context.Context
 }
 
 // NewHarness creates a new test harness for the given node.
@@ -54,6 +62,56 @@ func (h *Harness) WithContext(ctx *MockContext) *Harness {
 	return h
 }
 
+// WithParentContext sets the cancellation context the node sees through
+// ctx.Context() during Run.
+//
+// Example:
+//
+//	cctx, cancel := context.WithCancel(context.Background())
+//	cancel()
+//	err := h.WithParentContext(cctx).Run() // node should return context.Canceled
+func (h *Harness) WithParentContext(ctx context.Context) *Harness {
+	h.ctx.SetContext(ctx)
+	return h
+}
+
+// WithCredentials gives this harness's node its own mock vault, so tests
+// using credentials can run with t.Parallel instead of sharing the
+// package-level store set by InitCredentials.
+func (h *Harness) WithCredentials(store *CredentialStore) *Harness {
+	return h.WithRuntimeHelper(runtime.NewTestHelper(&mockHelper{store: store}))
+}
+
+// WithRuntimeHelper sets the RuntimeHelper the node sees during Run, both
+// through its embedded runtime.Node and through the message context.
+func (h *Harness) WithRuntimeHelper(helper runtime.RuntimeHelper) *Harness {
+	h.helper = helper
+	h.helperCtx = nil
+	return h
+}
+
+// prepare hands the harness's RuntimeHelper, if any, to the node and the
+// message context before a run, and takes the inputs as the message the
+// node received.
+func (h *Harness) prepare() {
+	h.ctx.ResetChanges()
+	if h.helper == nil {
+		return
+	}
+	v := reflect.ValueOf(h.node)
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		if f := v.Elem().FieldByName("Node"); f.IsValid() && f.CanAddr() {
+			if n, ok := f.Addr().Interface().(*runtime.Node); ok {
+				n.SetHelper(h.helper)
+			}
+		}
+	}
+	if parent := h.ctx.Context(); parent != h.helperCtx {
+		h.helperCtx = runtime.WithRuntimeHelper(parent, h.helper)
+		h.ctx.SetContext(h.helperCtx)
+	}
+}
+
 // Context returns the underlying MockContext.
 func (h *Harness) Context() *MockContext {
 	return h.ctx
@@ -154,6 +212,7 @@ func normalizeNumericValue(value interface{}) interface{} {
 // Run executes the node's OnMessage method with the configured context.
 // It returns any error from OnMessage.
 func (h *Harness) Run() error {
+	h.prepare()
 	if !h.created {
 		if err := h.node.OnCreate(); err != nil {
 			return err
@@ -165,6 +224,7 @@ func (h *Harness) Run() error {
 
 // RunWithCreate explicitly calls OnCreate before OnMessage.
 func (h *Harness) RunWithCreate() error {
+	h.prepare()
 	if err := h.node.OnCreate(); err != nil {
 		return err
 	}
@@ -174,6 +234,7 @@ func (h *Harness) RunWithCreate() error {
 
 // RunFull runs the complete node lifecycle: OnCreate, OnMessage, OnClose.
 func (h *Harness) RunFull() error {
+	h.prepare()
 	if err := h.node.OnCreate(); err != nil {
 		return err
 	}
@@ -213,6 +274,132 @@ func (h *Harness) GetAllOutputs() map[string]interface{} {
 	return h.ctx.GetAll()
 }
 
+// Outputs returns every message the node sent during Run, with the output
+// port each went to. A node that did not route returns its context on port 0.
+func (h *Harness) Outputs() []message.Output {
+	return h.ctx.Outputs()
+}
+
+// OutputsOn returns the messages the node sent out of port.
+//
+// Example:
+//
+//	err := h.WithInput("status", 500).Run()
+//	require.Len(t, h.OutputsOn(1), 1) // routed to the failure port
+func (h *Harness) OutputsOn(port int) []message.Context {
+	var msgs []message.Context
+	for _, out := range h.ctx.Outputs() {
+		if out.Port == port {
+			msgs = append(msgs, out.Message)
+		}
+	}
+	return msgs
+}
+
+// Changes lists the paths of the message the node added, changed or removed
+// during Run.
+func (h *Harness) Changes() []message.Change {
+	return h.ctx.Changes()
+}
+
+// ChangeAt returns the change at path, for checking its kind or size delta.
+func (h *Harness) ChangeAt(path string) (message.Change, bool) {
+	path = h.changePath(path)
+	for _, c := range h.ctx.Changes() {
+		if c.Path == path {
+			return c, true
+		}
+	}
+	return message.Change{}, false
+}
+
+// AssertChanged returns an error unless the node added, changed or removed
+// each of paths during Run, itself or a path around or under it.
+//
+// Example:
+//
+//	require.NoError(t, h.AssertChanged("order.total", "order.items[-1]"))
+func (h *Harness) AssertChanged(paths ...string) error {
+	changes := h.ctx.Changes()
+	for _, path := range paths {
+		path = h.changePath(path)
+		found := false
+		for _, c := range changes {
+			found = found || pathUnder(c.Path, path) || pathUnder(path, c.Path)
+		}
+		if !found {
+			return fmt.Errorf("%s was not changed; changes: %s", path, formatChanges(changes))
+		}
+	}
+	return nil
+}
+
+// AssertUnchanged returns an error if the node touched any of paths, or
+// anything around or under them, during Run; with no paths, if it touched the
+// message at all.
+func (h *Harness) AssertUnchanged(paths ...string) error {
+	changes := h.ctx.Changes()
+	if len(paths) == 0 && len(changes) > 0 {
+		return fmt.Errorf("message was changed: %s", formatChanges(changes))
+	}
+	for _, path := range paths {
+		path = h.changePath(path)
+		for _, c := range changes {
+			if pathUnder(c.Path, path) || pathUnder(path, c.Path) {
+				return fmt.Errorf("%s was changed: %s", path, formatChanges([]message.Change{c}))
+			}
+		}
+	}
+	return nil
+}
+
+// AssertOnlyChanged returns an error if the node touched anything but paths
+// and what is under them during Run.
+func (h *Harness) AssertOnlyChanged(paths ...string) error {
+	allowedPaths := make([]string, len(paths))
+	for i, path := range paths {
+		allowedPaths[i] = h.changePath(path)
+	}
+	var extra []message.Change
+	for _, c := range h.ctx.Changes() {
+		allowed := false
+		for _, path := range allowedPaths {
+			allowed = allowed || pathUnder(c.Path, path)
+		}
+		if !allowed {
+			extra = append(extra, c)
+		}
+	}
+	if len(extra) > 0 {
+		return fmt.Errorf("unexpected changes: %s", formatChanges(extra))
+	}
+	return nil
+}
+
+// changePath converts path to the form of message.Change paths.
+func (h *Harness) changePath(path string) string {
+	if converted, err := message.ConvertPath(h.ctx.GetJSON(), path); err == nil {
+		return converted
+	}
+	return path
+}
+
+// pathUnder reports whether path is parent or lies under it.
+func pathUnder(path, parent string) bool {
+	return parent == "" || path == parent || strings.HasPrefix(path, parent+".")
+}
+
+func formatChanges(changes []message.Change) string {
+	if len(changes) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		parts[i] = fmt.Sprintf("%s %s (%+d)", c.Path, c.Kind, c.Delta)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // Reset clears the context and input values for reuse.
 func (h *Harness) Reset() *Harness {
 	h.ctx = NewMockContext()